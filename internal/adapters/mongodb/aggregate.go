@@ -0,0 +1,255 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Count of documents user in range of ages.
+type AgeBucket struct {
+	// Minimum age, inclusive
+	Min int `bson:"_id"`
+	// Maximum age, exclusive
+	Max int `bson:"-"`
+	// Count of documents
+	Count int64 `bson:"count"`
+}
+
+// Count of documents user with domain of email.
+type DomainCount struct {
+	// Domain of email in lower case
+	Domain string `bson:"_id"`
+	// Count of documents
+	Count int64 `bson:"count"`
+}
+
+// Check, that results is pointer to slice. Returns error.
+//
+// Params:
+//
+//	results - pointer to slice of results
+func checkResults(results interface{}) error {
+
+	v := reflect.ValueOf(results)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return ErrNotCorrectResults
+	}
+
+	return nil
+}
+
+// Check boundaries of buckets of ages. Returns error.
+//
+// Params:
+//
+//	boundaries - boundaries of buckets
+func checkBoundaries(boundaries []int) error {
+
+	if len(boundaries) < 2 {
+		return ErrValueBoundaries
+	}
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i] <= boundaries[i-1] {
+			return ErrValueBoundaries
+		}
+	}
+
+	return nil
+}
+
+// Buckets of ages by boundaries with counts of found buckets. Returns buckets.
+//
+// Params:
+//
+//	boundaries - boundaries of buckets
+//	found - buckets with documents
+func ageBuckets(boundaries []int, found []AgeBucket) []AgeBucket {
+
+	counts := map[int]int64{}
+	for _, b := range found {
+		counts[b.Min] += b.Count
+	}
+
+	buckets := make([]AgeBucket, 0, len(boundaries)-1)
+	for i := 0; i+1 < len(boundaries); i++ {
+		buckets = append(buckets, AgeBucket{Min: boundaries[i], Max: boundaries[i+1], Count: counts[boundaries[i]]})
+	}
+
+	return buckets
+}
+
+// Run pipeline of aggregation on collection and decode results to slice. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+//	results - pointer to slice of results
+func (m *mongoDB) Aggregate(ctx context.Context, collectionName string, p *Pipeline, results interface{}) error {
+
+	// Check
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	if m.connect == nil {
+		return ErrNilPtrConnect
+	}
+	if collectionName == "" {
+		return ErrEmptyCollectionsName
+	}
+	if err := checkResults(results); err != nil {
+		return err
+	}
+	stages, err := p.Stages()
+	if err != nil {
+		return err
+	}
+
+	// Logic
+	return m.aggregate(ctx, collectionName, stages, results)
+}
+
+// Run stages of aggregation on collection and decode results. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	stages - stages of pipeline
+//	results - pointer to slice of results
+func (m *mongoDB) aggregate(ctx context.Context, collectionName string, stages mongo.Pipeline, results interface{}) error {
+
+	collection := m.db.Collection(collectionName)
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := collection.Aggregate(ctx, stages)
+	if err != nil {
+		return fmt.Errorf("Function Aggregate return error: <%w>", err)
+	}
+
+	if err := cursor.All(ctx, results); err != nil {
+		return fmt.Errorf("Function All return error: <%w>", err)
+	}
+
+	return nil
+}
+
+// Run pipeline of aggregation on collection and decode results to type R. Returns results and error.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+func AggregateAs[R any](ctx context.Context, db MongoDBI, collectionName string, p *Pipeline) ([]R, error) {
+
+	if db == nil {
+		return nil, ErrNilPtrDB
+	}
+
+	results := []R{}
+	if err := db.Aggregate(ctx, collectionName, p, &results); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// Count documents user by buckets of ages. Softly deleted documents are skipped.
+// Returns buckets in order of boundaries, buckets without documents including, and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	boundaries - ascending boundaries of buckets, bucket is [boundaries[i], boundaries[i+1])
+func (m *mongoDB) UserStatsByAgeBucket(ctx context.Context, collectionName string, boundaries []int) ([]AgeBucket, error) {
+
+	// Check
+	if m.db == nil {
+		return nil, ErrNilPtrDB
+	}
+	if m.connect == nil {
+		return nil, ErrNilPtrConnect
+	}
+	if collectionName == "" {
+		return nil, ErrEmptyCollectionsName
+	}
+	if err := checkBoundaries(boundaries); err != nil {
+		return nil, err
+	}
+
+	// Logic
+	bounds := make([]interface{}, 0, len(boundaries))
+	for _, b := range boundaries {
+		bounds = append(bounds, b)
+	}
+
+	filter := bson.M{"age": bson.M{"$gte": boundaries[0], "$lt": boundaries[len(boundaries)-1]}}
+
+	stages, err := NewPipeline().
+		Match(m.users().liveFilter(filter)).
+		Bucket(Field("age"), bounds, nil, Accumulators{"count": Count()}).
+		Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	found := []AgeBucket{}
+	if err := m.aggregate(ctx, collectionName, stages, &found); err != nil {
+		return nil, err
+	}
+
+	return ageBuckets(boundaries, found), nil
+}
+
+// Count documents user by domains of email. Softly deleted documents and documents without email are skipped.
+// Returns counts in descending order of count and ascending order of domain, and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (m *mongoDB) UserStatsByEmailDomain(ctx context.Context, collectionName string) ([]DomainCount, error) {
+
+	// Check
+	if m.db == nil {
+		return nil, ErrNilPtrDB
+	}
+	if m.connect == nil {
+		return nil, ErrNilPtrConnect
+	}
+	if collectionName == "" {
+		return nil, ErrEmptyCollectionsName
+	}
+
+	// Logic
+	filter := bson.M{"email": primitive.Regex{Pattern: "@"}}
+	domain := bson.M{"$toLower": bson.M{"$arrayElemAt": bson.A{bson.M{"$split": bson.A{Field("email"), "@"}}, -1}}}
+
+	stages, err := NewPipeline().
+		Match(m.users().liveFilter(filter)).
+		Project(bson.M{"domain": domain}).
+		Group(Field("domain"), Accumulators{"count": Count()}).
+		Sort(SortField{Field: "count", Desc: true}, SortField{Field: "_id"}).
+		Stages()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []DomainCount{}
+	if err := m.aggregate(ctx, collectionName, stages, &counts); err != nil {
+		return nil, err
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Test checkResults.
+func TestCheckResults(t *testing.T) {
+
+	var docs []bson.M
+	var doc bson.M
+	var nilPtr *[]bson.M
+
+	require.NoErrorf(t, checkResults(&docs), "Unexpected error")
+	require.Equalf(t, ErrNotCorrectResults, checkResults(docs), "Error is not equal")
+	require.Equalf(t, ErrNotCorrectResults, checkResults(&doc), "Error is not equal")
+	require.Equalf(t, ErrNotCorrectResults, checkResults(nilPtr), "Error is not equal")
+	require.Equalf(t, ErrNotCorrectResults, checkResults(nil), "Error is not equal")
+}
+
+// Test checkBoundaries and ageBuckets.
+func TestAgeBuckets(t *testing.T) {
+
+	t.Run("Not correct boundaries", func(t *testing.T) {
+
+		for _, b := range [][]int{nil, {1}, {2, 1}, {1, 1}} {
+			require.Equalf(t, ErrValueBoundaries, checkBoundaries(b), "Error is not equal")
+		}
+	})
+
+	t.Run("Buckets", func(t *testing.T) {
+
+		boundaries := []int{0, 18, 30}
+		require.NoErrorf(t, checkBoundaries(boundaries), "Unexpected error")
+
+		buckets := ageBuckets(boundaries, []AgeBucket{{Min: 18, Count: 5}})
+
+		want := []AgeBucket{{Min: 0, Max: 18, Count: 0}, {Min: 18, Max: 30, Count: 5}}
+		assert.Equalf(t, want, buckets, "Buckets is not equal")
+	})
+}
+
+// Test aggregation without connection.
+func TestAggregateChecks(t *testing.T) {
+
+	m := &mongoDB{}
+	ctx := context.Background()
+
+	var docs []bson.M
+
+	require.Equalf(t, ErrNilPtrDB, m.Aggregate(ctx, "users", nil, &docs), "Error is not equal")
+
+	_, err := m.UserStatsByAgeBucket(ctx, "users", []int{0, 100})
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	_, err = m.UserStatsByEmailDomain(ctx, "users")
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	_, err = AggregateAs[bson.M](ctx, nil, "users", nil)
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+}
@@ -0,0 +1,192 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Key of actor in context.
+type actorKey struct{}
+
+// Names of fields of audit, maintained by repository. Empty name - field is not maintained.
+type AuditFields struct {
+	// Time of insert, type time.Time
+	CreatedAt string
+	// Time of last change, type time.Time
+	UpdatedAt string
+	// Actor of insert, type string
+	CreatedBy string
+	// Actor of last change, type string
+	UpdatedBy string
+}
+
+// Context with actor, which is written to fields createdBy and updatedBy.
+//
+// Params:
+//
+//	ctx - context
+//	actor - actor, e.g. name of user of service
+func WithActor(ctx context.Context, actor string) context.Context {
+
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Actor of context. Returns actor or "".
+//
+// Params:
+//
+//	ctx - context
+func ActorFromContext(ctx context.Context) string {
+
+	if ctx == nil {
+		return ""
+	}
+
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// Check types of fields of audit. Returns error.
+//
+// Params:
+//
+//	t - type of document
+func (a AuditFields) check(t reflect.Type) error {
+
+	fields := []struct {
+		name string
+		typ  reflect.Type
+	}{
+		{a.CreatedAt, reflect.TypeOf(time.Time{})},
+		{a.UpdatedAt, reflect.TypeOf(time.Time{})},
+		{a.CreatedBy, reflect.TypeOf("")},
+		{a.UpdatedBy, reflect.TypeOf("")},
+	}
+
+	for _, f := range fields {
+		if f.name == "" {
+			continue
+		}
+		i, ok := bsonFieldIndex(t, f.name)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownField, f.name)
+		}
+		if t.Field(i).Type != f.typ {
+			return fmt.Errorf("%w: audit field %s", ErrNotSupportedType, f.name)
+		}
+	}
+
+	return nil
+}
+
+// Current time of repository with precision of BSON date. Returns time.
+func (r *Repository[T]) now() time.Time {
+
+	return r.m.now()
+}
+
+// Current time of connection with precision of BSON date. Returns time.
+func (m *mongoDB) now() time.Time {
+
+	clock := time.Now
+	if m != nil && m.clock != nil {
+		clock = m.clock
+	}
+
+	return clock().UTC().Truncate(time.Millisecond)
+}
+
+// Set fields of audit of inserted document. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) stampCreated(ctx context.Context, doc T) T {
+
+	now := r.now()
+	actor := ActorFromContext(ctx)
+
+	v := reflect.ValueOf(&doc).Elem()
+	set := func(name string, value interface{}) {
+		if name == "" {
+			return
+		}
+		if i, ok := bsonFieldIndex(v.Type(), name); ok {
+			v.Field(i).Set(reflect.ValueOf(value))
+		}
+	}
+
+	set(r.cfg.Audit.CreatedAt, now)
+	set(r.cfg.Audit.UpdatedAt, now)
+	set(r.cfg.Audit.CreatedBy, actor)
+	set(r.cfg.Audit.UpdatedBy, actor)
+
+	return doc
+}
+
+// Fields of audit, which are set on insert by upsert. Returns fields.
+//
+// Params:
+//
+//	ctx - context
+func (r *Repository[T]) createdFields(ctx context.Context) bson.D {
+
+	fields := bson.D{}
+	if r.cfg.Audit.CreatedAt != "" {
+		fields = append(fields, bson.E{Key: r.cfg.Audit.CreatedAt, Value: r.now()})
+	}
+	if actor := ActorFromContext(ctx); r.cfg.Audit.CreatedBy != "" && actor != "" {
+		fields = append(fields, bson.E{Key: r.cfg.Audit.CreatedBy, Value: actor})
+	}
+
+	return fields
+}
+
+// Add fields of audit of change to update. Returns document of update.
+//
+// Params:
+//
+//	ctx - context
+//	update - document of update
+func (r *Repository[T]) stampUpdated(ctx context.Context, update bson.D) bson.D {
+
+	if r.cfg.Audit.UpdatedAt != "" {
+		update = mergeOp(update, "$set", bson.D{{Key: r.cfg.Audit.UpdatedAt, Value: r.now()}})
+	}
+
+	if r.cfg.Audit.UpdatedBy != "" {
+		if actor := ActorFromContext(ctx); actor != "" {
+			update = mergeOp(update, "$set", bson.D{{Key: r.cfg.Audit.UpdatedBy, Value: actor}})
+		} else {
+			update = mergeOp(update, "$unset", bson.D{{Key: r.cfg.Audit.UpdatedBy, Value: ""}})
+		}
+	}
+
+	return update
+}
+
+// Set fields of audit of change to moved document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) stampMoved(ctx context.Context, doc bson.M) {
+
+	if r.cfg.Audit.UpdatedAt != "" {
+		doc[r.cfg.Audit.UpdatedAt] = r.now()
+	}
+
+	if r.cfg.Audit.UpdatedBy != "" {
+		if actor := ActorFromContext(ctx); actor != "" {
+			doc[r.cfg.Audit.UpdatedBy] = actor
+		} else {
+			delete(doc, r.cfg.Audit.UpdatedBy)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Document for tests of audit.
+type docAudited struct {
+	Code      string    `bson:"code"`
+	Title     string    `bson:"title,omitempty"`
+	CreatedAt time.Time `bson:"createdAt,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt,omitempty"`
+	CreatedBy string    `bson:"createdBy,omitempty"`
+	UpdatedBy string    `bson:"updatedBy,omitempty"`
+}
+
+// Fields of audit of docAudited.
+var docAuditedFields = AuditFields{CreatedAt: "createdAt", UpdatedAt: "updatedAt", CreatedBy: "createdBy", UpdatedBy: "updatedBy"}
+
+// Test WithActor and ActorFromContext.
+func TestActorFromContext(t *testing.T) {
+
+	assert.Emptyf(t, ActorFromContext(context.Background()), "Actor is not empty")
+	assert.Equalf(t, "alice", ActorFromContext(WithActor(context.Background(), "alice")), "Actor is not equal")
+}
+
+// Test check of fields of audit.
+func TestAuditFieldsCheck(t *testing.T) {
+
+	typ := reflect.TypeOf(docAudited{})
+
+	err := AuditFields{CreatedAt: "created"}.check(typ)
+	require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+	err = AuditFields{CreatedAt: "title"}.check(typ)
+	require.Truef(t, errors.Is(err, ErrNotSupportedType), "Error is not equal")
+
+	err = AuditFields{CreatedBy: "createdAt"}.check(typ)
+	require.Truef(t, errors.Is(err, ErrNotSupportedType), "Error is not equal")
+
+	err = docAuditedFields.check(typ)
+	require.NoErrorf(t, err, "Unexpected error")
+
+	err = AuditFields{}.check(typ)
+	require.NoErrorf(t, err, "Unexpected error")
+}
+
+// Test documents of update with audit.
+func TestAuditUpdates(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 123456789, time.Local)
+	want := now.UTC().Truncate(time.Millisecond)
+
+	r := &Repository[docAudited]{
+		m:   &mongoDB{clock: func() time.Time { return now }},
+		cfg: RepositoryConfig[docAudited]{Audit: docAuditedFields},
+	}
+	ctx := WithActor(context.Background(), "alice")
+
+	t.Run("Insert", func(t *testing.T) {
+
+		doc := r.insertDoc(ctx, docAudited{Code: "c1", CreatedBy: "eve"})
+		assert.Equalf(t, docAudited{Code: "c1", CreatedAt: want, UpdatedAt: want, CreatedBy: "alice", UpdatedBy: "alice"}, doc, "Document is not equal")
+	})
+
+	t.Run("Import", func(t *testing.T) {
+
+		created := time.Date(2020, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+		doc := r.importDoc(ctx, docAudited{Code: "c1", CreatedAt: created, CreatedBy: "eve"})
+		assert.Equalf(t, docAudited{Code: "c1", CreatedAt: created, UpdatedAt: want, CreatedBy: "eve", UpdatedBy: "alice"}, doc, "Document is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		update, err := r.updateDoc(ctx, docAudited{Code: "c1", CreatedBy: "eve"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		wantUpdate := bson.D{{Key: "$set", Value: bson.D{
+			{Key: "code", Value: "c1"},
+			{Key: "updatedAt", Value: want},
+			{Key: "updatedBy", Value: "alice"},
+		}}}
+		assert.Equalf(t, wantUpdate, update, "Update is not equal")
+	})
+
+	t.Run("Update without actor", func(t *testing.T) {
+
+		update, err := r.updateDoc(context.Background(), docAudited{Code: "c1"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		wantUpdate := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "code", Value: "c1"}, {Key: "updatedAt", Value: want}}},
+			{Key: "$unset", Value: bson.D{{Key: "updatedBy", Value: ""}}},
+		}
+		assert.Equalf(t, wantUpdate, update, "Update is not equal")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+
+		update, err := r.replaceDoc(ctx, docAudited{Code: "c1"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		wantUpdate := bson.D{
+			{Key: "$set", Value: bson.D{
+				{Key: "code", Value: "c1"},
+				{Key: "updatedAt", Value: want},
+				{Key: "updatedBy", Value: "alice"},
+			}},
+			{Key: "$unset", Value: bson.D{{Key: "title", Value: ""}}},
+			{Key: "$setOnInsert", Value: bson.D{{Key: "createdAt", Value: want}, {Key: "createdBy", Value: "alice"}}},
+		}
+		assert.Equalf(t, wantUpdate, update, "Update is not equal")
+	})
+
+	t.Run("Patch of audit field", func(t *testing.T) {
+
+		spec, err := NewPatch().Set("createdBy", "eve").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = r.patchDoc(ctx, spec)
+		require.Truef(t, errors.Is(err, ErrNotCorrectPatch), "Error is not equal")
+	})
+}
@@ -0,0 +1,481 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Options of bulk write.
+type BulkOptions struct {
+	// Stop on the first failed operation. False - all operations are tried
+	Ordered bool
+}
+
+// Result of operation of bulk write.
+type BulkItemResult struct {
+	// Index of operation in input
+	Index int
+	// Id of inserted document, NilObjectID for update, delete and document with _id of other type
+	ID primitive.ObjectID
+	// Error of operation, e.g. ErrDocumentExists, *ValidationError, ErrNotExecuted,
+	// ErrUpdateDocument for update without matched document
+	Err error
+}
+
+// Result of bulk write. Counts are totals, server does not report them per operation.
+type BulkResult struct {
+	// Results in order of input
+	Items []BulkItemResult
+	// Count of inserted documents
+	Inserted int64
+	// Count of matched documents
+	Matched int64
+	// Count of modified documents
+	Modified int64
+	// Count of deleted documents
+	Deleted int64
+}
+
+// Failed operations. Returns results with error.
+func (r BulkResult) Failed() []BulkItemResult {
+
+	failed := []BulkItemResult{}
+	for _, item := range r.Items {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+
+	return failed
+}
+
+// Update of document by key.
+type BulkUpdate[T any] struct {
+	// Value of key field
+	Key interface{}
+	// Document, fields are set by $set
+	Doc T
+}
+
+// Update of document user by name.
+type UserUpdate struct {
+	// Name of user
+	Name string
+	// Document
+	Doc DocUser
+}
+
+// Insert documents by one bulk write. With outbox, documents are inserted one by one, see insertEach.
+// Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (r *Repository[T]) BulkInsert(ctx context.Context, collectionName string, docs []T, opts BulkOptions) (BulkResult, error) {
+
+	return r.bulkInsert(ctx, collectionName, docs, opts, r.insertDoc)
+}
+
+// Insert imported documents by one bulk write, as BulkInsert. Maintained fields are kept from documents,
+// missing ones are set as on insert. Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (r *Repository[T]) BulkInsertImported(ctx context.Context, collectionName string, docs []T, opts BulkOptions) (BulkResult, error) {
+
+	return r.bulkInsert(ctx, collectionName, docs, opts, r.importDoc)
+}
+
+// Insert documents, prepared by function, by one bulk write. With outbox, documents are inserted one by one,
+// see insertEach. Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+//	prepare - preparation of checked document, sets maintained fields
+func (r *Repository[T]) bulkInsert(ctx context.Context, collectionName string, docs []T, opts BulkOptions,
+	prepare func(ctx context.Context, doc T) T) (BulkResult, error) {
+
+	if r.cfg.Outbox.Collection != "" {
+		return r.insertEach(ctx, collectionName, docs, opts, prepare)
+	}
+
+	return r.bulkWrite(ctx, collectionName, len(docs), opts, true, func(i int) (mongo.WriteModel, primitive.ObjectID, error) {
+
+		doc := r.normalize(docs[i])
+		if err := r.validate(doc); err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+
+		d, id, err := withObjectID(prepare(ctx, doc))
+		if err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+
+		return mongo.NewInsertOneModel().SetDocument(d), id, nil
+	})
+}
+
+// Insert documents one by one, each with event of outbox in own transaction. Write error aborts transaction,
+// so one bulk write in transaction can not keep successful documents of batch with their events.
+// Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+//	prepare - preparation of checked document, sets maintained fields
+func (r *Repository[T]) insertEach(ctx context.Context, collectionName string, docs []T, opts BulkOptions,
+	prepare func(ctx context.Context, doc T) T) (BulkResult, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return BulkResult{}, err
+	}
+	if len(docs) == 0 {
+		return BulkResult{}, ErrEmptyBulk
+	}
+
+	// Logic
+	result := BulkResult{Items: make([]BulkItemResult, len(docs))}
+	for i := range result.Items {
+		result.Items[i].Index = i
+	}
+
+	for i := range docs {
+
+		id, err := r.insert(ctx, collectionName, docs[i], prepare)
+		if err != nil {
+			result.Items[i].Err = err
+			if opts.Ordered {
+				notExecuted(result.Items[i+1:])
+				break
+			}
+			continue
+		}
+
+		result.Items[i].ID, _ = id.(primitive.ObjectID)
+		result.Inserted++
+	}
+
+	if failed := len(result.Failed()); failed > 0 {
+		return result, fmt.Errorf("%w: %d of %d operations", ErrBulkWrite, failed, len(docs))
+	}
+
+	return result, nil
+}
+
+// Update documents by key by one bulk write. Update of key without document fails with ErrUpdateDocument and
+// is not sent, keys are looked up before write. Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	updates - updates
+//	opts - options
+func (r *Repository[T]) BulkUpdateByKey(ctx context.Context, collectionName string, updates []BulkUpdate[T], opts BulkOptions) (BulkResult, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return BulkResult{}, err
+	}
+	if len(updates) == 0 {
+		return BulkResult{}, ErrEmptyBulk
+	}
+
+	keys := make([]interface{}, 0, len(updates))
+	for _, u := range updates {
+		keys = append(keys, u.Key)
+	}
+	existing, err := r.existingKeys(ctx, collectionName, keys)
+	if err != nil {
+		return BulkResult{}, err
+	}
+
+	// Logic
+	return r.bulkWrite(ctx, collectionName, len(updates), opts, true, func(i int) (mongo.WriteModel, primitive.ObjectID, error) {
+
+		filter, err := r.keyFilter(updates[i].Key)
+		if err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+		if !existing[keyOf(updates[i].Key)] {
+			return nil, primitive.NilObjectID, ErrUpdateDocument
+		}
+
+		doc := r.normalize(updates[i].Doc)
+		if err := r.validateUpdate(doc); err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+
+		update, err := r.updateDoc(ctx, doc)
+		if err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+
+		return mongo.NewUpdateOneModel().SetFilter(r.liveFilter(filter)).SetUpdate(update), primitive.NilObjectID, nil
+	})
+}
+
+// Delete documents by key by one bulk write. With DeletedField documents are deleted softly.
+// Returns report and ErrBulkWrite, if some operations failed.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	keys - values of key field
+//	opts - options
+func (r *Repository[T]) BulkDeleteByKey(ctx context.Context, collectionName string, keys []interface{}, opts BulkOptions) (BulkResult, error) {
+
+	result, err := r.bulkWrite(ctx, collectionName, len(keys), opts, false, func(i int) (mongo.WriteModel, primitive.ObjectID, error) {
+
+		filter, err := r.keyFilter(keys[i])
+		if err != nil {
+			return nil, primitive.NilObjectID, err
+		}
+
+		if r.cfg.DeletedField != "" {
+			return mongo.NewUpdateOneModel().SetFilter(r.liveFilter(filter)).SetUpdate(r.deleteDoc(ctx)), primitive.NilObjectID, nil
+		}
+
+		return mongo.NewDeleteOneModel().SetFilter(filter), primitive.NilObjectID, nil
+	})
+
+	// Soft delete is executed by updates
+	if r.cfg.DeletedField != "" {
+		result.Deleted, result.Matched, result.Modified = result.Modified, 0, 0
+	}
+
+	return result, err
+}
+
+// Keys of live documents among values. Returns set of keys by keyOf and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	keys - values of key field, empty values are skipped
+func (r *Repository[T]) existingKeys(ctx context.Context, collectionName string, keys []interface{}) (map[string]bool, error) {
+
+	if r.cfg.KeyField == "" {
+		return nil, ErrEmptyKeyField
+	}
+
+	values := bson.A{}
+	for _, k := range keys {
+		if _, err := r.keyFilter(k); err == nil {
+			values = append(values, k)
+		}
+	}
+
+	existing := map[string]bool{}
+	if len(values) == 0 {
+		return existing, nil
+	}
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	filter := r.liveFilter(bson.M{r.cfg.KeyField: bson.M{"$in": values}})
+
+	found, err := r.m.db.Collection(collectionName).Distinct(ctx, r.cfg.KeyField, filter)
+	if err != nil {
+		return nil, fmt.Errorf("Function Distinct, returned error: <%w>", err)
+	}
+	for _, v := range found {
+		existing[keyOf(v)] = true
+	}
+
+	return existing, nil
+}
+
+// Comparable form of value of key: type and bytes of BSON. Returns string.
+//
+// Params:
+//
+//	v - value of key
+func keyOf(v interface{}) string {
+
+	t, data, err := bson.MarshalValue(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(rune(t)) + string(data)
+}
+
+// Execute bulk write. Operations, which are failed by prepare, are not sent.
+// Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	n - count of operations
+//	opts - options
+//	indexes - create indexes of repository before write
+//	prepare - model and id of operation by index
+func (r *Repository[T]) bulkWrite(ctx context.Context, collectionName string, n int, opts BulkOptions, indexes bool,
+	prepare func(i int) (mongo.WriteModel, primitive.ObjectID, error)) (BulkResult, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return BulkResult{}, err
+	}
+	if n == 0 {
+		return BulkResult{}, ErrEmptyBulk
+	}
+
+	// Models
+	result := BulkResult{Items: make([]BulkItemResult, n)}
+	models := []mongo.WriteModel{}
+	pos := []int{}
+
+	for i := range result.Items {
+		result.Items[i].Index = i
+	}
+
+	for i := 0; i < n; i++ {
+
+		model, id, err := prepare(i)
+		if err != nil {
+			result.Items[i].Err = err
+			if opts.Ordered {
+				notExecuted(result.Items[i+1:])
+				break
+			}
+			continue
+		}
+
+		result.Items[i].ID = id
+		models = append(models, model)
+		pos = append(pos, i)
+	}
+
+	// Write
+	if len(models) > 0 {
+
+		collection := r.m.db.Collection(collectionName)
+
+		ctx, cancel := r.m.withTimeout(ctx)
+		defer cancel()
+
+		if indexes {
+			if err := r.ensureIndexes(ctx, collectionName); err != nil {
+				return BulkResult{}, err
+			}
+		}
+
+		res, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(opts.Ordered))
+		if res != nil {
+			result.Inserted = res.InsertedCount
+			result.Matched = res.MatchedCount
+			result.Modified = res.ModifiedCount
+			result.Deleted = res.DeletedCount
+		}
+
+		if err != nil {
+			var bwe mongo.BulkWriteException
+			if !errors.As(err, &bwe) || bwe.WriteConcernError != nil {
+				return result, fmt.Errorf("Function BulkWrite, returned error: <%w>", err)
+			}
+
+			first := len(models)
+			for _, e := range bwe.WriteErrors {
+				item := &result.Items[pos[e.Index]]
+				item.ID = primitive.NilObjectID
+				item.Err = bulkItemError(e.WriteError)
+				first = min(first, e.Index)
+			}
+
+			// Server stops on the first failed operation
+			if opts.Ordered {
+				for _, i := range pos[min(first+1, len(pos)):] {
+					notExecuted(result.Items[i : i+1])
+				}
+			}
+		}
+	}
+
+	if failed := len(result.Failed()); failed > 0 {
+		return result, fmt.Errorf("%w: %d of %d operations", ErrBulkWrite, failed, n)
+	}
+
+	return result, nil
+}
+
+// Mark operations as not executed.
+//
+// Params:
+//
+//	items - results of operations
+func notExecuted(items []BulkItemResult) {
+
+	for i := range items {
+		items[i].ID = primitive.NilObjectID
+		items[i].Err = ErrNotExecuted
+	}
+}
+
+// Error of operation of bulk write. Returns error.
+//
+// Params:
+//
+//	e - error of server
+func bulkItemError(e mongo.WriteError) error {
+
+	err := mongo.WriteException{WriteErrors: mongo.WriteErrors{e}}
+
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDocumentExists
+	}
+	if isServerError(err, codeDocumentValidationFailure) {
+		return schemaValidationError(err)
+	}
+
+	return fmt.Errorf("Function BulkWrite, returned error: <%w>", err)
+}
+
+// Document with _id. Missing _id is generated. Returns document, id and error.
+// Id of other type than ObjectID is returned as NilObjectID.
+//
+// Params:
+//
+//	doc - document
+func withObjectID(doc interface{}) (bson.D, primitive.ObjectID, error) {
+
+	d, err := marshalDoc(doc)
+	if err != nil {
+		return nil, primitive.NilObjectID, err
+	}
+
+	if id := lookup(d, "_id"); id != nil {
+		oid, _ := id.(primitive.ObjectID)
+		return d, oid, nil
+	}
+
+	id := primitive.NewObjectID()
+
+	return append(bson.D{{Key: "_id", Value: id}}, d...), id, nil
+}
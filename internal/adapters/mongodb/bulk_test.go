@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Test withObjectID
+func TestWithObjectID(t *testing.T) {
+
+	t.Run("Generated", func(t *testing.T) {
+
+		d, id, err := withObjectID(DocUser{Name: "Aaa", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		require.Falsef(t, id.IsZero(), "Id is not generated")
+		assert.Equalf(t, bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "Aaa"}, {Key: "age", Value: int32(30)}}, d, "Document is not equal")
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+
+		d, id, err := withObjectID(bson.M{"_id": "u1", "name": "Aaa"})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, primitive.NilObjectID, id, "Id is not equal")
+		assert.Lenf(t, d, 2, "Count of fields is not equal")
+	})
+}
+
+// Test bulkItemError
+func TestBulkItemError(t *testing.T) {
+
+	err := bulkItemError(mongo.WriteError{Code: 11000, Message: "E11000 duplicate key error"})
+	require.Equalf(t, ErrDocumentExists, err, "Error is not equal")
+
+	err = bulkItemError(mongo.WriteError{Code: codeDocumentValidationFailure, Message: "Document failed validation"})
+	require.Truef(t, errors.Is(err, ErrSchemaValidation), "Error is not equal")
+
+	err = bulkItemError(mongo.WriteError{Code: 2, Message: "Bad value"})
+	require.Errorf(t, err, "Expected error")
+	require.Falsef(t, errors.Is(err, ErrDocumentExists), "Error is not equal")
+}
+
+// Test BulkResult.Failed
+func TestBulkResultFailed(t *testing.T) {
+
+	result := BulkResult{Items: []BulkItemResult{
+		{Index: 0, ID: primitive.NewObjectID()},
+		{Index: 1, Err: ErrDocumentExists},
+		{Index: 2, Err: ErrNotExecuted},
+	}}
+
+	failed := result.Failed()
+	require.Lenf(t, failed, 2, "Count of failed is not equal")
+	assert.Equalf(t, 1, failed[0].Index, "Index is not equal")
+	assert.Equalf(t, 2, failed[1].Index, "Index is not equal")
+}
+
+// Test keyOf
+func TestKeyOf(t *testing.T) {
+
+	assert.Equalf(t, keyOf("Anna"), keyOf("Anna"), "Keys is not equal")
+	assert.Equalf(t, keyOf(int32(7)), keyOf(7), "Keys is not equal")
+	assert.NotEqualf(t, keyOf("7"), keyOf(7), "Keys is equal")
+	assert.NotEqualf(t, keyOf("Anna"), keyOf("anna"), "Keys is equal")
+}
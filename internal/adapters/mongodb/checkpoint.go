@@ -0,0 +1,142 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Store of resume tokens of streams of changes.
+type CheckpointStore interface {
+	// Load resume token by key. Nil - token is not saved
+	Load(ctx context.Context, key string) (bson.Raw, error)
+	// Save resume token by key
+	Save(ctx context.Context, key string, token bson.Raw) error
+}
+
+// Store of resume tokens in memory of process.
+type memoryCheckpoints struct {
+	mu     sync.Mutex
+	tokens map[string]bson.Raw
+}
+
+// Constructor of store of resume tokens in memory of process.
+func NewMemoryCheckpoints() CheckpointStore {
+
+	return &memoryCheckpoints{tokens: map[string]bson.Raw{}}
+}
+
+// Load resume token by key. Returns token and error.
+//
+// Params:
+//
+//	ctx - context
+//	key - key of token
+func (c *memoryCheckpoints) Load(ctx context.Context, key string) (bson.Raw, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.tokens[key]), nil
+}
+
+// Save resume token by key. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	key - key of token
+//	token - resume token
+func (c *memoryCheckpoints) Save(ctx context.Context, key string, token bson.Raw) error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tokens[key] = slices.Clone(token)
+
+	return nil
+}
+
+// Store of resume tokens in collection.
+type collectionCheckpoints struct {
+	m              *mongoDB
+	collectionName string
+}
+
+// Document of resume token.
+type docCheckpoint struct {
+	Key       string    `bson:"_id"`
+	Token     bson.Raw  `bson:"token"`
+	UpdatedAt time.Time `bson:"updatedAt"`
+}
+
+// Constructor of store of resume tokens in collection.
+//
+// Params:
+//
+//	db - connection, returned by New
+//	collectionName - name of collection
+func NewCollectionCheckpoints(db MongoDBI, collectionName string) (CheckpointStore, error) {
+
+	// Check
+	m, ok := db.(*mongoDB)
+	if !ok || m == nil {
+		return nil, ErrNotSupportedDB
+	}
+	if collectionName == "" {
+		return nil, ErrEmptyCollectionsName
+	}
+
+	return &collectionCheckpoints{m: m, collectionName: collectionName}, nil
+}
+
+// Load resume token by key. Returns token and error.
+//
+// Params:
+//
+//	ctx - context
+//	key - key of token
+func (c *collectionCheckpoints) Load(ctx context.Context, key string) (bson.Raw, error) {
+
+	ctx, cancel := c.m.withTimeout(ctx)
+	defer cancel()
+
+	var doc docCheckpoint
+	err := c.m.db.Collection(c.collectionName).FindOne(ctx, bson.M{"_id": key}).Decode(&doc)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Function FindOne return error: <%w>", err)
+	}
+
+	return doc.Token, nil
+}
+
+// Save resume token by key. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	key - key of token
+//	token - resume token
+func (c *collectionCheckpoints) Save(ctx context.Context, key string, token bson.Raw) error {
+
+	ctx, cancel := c.m.withTimeout(ctx)
+	defer cancel()
+
+	update := bson.M{"$set": bson.M{"token": token, "updatedAt": time.Now().UTC()}}
+
+	_, err := c.m.db.Collection(c.collectionName).UpdateOne(ctx, bson.M{"_id": key}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Code of server error: collection already exists.
+const codeNamespaceExists = 48
+
+// Name of legacy placeholder document, inserted by CheckCreateDB before.
+const initialDocumentName = "initial"
+
+// Levels of validation.
+const (
+	ValidationLevelOff      = "off"
+	ValidationLevelStrict   = "strict"
+	ValidationLevelModerate = "moderate"
+)
+
+// Actions of validation.
+const (
+	ValidationActionError = "error"
+	ValidationActionWarn  = "warn"
+)
+
+// Declaration of collection.
+type CollectionSpec struct {
+	// Name of collection
+	Name string
+	// Capped collection
+	Capped bool
+	// Maximum size of capped collection in bytes
+	SizeInBytes int64
+	// Maximum count of documents in capped collection. Zero - without limit
+	MaxDocuments int64
+	// Validator of documents, e.g. {$jsonSchema: ...}
+	Validator interface{}
+	// Level of validation: off, strict, moderate
+	ValidationLevel string
+	// Action of validation: error, warn
+	ValidationAction string
+	// Default collation
+	Collation *options.Collation
+	// Settings of time series collection
+	TimeSeries *options.TimeSeriesOptions
+	// Clustered index, e.g. {key: {_id: 1}, unique: true}
+	ClusteredIndex interface{}
+	// TTL of documents in time series or clustered collection
+	ExpireAfterSeconds *int64
+}
+
+// Check declaration of collection. Returns error.
+func (s CollectionSpec) Check() error {
+
+	if s.Name == "" {
+		return ErrEmptyCollectionsName
+	}
+	if s.Capped && s.SizeInBytes <= 0 {
+		return fmt.Errorf("%w: capped collection %s requires size", ErrNotCorrectCollection, s.Name)
+	}
+	if !s.Capped && (s.SizeInBytes != 0 || s.MaxDocuments != 0) {
+		return fmt.Errorf("%w: size of not capped collection %s", ErrNotCorrectCollection, s.Name)
+	}
+	switch s.ValidationLevel {
+	case "", ValidationLevelOff, ValidationLevelStrict, ValidationLevelModerate:
+	default:
+		return fmt.Errorf("%w: validation level %s", ErrNotCorrectCollection, s.ValidationLevel)
+	}
+	switch s.ValidationAction {
+	case "", ValidationActionError, ValidationActionWarn:
+	default:
+		return fmt.Errorf("%w: validation action %s", ErrNotCorrectCollection, s.ValidationAction)
+	}
+	if s.TimeSeries != nil && s.TimeSeries.TimeField == "" {
+		return fmt.Errorf("%w: time series collection %s requires time field", ErrNotCorrectCollection, s.Name)
+	}
+	if s.TimeSeries != nil && (s.Capped || s.ClusteredIndex != nil) {
+		return fmt.Errorf("%w: time series collection %s can not be capped or clustered", ErrNotCorrectCollection, s.Name)
+	}
+	if s.ExpireAfterSeconds != nil && s.TimeSeries == nil && s.ClusteredIndex == nil {
+		return fmt.Errorf("%w: TTL of collection %s requires time series or clustered index", ErrNotCorrectCollection, s.Name)
+	}
+
+	return nil
+}
+
+// Options of creation for driver. Returns options.
+func (s CollectionSpec) options() *options.CreateCollectionOptions {
+
+	opts := options.CreateCollection()
+
+	if s.Capped {
+		opts.SetCapped(true).SetSizeInBytes(s.SizeInBytes)
+		if s.MaxDocuments > 0 {
+			opts.SetMaxDocuments(s.MaxDocuments)
+		}
+	}
+	if s.Validator != nil {
+		opts.SetValidator(s.Validator)
+	}
+	if s.ValidationLevel != "" {
+		opts.SetValidationLevel(s.ValidationLevel)
+	}
+	if s.ValidationAction != "" {
+		opts.SetValidationAction(s.ValidationAction)
+	}
+	if s.Collation != nil {
+		opts.SetCollation(s.Collation)
+	}
+	if s.TimeSeries != nil {
+		opts.SetTimeSeriesOptions(s.TimeSeries)
+	}
+	if s.ClusteredIndex != nil {
+		opts.SetClusteredIndex(s.ClusteredIndex)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*s.ExpireAfterSeconds)
+	}
+
+	return opts
+}
+
+// Create collections, which are not exists. Existing collections are not changed. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	specs - declarations of collections
+func (m *mongoDB) CreateCollections(ctx context.Context, specs []CollectionSpec) error {
+
+	// Check
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	if m.nameDB == "" {
+		return ErrEmptyValueNameDB
+	}
+	if len(specs) == 0 {
+		return ErrEmptyCollectionsNames
+	}
+	for _, s := range specs {
+		if err := s.Check(); err != nil {
+			return err
+		}
+	}
+
+	// Get collections names
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	names, err := m.db.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	// Create collections
+	for _, s := range specs {
+
+		if IsExistsCollection(names, s.Name) {
+			continue
+		}
+
+		err := m.db.CreateCollection(ctx, s.Name, s.options())
+		if err != nil && !isServerError(err, codeNamespaceExists) {
+			return fmt.Errorf("failed to create collection %s: <%w>", s.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Migration: delete placeholder documents {name: "initial"}, inserted by CheckCreateDB before.
+// Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collections - list of collections names
+func (m *mongoDB) RemoveInitialDocuments(ctx context.Context, collections []string) (int64, error) {
+
+	// Check
+	if m.db == nil {
+		return 0, ErrNilPtrDB
+	}
+	if len(collections) == 0 {
+		return 0, ErrEmptyCollectionsNames
+	}
+
+	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{
+		"name":  initialDocumentName,
+		"age":   bson.M{"$exists": false},
+		"email": bson.M{"$exists": false},
+	}
+
+	var deleted int64
+	for _, c := range collections {
+
+		if c == "" {
+			return deleted, ErrEmptyCollectionsName
+		}
+
+		result, err := m.db.Collection(c).DeleteMany(ctx, filter)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete initial documents: <%w>", err)
+		}
+		deleted += result.DeletedCount
+	}
+
+	return deleted, nil
+}
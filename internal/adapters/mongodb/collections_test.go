@@ -0,0 +1,71 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Test CollectionSpec.
+func TestCollectionSpec(t *testing.T) {
+
+	ttl := int64(3600)
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		err := CollectionSpec{}.Check()
+		require.Equalf(t, ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Not correct", func(t *testing.T) {
+
+		list := []CollectionSpec{
+			{Name: "a", Capped: true},
+			{Name: "a", MaxDocuments: 10},
+			{Name: "a", ValidationLevel: "hard"},
+			{Name: "a", ValidationAction: "ignore"},
+			{Name: "a", TimeSeries: options.TimeSeries()},
+			{Name: "a", TimeSeries: options.TimeSeries().SetTimeField("ts"), Capped: true, SizeInBytes: 1024},
+			{Name: "a", ExpireAfterSeconds: &ttl},
+		}
+
+		for _, spec := range list {
+			err := spec.Check()
+			require.Truef(t, errors.Is(err, ErrNotCorrectCollection), "Error is not equal for %+v", spec)
+		}
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		spec := CollectionSpec{
+			Name:               "events",
+			Validator:          bson.M{"age": bson.M{"$gt": 0}},
+			ValidationLevel:    ValidationLevelModerate,
+			ValidationAction:   ValidationActionWarn,
+			Collation:          &options.Collation{Locale: "en"},
+			TimeSeries:         options.TimeSeries().SetTimeField("ts").SetGranularity("minutes"),
+			ExpireAfterSeconds: &ttl,
+		}
+		require.NoErrorf(t, spec.Check(), "Unexpected error")
+
+		opts := spec.options()
+		assert.Equalf(t, spec.Validator, opts.Validator, "Validator is not equal")
+		assert.Equalf(t, ValidationLevelModerate, *opts.ValidationLevel, "Validation level is not equal")
+		assert.Equalf(t, ValidationActionWarn, *opts.ValidationAction, "Validation action is not equal")
+		assert.Equalf(t, "ts", opts.TimeSeriesOptions.TimeField, "Time field is not equal")
+		assert.Equalf(t, ttl, *opts.ExpireAfterSeconds, "TTL is not equal")
+		assert.Nilf(t, opts.Capped, "Capped is not nil")
+
+		spec = CollectionSpec{Name: "log", Capped: true, SizeInBytes: 4096, MaxDocuments: 10}
+		require.NoErrorf(t, spec.Check(), "Unexpected error")
+
+		opts = spec.options()
+		assert.Truef(t, *opts.Capped, "Collection is not capped")
+		assert.Equalf(t, int64(4096), *opts.SizeInBytes, "Size is not equal")
+		assert.Equalf(t, int64(10), *opts.MaxDocuments, "Max documents is not equal")
+	})
+}
@@ -0,0 +1,3663 @@
+// Package conformance is shared test suite for implementations of mongodb.MongoDBI.
+// Suite covers methods of interface. Repository[T] is built on connection of mongodb.New only,
+// its behaviour is checked by tests of package mongodb against server.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Names of collections, used by suite.
+var collections = []string{"conformance-1", "conformance-2"}
+
+// Name of collection of checkpoints of watch.
+const checkpointsCollection = "conformance-checkpoints"
+
+// Factory of tested implementation. Must register closing with t.Cleanup.
+type Factory func(t *testing.T) mongodb.MongoDBI
+
+// Factory of tested implementation, which takes time of audit from clock.
+type ClockFactory func(clock func() time.Time) Factory
+
+// Run suite against implementation.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation
+func Run(t *testing.T, newDB Factory) {
+
+	t.Run("CheckCreateDB", func(t *testing.T) { testCheckCreateDB(t, newDB) })
+	t.Run("CreateCollections", func(t *testing.T) { testCreateCollections(t, newDB) })
+	t.Run("ApplySchema", func(t *testing.T) { testApplySchema(t, newDB) })
+	t.Run("DropCollection", func(t *testing.T) { testDropCollection(t, newDB) })
+	t.Run("SendDocumentUser", func(t *testing.T) { testSendDocumentUser(t, newDB) })
+	t.Run("UpdateDocumentUserByName", func(t *testing.T) { testUpdateDocumentUserByName(t, newDB) })
+	t.Run("PatchDocumentUserByName", func(t *testing.T) { testPatchDocumentUserByName(t, newDB) })
+	t.Run("UpsertDocumentUserByName", func(t *testing.T) { testUpsertDocumentUserByName(t, newDB) })
+	t.Run("Bulk", func(t *testing.T) { testBulk(t, newDB) })
+	t.Run("RecvDocumentUserByName", func(t *testing.T) { testRecvDocumentUserByName(t, newDB) })
+	t.Run("DelDocumentUserByName", func(t *testing.T) { testDelDocumentUserByName(t, newDB) })
+	t.Run("DeleteMany", func(t *testing.T) { testDeleteMany(t, newDB) })
+	t.Run("MoveDocumentUserTx", func(t *testing.T) { testMoveDocumentUserTx(t, newDB) })
+	t.Run("By id", func(t *testing.T) { testByID(t, newDB) })
+	t.Run("By email", func(t *testing.T) { testByEmail(t, newDB) })
+	t.Run("WithTransaction", func(t *testing.T) { testWithTransaction(t, newDB) })
+	t.Run("Watch", func(t *testing.T) { testWatch(t, newDB) })
+	t.Run("FindDocumentsUser", func(t *testing.T) { testFindDocumentsUser(t, newDB) })
+	t.Run("Aggregate", func(t *testing.T) { testAggregate(t, newDB) })
+	t.Run("User stats", func(t *testing.T) { testUserStats(t, newDB) })
+	t.Run("Iteration", func(t *testing.T) { testIter(t, newDB) })
+	t.Run("Export and import", func(t *testing.T) { testTransfer(t, newDB) })
+	t.Run("EnsureIndexes", func(t *testing.T) { testEnsureIndexes(t, newDB) })
+	t.Run("SyncIndexes", func(t *testing.T) { testSyncIndexes(t, newDB) })
+	t.Run("Concurrent send", func(t *testing.T) { testConcurrentSend(t, newDB) })
+	t.Run("Canceled context", func(t *testing.T) { testCanceledContext(t, newDB) })
+	t.Run("Without versioning", func(t *testing.T) { testWithoutVersioning(t, newDB) })
+	t.Run("Without soft delete", func(t *testing.T) { testWithoutSoftDelete(t, newDB) })
+	t.Run("Without outbox", func(t *testing.T) { testWithoutOutbox(t, newDB) })
+	t.Run("NewRepository", func(t *testing.T) { testNewRepository(t, newDB) })
+}
+
+// Run suite of versioning against implementation, created with versioning.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation with versioning
+func RunVersioning(t *testing.T, newDB Factory) {
+
+	t.Run("Write paths", func(t *testing.T) { testVersionWritePaths(t, newDB) })
+	t.Run("UpdateDocumentUserByNameVersioned", func(t *testing.T) { testUpdateDocumentUserByNameVersioned(t, newDB) })
+	t.Run("Concurrent updates", func(t *testing.T) { testConcurrentVersionedUpdates(t, newDB) })
+}
+
+// Run suite of audit against implementation.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation with clock
+func RunAudit(t *testing.T, newDB ClockFactory) {
+
+	t.Run("Write paths", func(t *testing.T) { testAuditWritePaths(t, newDB) })
+	t.Run("Managed fields", func(t *testing.T) { testAuditManagedFields(t, newDB) })
+}
+
+// Run suite of soft delete against implementation, created with soft delete.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation with soft delete and clock
+func RunSoftDelete(t *testing.T, newDB ClockFactory) {
+
+	t.Run("Read and write paths", func(t *testing.T) { testSoftDeletePaths(t, newDB) })
+	t.Run("Restore", func(t *testing.T) { testRestoreDocumentUserByName(t, newDB) })
+	t.Run("Purge", func(t *testing.T) { testPurgeDeletedDocumentsUser(t, newDB) })
+	t.Run("DeleteMany", func(t *testing.T) { testSoftDeleteMany(t, newDB) })
+	t.Run("User stats", func(t *testing.T) { testSoftDeleteStats(t, newDB) })
+	t.Run("Iteration", func(t *testing.T) { testSoftDeleteIter(t, newDB) })
+}
+
+// Name of outbox collection, used by suite of outbox.
+const OutboxCollection = "conformance-outbox"
+
+// Run suite of outbox against implementation, created with outbox OutboxCollection.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation with outbox
+func RunOutbox(t *testing.T, newDB Factory) {
+
+	t.Run("Write paths", func(t *testing.T) { testOutboxWritePaths(t, newDB) })
+	t.Run("Delivery", func(t *testing.T) { testOutboxDelivery(t, newDB) })
+	t.Run("Relay", func(t *testing.T) { testRelay(t, newDB) })
+}
+
+// Create collections of suite and drop them on cleanup. Returns implementation.
+//
+// Params:
+//
+//	t - testing
+//	newDB - factory of implementation
+func setup(t *testing.T, newDB Factory) mongodb.MongoDBI {
+
+	db := newDB(t)
+	require.NotNil(t, db, "Pointer db is nil")
+
+	for _, c := range collections {
+		err := db.DropCollection(c)
+		require.NoErrorf(t, err, "Unexpected error DropCollection")
+	}
+
+	err := db.CheckCreateDB(collections)
+	require.NoErrorf(t, err, "Unexpected error CheckCreateDB")
+
+	t.Cleanup(func() {
+		for _, c := range collections {
+			err := db.DropCollection(c)
+			assert.NoErrorf(t, err, "Unexpected error DropCollection")
+		}
+	})
+
+	return db
+}
+
+// Drop collection, which is not created by setup, before test and on cleanup. Returns name of collection.
+func dropOnCleanup(t *testing.T, db mongodb.MongoDBI, collectionName string) string {
+
+	t.Helper()
+
+	err := db.DropCollection(collectionName)
+	require.NoErrorf(t, err, "Unexpected error DropCollection")
+
+	t.Cleanup(func() {
+		err := db.DropCollection(collectionName)
+		assert.NoErrorf(t, err, "Unexpected error DropCollection")
+	})
+
+	return collectionName
+}
+
+// Test CheckCreateDB
+func testCheckCreateDB(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing pointer collections name", func(t *testing.T) {
+
+		err := db.CheckCreateDB(nil)
+		require.Equalf(t, mongodb.ErrNilPtrCollections, err, "Error is not exists")
+	})
+
+	t.Run("Missing names", func(t *testing.T) {
+
+		err := db.CheckCreateDB([]string{})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsNames, err, "Error is not exists")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		names, err := db.GetNamesCollections()
+		require.NoErrorf(t, err, "Unexpected error GetNamesCollections")
+
+		for _, c := range collections {
+			assert.Truef(t, mongodb.IsExistsCollection(names, c), "Collection %s is not exists", c)
+		}
+	})
+
+	t.Run("Without placeholder", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByName(collections[0], "initial")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Placeholder document is exists")
+
+		cnt, err := db.RemoveInitialDocuments(context.Background(), collections)
+		require.NoErrorf(t, err, "Unexpected error RemoveInitialDocuments")
+		assert.Equalf(t, int64(0), cnt, "Value is not equal")
+	})
+}
+
+// Test CreateCollections
+func testCreateCollections(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	capped := "conformance-capped"
+	t.Cleanup(func() {
+		err := db.DropCollection(capped)
+		assert.NoErrorf(t, err, "Unexpected error DropCollection")
+	})
+
+	t.Run("Missing collections", func(t *testing.T) {
+
+		err := db.CreateCollections(ctx, nil)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsNames, err, "Error is not equal")
+	})
+
+	t.Run("Not correct collection", func(t *testing.T) {
+
+		err := db.CreateCollections(ctx, []mongodb.CollectionSpec{{Name: capped, Capped: true}})
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectCollection), "Error is not equal")
+
+		err = db.CreateCollections(ctx, []mongodb.CollectionSpec{{Name: capped, ValidationLevel: "hard"}})
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectCollection), "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		specs := []mongodb.CollectionSpec{
+			{Name: collections[0]},
+			{Name: capped, Capped: true, SizeInBytes: 1 << 20, MaxDocuments: 100},
+		}
+
+		err := db.CreateCollections(ctx, specs)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		names, err := db.GetNamesCollections()
+		require.NoErrorf(t, err, "Unexpected error GetNamesCollections")
+		assert.Truef(t, mongodb.IsExistsCollection(names, capped), "Collection is not exists")
+
+		docs, _, err := db.FindDocumentsUser(ctx, capped, nil)
+		require.NoErrorf(t, err, "Unexpected error FindDocumentsUser")
+		assert.Emptyf(t, docs, "Collection is not empty")
+	})
+}
+
+// Test ApplySchema
+func testApplySchema(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	schemaCollection := "conformance-schema"
+	t.Cleanup(func() {
+		err := db.DropCollection(schemaCollection)
+		assert.NoErrorf(t, err, "Unexpected error DropCollection")
+	})
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		err := db.ApplySchema(ctx, "", mongodb.DocUser{}, mongodb.SchemaOptions{})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Not struct", func(t *testing.T) {
+
+		err := db.ApplySchema(ctx, collections[0], "user", mongodb.SchemaOptions{})
+		require.Equalf(t, mongodb.ErrNotStructType, err, "Error is not equal")
+	})
+
+	t.Run("Not correct options", func(t *testing.T) {
+
+		err := db.ApplySchema(ctx, collections[0], mongodb.DocUser{}, mongodb.SchemaOptions{Level: "hard"})
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectCollection), "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		// Existing collection
+		err := db.ApplySchema(ctx, collections[0], mongodb.DocUser{}, mongodb.SchemaOptions{})
+		require.NoErrorf(t, err, "Unexpected error existing")
+
+		// Missing collection
+		err = db.ApplySchema(ctx, schemaCollection, &mongodb.DocUser{}, mongodb.SchemaOptions{Level: mongodb.ValidationLevelModerate})
+		require.NoErrorf(t, err, "Unexpected error missing")
+
+		names, err := db.GetNamesCollections()
+		require.NoErrorf(t, err, "Unexpected error GetNamesCollections")
+		assert.Truef(t, mongodb.IsExistsCollection(names, schemaCollection), "Collection is not exists")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "A", Age: 20, Email: "a@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+	})
+}
+
+// Test DropCollection
+func testDropCollection(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		err := db.DropCollection("")
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not exists")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		err := db.DropCollection(collections[0])
+		require.NoErrorf(t, err, "Unexpected error DropCollection")
+
+		names, err := db.GetNamesCollections()
+		require.NoErrorf(t, err, "Unexpected error GetNamesCollections")
+
+		assert.Falsef(t, mongodb.IsExistsCollection(names, collections[0]), "Collection is exists")
+		assert.Truef(t, mongodb.IsExistsCollection(names, collections[1]), "Collection is not exists")
+	})
+
+	t.Run("Not exists collection", func(t *testing.T) {
+
+		err := db.DropCollection("conformance-missing")
+		require.NoErrorf(t, err, "Unexpected error DropCollection")
+	})
+}
+
+// Test SendDocumentUser
+func testSendDocumentUser(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 20, Email: "aaa@mail.com"}
+
+		_, err := db.SendDocumentUser("", doc)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Wrong age", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: -1, Email: "aaa@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Missing document", func(t *testing.T) {
+
+		_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{})
+		require.Equalf(t, mongodb.ErrEmptyDocument, err, "Error is not equal")
+	})
+
+	t.Run("Wrong fields", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "  ", Age: 200, Email: "not-email"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+
+		var verr *mongodb.ValidationError
+		require.Truef(t, errors.As(err, &verr), "Error is not ValidationError")
+		assert.Lenf(t, verr.Fields, 3, "Count of fields is not equal")
+		assert.Truef(t, errors.Is(err, mongodb.ErrValueName), "Error is not equal")
+		assert.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+		assert.Truef(t, errors.Is(err, mongodb.ErrValueEmail), "Error is not equal")
+	})
+
+	t.Run("Normalization", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: " Vera ", Age: 30, Email: " Vera@Mail.COM "}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Vera")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Vera", Age: 30, Email: "vera@mail.com"}, withoutMeta(rxDoc), "Document is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"}
+
+		id, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.NotNilf(t, id, "Id is nil")
+	})
+
+	t.Run("Exists entry", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "B", Age: 30, Email: "b@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = db.SendDocumentUser(collections[0], doc)
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	})
+
+	t.Run("Same name other fields", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "C", Age: 30, Email: "c@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		doc.Age = 31
+		_, err = db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Collection is not set up", func(t *testing.T) {
+
+		notSetUp := dropOnCleanup(t, db, "conformance-not-set-up")
+		doc := mongodb.DocUser{Name: "D", Age: 30, Email: "d@mail.com"}
+
+		_, err := db.SendDocumentUser(notSetUp, doc)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = db.SendDocumentUser(notSetUp, doc)
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	})
+}
+
+// Test UpdateDocumentUserByName
+func testUpdateDocumentUserByName(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 20, Email: "aaa@mail.com"}
+
+		err := db.UpdateDocumentUserByName("", "Aaa", doc)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 20, Email: "aaa@mail.com"}
+
+		err := db.UpdateDocumentUserByName(collections[0], "", doc)
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Missing document", func(t *testing.T) {
+
+		err := db.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{})
+		require.Equalf(t, mongodb.ErrEmptyDocument, err, "Error is not equal")
+	})
+
+	t.Run("Not correct age", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 0, Email: "bbb@mail.com"}
+
+		err := db.UpdateDocumentUserByName(collections[0], "Aaa", doc)
+		require.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Wrong email", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Age: 30, Email: "Bbb@"}
+
+		err := db.UpdateDocumentUserByName(collections[0], "Aaa", doc)
+		require.Truef(t, errors.Is(err, mongodb.ErrValueEmail), "Error is not equal")
+		require.Falsef(t, errors.Is(err, mongodb.ErrValueName), "Omitted name is checked")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Zzz", Age: 20, Email: "zzz@mail.com"}
+
+		err := db.UpdateDocumentUserByName(collections[0], "Zzz", doc)
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 30, Email: "bbb@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		doc2 := mongodb.DocUser{Name: "Aaa", Age: 33, Email: "bbb@mail.com"}
+
+		err = db.UpdateDocumentUserByName(collections[0], doc.Name, doc2)
+		require.NoErrorf(t, err, "Unexpected error update")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.NoErrorf(t, err, "Unexpected error recieve")
+		assert.Equalf(t, doc2, withoutMeta(rxDoc), "Document is not equal")
+	})
+
+	t.Run("Omitted fields", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Ddd", Age: 30, Email: "ddd@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.UpdateDocumentUserByName(collections[0], doc.Name, mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error update")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.NoErrorf(t, err, "Unexpected error recieve")
+		assert.Equalf(t, mongodb.DocUser{Name: "Ddd", Age: 40, Email: "ddd@mail.com"}, withoutMeta(rxDoc), "Document is not equal")
+	})
+}
+
+// Test PatchDocumentUserByName
+func testPatchDocumentUserByName(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{
+		{Name: "Aaa", Age: 30, Email: "aaa@mail.com"},
+		{Name: "Bbb", Age: 30, Email: "bbb@mail.com"},
+	} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, "", "Aaa", mongodb.NewPatch().Set("age", 31))
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "", mongodb.NewPatch().Set("age", 31))
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Empty patch", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch())
+		require.Equalf(t, mongodb.ErrEmptyPatch, err, "Error is not equal")
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("phone", "1"))
+		require.Truef(t, errors.Is(err, mongodb.ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Wrong type", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("age", "31"))
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPatch), "Error is not equal")
+	})
+
+	t.Run("Wrong touched fields", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("age", 0).Unset("name"))
+		require.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+		require.Truef(t, errors.Is(err, mongodb.ErrValueName), "Error is not equal")
+		require.Falsef(t, errors.Is(err, mongodb.ErrValueEmail), "Untouched field is checked")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Zzz", mongodb.NewPatch().Set("age", 31))
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Only email", func(t *testing.T) {
+
+		doc, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("email", " Aaa@Other.COM "))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@other.com"}, withoutMeta(doc), "Document is not equal")
+	})
+
+	t.Run("Clear email and increment age", func(t *testing.T) {
+
+		doc, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Unset("email").Inc("age", 2))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 32}, withoutMeta(doc), "Document is not equal")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, doc, rxDoc, "Document is not equal")
+	})
+
+	t.Run("Exists entry", func(t *testing.T) {
+
+		_, err := db.PatchDocumentUserByName(ctx, collections[0], "Bbb", mongodb.NewPatch().Set("name", "Aaa").Set("age", 32).Unset("email"))
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	})
+}
+
+// Test UpsertDocumentUserByName
+func testUpsertDocumentUserByName(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.UpsertDocumentUserByName(ctx, "", "Aaa", mongodb.DocUser{Age: 30})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.UpsertDocumentUserByName(ctx, collections[0], "", mongodb.DocUser{Age: 30})
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Other name", func(t *testing.T) {
+
+		_, err := db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Name: "Bbb", Age: 30})
+		require.Equalf(t, mongodb.ErrKeyMismatch, err, "Error is not equal")
+	})
+
+	t.Run("Wrong age", func(t *testing.T) {
+
+		_, err := db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Age: -1})
+		require.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Insert and replace", func(t *testing.T) {
+
+		result, err := db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Age: 30, Email: "aaa@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Truef(t, result.Inserted, "Document is not inserted")
+		assert.Falsef(t, result.ID.IsZero(), "Id is nil")
+
+		result, err = db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Name: "Aaa", Age: 31})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Falsef(t, result.Inserted, "Document is inserted")
+		assert.Truef(t, result.ID.IsZero(), "Id is not nil")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 31}, withoutMeta(rxDoc), "Document is not replaced")
+	})
+}
+
+// Test SendDocumentsUser, UpdateDocumentsUserByName and DelDocumentsUserByName
+func testBulk(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.SendDocumentsUser(ctx, "", []mongodb.DocUser{{Name: "Aaa", Age: 30}}, mongodb.BulkOptions{})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Empty bulk", func(t *testing.T) {
+
+		_, err := db.SendDocumentsUser(ctx, collections[0], nil, mongodb.BulkOptions{})
+		require.Equalf(t, mongodb.ErrEmptyBulk, err, "Error is not equal")
+
+		_, err = db.UpdateDocumentsUserByName(ctx, collections[0], nil, mongodb.BulkOptions{})
+		require.Equalf(t, mongodb.ErrEmptyBulk, err, "Error is not equal")
+
+		_, err = db.DelDocumentsUserByName(ctx, collections[0], nil, mongodb.BulkOptions{})
+		require.Equalf(t, mongodb.ErrEmptyBulk, err, "Error is not equal")
+	})
+
+	t.Run("Insert unordered", func(t *testing.T) {
+
+		docs := []mongodb.DocUser{
+			{Name: "Aaa", Age: 30, Email: "aaa@mail.com"},
+			{Name: "Bbb", Age: -1},
+			{Name: "Aaa", Age: 30, Email: "aaa@mail.com"},
+			{Name: "Ccc", Age: 30},
+		}
+
+		result, err := db.SendDocumentsUser(ctx, collections[0], docs, mongodb.BulkOptions{})
+		require.Truef(t, errors.Is(err, mongodb.ErrBulkWrite), "Error is not equal")
+		require.Lenf(t, result.Items, 4, "Count of results is not equal")
+
+		assert.Equalf(t, int64(2), result.Inserted, "Count of inserted is not equal")
+		assert.Falsef(t, result.Items[0].ID.IsZero(), "Id is nil")
+		assert.Truef(t, errors.Is(result.Items[1].Err, mongodb.ErrValueAge), "Error is not equal")
+		assert.Equalf(t, mongodb.ErrDocumentExists, result.Items[2].Err, "Error is not equal")
+		assert.Truef(t, result.Items[2].ID.IsZero(), "Id is not nil")
+		assert.NoErrorf(t, result.Items[3].Err, "Unexpected error")
+		assert.Lenf(t, result.Failed(), 2, "Count of failed is not equal")
+	})
+
+	t.Run("Insert ordered", func(t *testing.T) {
+
+		docs := []mongodb.DocUser{
+			{Name: "Ddd", Age: 30},
+			{Name: "Aaa", Age: 30, Email: "aaa@mail.com"},
+			{Name: "Eee", Age: 30},
+		}
+
+		result, err := db.SendDocumentsUser(ctx, collections[0], docs, mongodb.BulkOptions{Ordered: true})
+		require.Truef(t, errors.Is(err, mongodb.ErrBulkWrite), "Error is not equal")
+
+		assert.Equalf(t, int64(1), result.Inserted, "Count of inserted is not equal")
+		assert.Equalf(t, mongodb.ErrDocumentExists, result.Items[1].Err, "Error is not equal")
+		assert.Equalf(t, mongodb.ErrNotExecuted, result.Items[2].Err, "Error is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[0], "Eee")
+		require.Errorf(t, err, "Document is inserted")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		updates := []mongodb.UserUpdate{
+			{Name: "Aaa", Doc: mongodb.DocUser{Age: 31}},
+			{Name: "Zzz", Doc: mongodb.DocUser{Age: 31}},
+			{Name: "Ccc", Doc: mongodb.DocUser{Email: "ccc@mail.com"}},
+			{Name: "", Doc: mongodb.DocUser{Age: 31}},
+		}
+
+		result, err := db.UpdateDocumentsUserByName(ctx, collections[0], updates, mongodb.BulkOptions{})
+		require.Truef(t, errors.Is(err, mongodb.ErrBulkWrite), "Error is not equal")
+
+		assert.Equalf(t, int64(1), result.Matched, "Count of matched is not equal")
+		assert.Equalf(t, int64(1), result.Modified, "Count of modified is not equal")
+		assert.Equalf(t, mongodb.ErrUpdateDocument, result.Items[1].Err, "Error is not equal")
+		assert.Truef(t, errors.Is(result.Items[2].Err, mongodb.ErrValueAge), "Error is not equal")
+		assert.Equalf(t, mongodb.ErrEmptyValueName, result.Items[3].Err, "Error is not equal")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 31, rxDoc.Age, "Age is not updated")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+
+		result, err := db.DelDocumentsUserByName(ctx, collections[0], []string{"Aaa", "Zzz", "Ccc"}, mongodb.BulkOptions{Ordered: true})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(2), result.Deleted, "Count of deleted is not equal")
+		assert.Emptyf(t, result.Failed(), "Failed is not empty")
+	})
+}
+
+// Test RecvDocumentUserByName
+func testRecvDocumentUserByName(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByName("", "AAA")
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByName(collections[0], "")
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Missing document", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByName(collections[0], "B")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+		require.Equalf(t, "Function FindOne return error: <mongo: no documents in result>", err.Error(), "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Aaa", Age: 20, Email: "aaa@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.NoErrorf(t, err, "Unexpected error recieve")
+		assert.Equalf(t, doc, withoutMeta(rxDoc), "Document is not equal")
+	})
+}
+
+// Test DelDocumentUserByName
+func testDelDocumentUserByName(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.DelDocumentUserByName("", "AAA")
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.DelDocumentUserByName(collections[0], "")
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		cnt, err := db.DelDocumentUserByName(collections[0], "CCC")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(0), cnt, "Value is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		doc := mongodb.DocUser{Name: "Ccc", Age: 30, Email: "ccc@mail.com"}
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		cnt, err := db.DelDocumentUserByName(collections[0], doc.Name)
+		require.NoErrorf(t, err, "Unexpected error delete")
+		assert.Equalf(t, int64(1), cnt, "Value is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Document is not deleted")
+	})
+}
+
+// Test DelAllDocumentsUserByName and DelDocumentsUserByQuery
+func testDeleteMany(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	users := []mongodb.DocUser{
+		{Name: "Aaa", Age: 30},
+		{Name: "Aaa", Age: 31},
+		{Name: "Bbb", Age: 40, Email: "bbb@example.com"},
+		{Name: "Ccc", Age: 50, Email: "ccc@example.com"},
+		{Name: "Ddd", Age: 60},
+	}
+	for _, u := range users {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	names := func(t *testing.T) []mongodb.DocUser {
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().SortBy("name", false).Project("name"))
+		require.NoErrorf(t, err, "Unexpected error")
+
+		return withoutMetaAll(docs)
+	}
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.DelAllDocumentsUserByName(ctx, collections[0], "", mongodb.DeleteOptions{})
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Empty filter", func(t *testing.T) {
+
+		_, err := db.DelDocumentsUserByQuery(ctx, collections[0], nil, mongodb.DeleteOptions{})
+		require.Equalf(t, mongodb.ErrEmptyFilter, err, "Error is not equal")
+
+		_, err = db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery(), mongodb.DeleteOptions{DryRun: true})
+		require.Equalf(t, mongodb.ErrEmptyFilter, err, "Error is not equal")
+	})
+
+	t.Run("Not correct query", func(t *testing.T) {
+
+		_, err := db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery().AgeMin(1).Limit(1), mongodb.DeleteOptions{})
+		require.Equalf(t, mongodb.ErrNotCorrectDeleteQuery, err, "Error is not equal")
+
+		_, err = db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery().SortBy("phone", false), mongodb.DeleteOptions{})
+		require.Truef(t, errors.Is(err, mongodb.ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+
+		n, err := db.DelAllDocumentsUserByName(ctx, collections[0], "Aaa", mongodb.DeleteOptions{DryRun: true})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(2), n, "Count is not equal")
+
+		n, err = db.DelDocumentsUserByQuery(ctx, collections[0], nil, mongodb.DeleteOptions{DryRun: true, AllowEmptyFilter: true})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(len(users)), n, "Count is not equal")
+
+		assert.Lenf(t, names(t), len(users), "Documents are deleted")
+	})
+
+	t.Run("By name", func(t *testing.T) {
+
+		n, err := db.DelAllDocumentsUserByName(ctx, collections[0], "Aaa", mongodb.DeleteOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(2), n, "Count is not equal")
+
+		want := []mongodb.DocUser{{Name: "Bbb"}, {Name: "Ccc"}, {Name: "Ddd"}}
+		assert.Equalf(t, want, names(t), "Documents is not equal")
+	})
+
+	t.Run("By query", func(t *testing.T) {
+
+		n, err := db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery().EmailDomain("example.com").AgeMax(45), mongodb.DeleteOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		n, err = db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery().Email("ccc@example.com"), mongodb.DeleteOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		assert.Equalf(t, []mongodb.DocUser{{Name: "Ddd"}}, names(t), "Documents is not equal")
+	})
+
+	t.Run("All documents", func(t *testing.T) {
+
+		n, err := db.DelDocumentsUserByQuery(ctx, collections[0], nil, mongodb.DeleteOptions{AllowEmptyFilter: true})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		assert.Emptyf(t, names(t), "Documents is not empty")
+	})
+}
+
+// Test MoveDocumentUserTx
+func testMoveDocumentUserTx(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	doc := mongodb.DocUser{Name: "A", Age: 30, Email: "a@mail.mail"}
+
+	t.Run("Missing srcCollection name", func(t *testing.T) {
+
+		err := db.MoveDocumentUserTx("", collections[1], doc)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing destCollection name", func(t *testing.T) {
+
+		err := db.MoveDocumentUserTx(collections[0], "", doc)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		err := db.MoveDocumentUserTx(collections[0], collections[1], mongodb.DocUser{Age: 30})
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		err := db.MoveDocumentUserTx(collections[0], collections[1], mongodb.DocUser{Name: "Missing"})
+		require.Errorf(t, err, "Error is not exists")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.MoveDocumentUserTx(collections[0], collections[1], doc)
+		require.NoErrorf(t, err, "Unexpected error move")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[1], doc.Name)
+		require.NoErrorf(t, err, "Unexpected error receive")
+		assert.Equalf(t, doc, withoutMeta(rxDoc), "Document is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Document should be deleted from source collection")
+	})
+
+	t.Run("Duplicate in collection, which is not set up", func(t *testing.T) {
+
+		notSetUp := dropOnCleanup(t, db, "conformance-not-set-up")
+
+		for i := 0; i < 2; i++ {
+			_, err := db.SendDocumentUser(collections[0], doc)
+			require.NoErrorf(t, err, "Unexpected error send")
+
+			err = db.MoveDocumentUserTx(collections[0], notSetUp, doc)
+			if i == 0 {
+				require.NoErrorf(t, err, "Unexpected error move")
+			} else {
+				require.Errorf(t, err, "Error is not exists")
+			}
+		}
+
+		_, err := db.RecvDocumentUserByName(collections[0], doc.Name)
+		require.NoErrorf(t, err, "Document should be kept in source collection")
+	})
+}
+
+// Test operations by id
+func testByID(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	id, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+	require.Falsef(t, id.IsZero(), "Id is zero")
+
+	missing := primitive.NewObjectID()
+
+	t.Run("Missing id", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByID(ctx, collections[0], primitive.NilObjectID)
+		require.Equalf(t, mongodb.ErrEmptyID, err, "Error is not equal")
+
+		err = db.UpdateDocumentUserByID(ctx, collections[0], primitive.NilObjectID, mongodb.DocUser{Age: 31})
+		require.Equalf(t, mongodb.ErrEmptyID, err, "Error is not equal")
+
+		_, err = db.DelDocumentUserByID(ctx, collections[0], primitive.NilObjectID)
+		require.Equalf(t, mongodb.ErrEmptyID, err, "Error is not equal")
+
+		err = db.MoveDocumentUserByIDTx(ctx, collections[0], collections[1], primitive.NilObjectID)
+		require.Equalf(t, mongodb.ErrEmptyID, err, "Error is not equal")
+	})
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByID(ctx, "", id)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+
+		err = db.MoveDocumentUserByIDTx(ctx, collections[0], "", id)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByID(ctx, collections[0], missing)
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+
+		err = db.UpdateDocumentUserByID(ctx, collections[0], missing, mongodb.DocUser{Age: 31})
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+
+		n, err := db.DelDocumentUserByID(ctx, collections[0], missing)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(0), n, "Count is not equal")
+
+		err = db.MoveDocumentUserByIDTx(ctx, collections[0], collections[1], missing)
+		require.Errorf(t, err, "Error is not exists")
+	})
+
+	t.Run("Recieve", func(t *testing.T) {
+
+		rxDoc, err := db.RecvDocumentUserByID(ctx, collections[0], id)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, id, rxDoc.ID, "Id is not equal")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"}, withoutMeta(rxDoc), "Document is not equal")
+
+		byName, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, id, byName.ID, "Id is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		err := db.UpdateDocumentUserByID(ctx, collections[0], id, mongodb.DocUser{ID: missing, Name: "Bbb", Age: 31})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByID(ctx, collections[0], id)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Bbb", Age: 31, Email: "aaa@mail.com"}, withoutMeta(rxDoc), "Document is not equal")
+
+		_, err = db.PatchDocumentUserByName(ctx, collections[0], "Bbb", mongodb.NewPatch().Set("_id", missing))
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPatch), "Error is not equal")
+	})
+
+	t.Run("Given id", func(t *testing.T) {
+
+		given := primitive.NewObjectID()
+
+		rxID, err := db.SendDocumentUser(collections[0], mongodb.DocUser{ID: given, Name: "Ccc", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, given, rxID, "Id is not equal")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{ID: given, Name: "Ddd", Age: 30})
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	})
+
+	t.Run("Move and delete", func(t *testing.T) {
+
+		err := db.MoveDocumentUserByIDTx(ctx, collections[0], collections[1], id)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByID(ctx, collections[1], id)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "Bbb", rxDoc.Name, "Name is not equal")
+
+		n, err := db.DelDocumentUserByID(ctx, collections[1], id)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		_, err = db.RecvDocumentUserByID(ctx, collections[1], id)
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+	})
+}
+
+// Test operations by email
+func testByEmail(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30, Email: "Aaa@Mail.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	t.Run("Missing email", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByEmail(ctx, collections[0], " ")
+		require.Equalf(t, mongodb.ErrEmptyEmail, err, "Error is not equal")
+
+		err = db.UpdateDocumentUserByEmail(ctx, collections[0], "", mongodb.DocUser{Age: 31})
+		require.Equalf(t, mongodb.ErrEmptyEmail, err, "Error is not equal")
+
+		_, err = db.DelDocumentUserByEmail(ctx, collections[0], "")
+		require.Equalf(t, mongodb.ErrEmptyEmail, err, "Error is not equal")
+
+		err = db.MoveDocumentUserByEmailTx(ctx, collections[0], collections[1], "")
+		require.Equalf(t, mongodb.ErrEmptyEmail, err, "Error is not equal")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByEmail(ctx, collections[0], "bbb@mail.com")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+	})
+
+	t.Run("Recieve with normalization", func(t *testing.T) {
+
+		rxDoc, err := db.RecvDocumentUserByEmail(ctx, collections[0], " AAA@MAIL.com ")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "Aaa", rxDoc.Name, "Name is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		err := db.UpdateDocumentUserByEmail(ctx, collections[0], "Aaa@mail.com", mongodb.DocUser{Age: 31})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 31, rxDoc.Age, "Age is not equal")
+
+		err = db.UpdateDocumentUserByEmail(ctx, collections[0], "zzz@mail.com", mongodb.DocUser{Age: 31})
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Move and delete", func(t *testing.T) {
+
+		err := db.MoveDocumentUserByEmailTx(ctx, collections[0], collections[1], "Aaa@mail.com")
+		require.NoErrorf(t, err, "Unexpected error")
+
+		n, err := db.DelDocumentUserByEmail(ctx, collections[1], "Aaa@mail.com")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		n, err = db.DelDocumentUserByEmail(ctx, collections[1], "Aaa@mail.com")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(0), n, "Count is not equal")
+	})
+}
+
+// Test WithTransaction
+func testWithTransaction(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	t.Run("Missing function", func(t *testing.T) {
+
+		err := db.WithTransaction(ctx, nil)
+		require.Equalf(t, mongodb.ErrEmptyTxFunction, err, "Error is not equal")
+	})
+
+	t.Run("Commit", func(t *testing.T) {
+
+		err := db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+
+			require.NotNilf(t, tx.Context(), "Context is nil")
+
+			if _, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Bbb", Age: 40}); err != nil {
+				return err
+			}
+			if err := tx.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{Age: 31}); err != nil {
+				return err
+			}
+			if err := tx.MoveDocumentUser(collections[0], collections[1], mongodb.DocUser{Name: "Bbb"}); err != nil {
+				return err
+			}
+
+			// Own writes are visible in transaction
+			doc, err := tx.RecvDocumentUserByName(collections[1], "Bbb")
+			if err != nil {
+				return err
+			}
+			_, err = tx.RecvDocumentUserByID(collections[1], doc.ID)
+			return err
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 31, rxDoc.Age, "Age is not equal")
+
+		rxDoc, err = db.RecvDocumentUserByName(collections[1], "Bbb")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 40, rxDoc.Age, "Age is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[0], "Bbb")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+	})
+
+	t.Run("Abort", func(t *testing.T) {
+
+		errAbort := errors.New("abort")
+
+		err := db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+
+			if _, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ccc", Age: 50}); err != nil {
+				return err
+			}
+			if _, err := tx.PatchDocumentUserByName(collections[0], "Aaa", mongodb.NewPatch().Inc("age", 1)); err != nil {
+				return err
+			}
+			if _, err := tx.UpsertDocumentUserByName(collections[0], "Ddd", mongodb.DocUser{Name: "Ddd", Age: 60}); err != nil {
+				return err
+			}
+			if _, err := tx.DelDocumentUserByName(collections[1], "Bbb"); err != nil {
+				return err
+			}
+
+			docs, _, err := tx.FindDocumentsUser(collections[0], mongodb.NewUserQuery())
+			if err != nil {
+				return err
+			}
+			if len(docs) != 3 {
+				return fmt.Errorf("count of documents: %d", len(docs))
+			}
+
+			return errAbort
+		})
+		require.Truef(t, errors.Is(err, errAbort), "Error is not equal")
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[0], mongodb.NewUserQuery())
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Equalf(t, 1, len(docs), "Count of documents is not equal")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 31}, withoutMeta(docs[0]), "Document is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[1], "Bbb")
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Error of operation", func(t *testing.T) {
+
+		err := db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+
+			if _, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Eee", Age: 20}); err != nil {
+				return err
+			}
+			_, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 31})
+			return err
+		})
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+
+		_, err = db.RecvDocumentUserByName(collections[0], "Eee")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+	})
+}
+
+// Receive next event of stream. Returns event.
+func nextEvent(t *testing.T, events <-chan mongodb.ChangeEvent) mongodb.ChangeEvent {
+
+	t.Helper()
+
+	select {
+	case ev, ok := <-events:
+		require.Truef(t, ok, "Channel of events is closed")
+		return ev
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "Event is not received")
+	}
+
+	return mongodb.ChangeEvent{}
+}
+
+// Wait closing of stream. Returns error of stream.
+func closedStream(t *testing.T, events <-chan mongodb.ChangeEvent, errs <-chan error) error {
+
+	t.Helper()
+
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return <-errs
+			}
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "Stream is not closed")
+		}
+	}
+}
+
+// Test Watch
+func testWatch(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		_, _, err := db.Watch(ctx, mongodb.WatchOptions{Buffer: -1})
+		require.Equalf(t, mongodb.ErrNegativeBuffer, err, "Error is not equal")
+
+		store := mongodb.NewMemoryCheckpoints()
+		token, err := bson.Marshal(bson.M{"token": 1})
+		require.NoErrorf(t, err, "Unexpected error")
+		require.NoErrorf(t, store.Save(ctx, "wrong", token), "Unexpected error")
+
+		_, _, err = db.Watch(ctx, mongodb.WatchOptions{Checkpoints: store, CheckpointKey: "wrong"})
+		require.Equalf(t, mongodb.ErrNotCorrectResumeToken, err, "Error is not equal")
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, errs, err := db.Watch(wctx, mongodb.WatchOptions{Collection: collections[0]})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		id, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		ev := nextEvent(t, events)
+		assert.Equalf(t, mongodb.OperationInsert, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, collections[0], ev.Collection, "Collection is not equal")
+		assert.Equalf(t, id, ev.DocumentKey, "Key is not equal")
+		require.NotNilf(t, ev.Doc, "Document is nil")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 30}, withoutMeta(*ev.Doc), "Document is not equal")
+		assert.NotNilf(t, ev.Token, "Token is nil")
+
+		// Other collection is not watched
+		_, err = db.SendDocumentUser(collections[1], mongodb.DocUser{Name: "Bbb", Age: 40})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{Age: 31})
+		require.NoErrorf(t, err, "Unexpected error update")
+
+		ev = nextEvent(t, events)
+		assert.Equalf(t, mongodb.OperationUpdate, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, id, ev.DocumentKey, "Key is not equal")
+		require.NotNilf(t, ev.Update, "Description of update is nil")
+		assert.Equalf(t, int32(31), ev.Update.UpdatedFields["age"], "Age is not equal")
+		require.NotNilf(t, ev.Doc, "Document is nil")
+		assert.Equalf(t, 31, ev.Doc.Age, "Age is not equal")
+
+		_, err = db.DelDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error delete")
+
+		ev = nextEvent(t, events)
+		assert.Equalf(t, mongodb.OperationDelete, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, id, ev.DocumentKey, "Key is not equal")
+		assert.Nilf(t, ev.Doc, "Document is not nil")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("DB", func(t *testing.T) {
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, errs, err := db.Watch(wctx, mongodb.WatchOptions{Buffer: 4})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		err = db.MoveDocumentUserTx(collections[1], collections[0], mongodb.DocUser{Name: "Bbb"})
+		require.NoErrorf(t, err, "Unexpected error move")
+
+		got := map[mongodb.OperationType]string{}
+		for range 2 {
+			ev := nextEvent(t, events)
+			got[ev.Operation] = ev.Collection
+
+			// Collections of users are not set, so documents are raw
+			assert.Nilf(t, ev.Doc, "Document is not nil")
+			if ev.Operation == mongodb.OperationInsert {
+				require.NotNilf(t, ev.FullDocument, "Document is nil")
+				assert.Equalf(t, "Bbb", ev.FullDocument.Lookup("name").StringValue(), "Name is not equal")
+			}
+		}
+		assert.Equalf(t, map[mongodb.OperationType]string{
+			mongodb.OperationInsert: collections[0],
+			mongodb.OperationDelete: collections[1],
+		}, got, "Events are not equal")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("DB with checkpoints in collection", func(t *testing.T) {
+
+		store, err := mongodb.NewCollectionCheckpoints(db, checkpointsCollection)
+		if errors.Is(err, mongodb.ErrNotSupportedDB) {
+			t.Skip("Checkpoints in collection are not supported by implementation")
+		}
+		require.NoErrorf(t, err, "Unexpected error")
+
+		defer func() {
+			assert.NoErrorf(t, db.DropCollection(checkpointsCollection), "Unexpected error DropCollection")
+		}()
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, errs, err := db.Watch(wctx, mongodb.WatchOptions{Checkpoints: store, CheckpointKey: "conformance",
+			UserCollections: collections, Buffer: 4})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		// Saves of checkpoints are not events of stream
+		for _, name := range []string{"Www", "Xxx"} {
+			_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: name, Age: 30})
+			require.NoErrorf(t, err, "Unexpected error send")
+
+			ev := nextEvent(t, events)
+			assert.Equalf(t, mongodb.OperationInsert, ev.Operation, "Operation is not equal")
+			assert.Equalf(t, collections[0], ev.Collection, "Collection is not equal")
+			require.NotNilf(t, ev.Doc, "Document is nil")
+			assert.Equalf(t, name, ev.Doc.Name, "Name is not equal")
+		}
+
+		token, err := store.Load(ctx, "conformance")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.NotNilf(t, token, "Token is not saved")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("Checkpoint after receive", func(t *testing.T) {
+
+		store := mongodb.NewMemoryCheckpoints()
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		key := "conformance"
+		events, errs, err := db.Watch(wctx, mongodb.WatchOptions{Collection: collections[0], Checkpoints: store,
+			CheckpointKey: key, Buffer: 4})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		for _, name := range []string{"Yyy", "Zzz"} {
+			_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: name, Age: 30})
+			require.NoErrorf(t, err, "Unexpected error send")
+		}
+
+		// Buffer is ignored, so no event is taken from stream before receive
+		time.Sleep(200 * time.Millisecond)
+		token, err := store.Load(ctx, key)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Nilf(t, token, "Token is saved before receive")
+
+		ev := nextEvent(t, events)
+		assert.Equalf(t, "Yyy", ev.Doc.Name, "Name is not equal")
+		require.Eventuallyf(t, func() bool {
+			token, err := store.Load(ctx, key)
+			return err == nil && bytes.Equal(token, ev.Token)
+		}, time.Second, 10*time.Millisecond, "Token is not saved")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("Resume by checkpoint", func(t *testing.T) {
+
+		store := mongodb.NewMemoryCheckpoints()
+		opts := mongodb.WatchOptions{Collection: collections[0], Checkpoints: store}
+
+		wctx, cancel := context.WithCancel(ctx)
+		events, errs, err := db.Watch(wctx, opts)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ccc", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		ev := nextEvent(t, events)
+		assert.Equalf(t, "Ccc", ev.Doc.Name, "Name is not equal")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+
+		// Changes without subscriber
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ddd", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		wctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		events, errs, err = db.Watch(wctx, opts)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev = nextEvent(t, events)
+		assert.Equalf(t, mongodb.OperationInsert, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, "Ddd", ev.Doc.Name, "Name is not equal")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("Transaction", func(t *testing.T) {
+
+		wctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		events, errs, err := db.Watch(wctx, mongodb.WatchOptions{Collection: collections[0]})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		errAbort := errors.New("abort")
+		err = db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+			if _, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Eee", Age: 30}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		require.Truef(t, errors.Is(err, errAbort), "Error is not equal")
+
+		err = db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+			_, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Fff", Age: 30})
+			return err
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev := nextEvent(t, events)
+		assert.Equalf(t, "Fff", ev.Doc.Name, "Name is not equal")
+
+		cancel()
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+
+	t.Run("Drop of collection", func(t *testing.T) {
+
+		events, errs, err := db.Watch(ctx, mongodb.WatchOptions{Collection: collections[1]})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = db.SendDocumentUser(collections[1], mongodb.DocUser{Name: "Ggg", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.DropCollection(collections[1])
+		require.NoErrorf(t, err, "Unexpected error drop")
+
+		assert.Equalf(t, mongodb.OperationInsert, nextEvent(t, events).Operation, "Operation is not equal")
+		assert.Equalf(t, mongodb.OperationDrop, nextEvent(t, events).Operation, "Operation is not equal")
+		assert.Equalf(t, mongodb.OperationInvalidate, nextEvent(t, events).Operation, "Operation is not equal")
+
+		require.NoErrorf(t, closedStream(t, events, errs), "Unexpected error of stream")
+	})
+}
+
+// Test FindDocumentsUser
+func testFindDocumentsUser(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	users := []mongodb.DocUser{
+		{Name: "Anna", Age: 25, Email: "anna@example.com"},
+		{Name: "Andrew", Age: 40, Email: "andrew@mail.com"},
+		{Name: "Boris", Age: 33, Email: "boris@example.com"},
+		{Name: "Clara", Age: 25, Email: "clara@mail.com"},
+		{Name: "Dmitry", Age: 19, Email: "dmitry@example.com"},
+	}
+	for _, u := range users {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, _, err := db.FindDocumentsUser(ctx, "", nil)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+
+		_, _, err := db.FindDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().SortBy("phone", false))
+		require.Truef(t, errors.Is(err, mongodb.ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Not correct token", func(t *testing.T) {
+
+		_, _, err := db.FindDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().After("???"))
+		require.Equalf(t, mongodb.ErrNotCorrectPageToken, err, "Error is not equal")
+	})
+
+	t.Run("Filters", func(t *testing.T) {
+
+		q := mongodb.NewUserQuery().AgeBetween(20, 35).SortBy("name", false)
+
+		docs, next, err := db.FindDocumentsUser(ctx, collections[0], q)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Emptyf(t, next, "Token is not empty")
+		assert.Equalf(t, []mongodb.DocUser{users[0], users[2], users[3]}, withoutMetaAll(docs), "Documents is not equal")
+
+		q = mongodb.NewUserQuery().EmailDomain("example.com").NamePrefix("An")
+
+		docs, _, err = db.FindDocumentsUser(ctx, collections[0], q)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []mongodb.DocUser{users[0]}, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Sort and projection", func(t *testing.T) {
+
+		q := mongodb.NewUserQuery().AgeMin(1).SortBy("age", true).SortBy("name", false).Project("name")
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[0], q)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []mongodb.DocUser{{Name: "Andrew"}, {Name: "Boris"}, {Name: "Anna"}, {Name: "Clara"}, {Name: "Dmitry"}}
+		assert.Equalf(t, want, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Skip and limit", func(t *testing.T) {
+
+		q := mongodb.NewUserQuery().AgeMin(1).SortBy("name", false).Skip(1).Limit(2)
+
+		docs, next, err := db.FindDocumentsUser(ctx, collections[0], q)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.NotEmptyf(t, next, "Token is empty")
+		assert.Equalf(t, []mongodb.DocUser{users[0], users[2]}, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Keyset pagination", func(t *testing.T) {
+
+		var all []mongodb.DocUser
+		next := ""
+
+		for pages := 0; pages < 10; pages++ {
+
+			q := mongodb.NewUserQuery().AgeMin(1).SortBy("age", false).Limit(2).After(next)
+
+			docs, token, err := db.FindDocumentsUser(ctx, collections[0], q)
+			require.NoErrorf(t, err, "Unexpected error")
+
+			all = append(all, docs...)
+			next = token
+			if next == "" {
+				break
+			}
+		}
+
+		want := []mongodb.DocUser{users[4], users[0], users[3], users[2], users[1]}
+		assert.Equalf(t, want, withoutMetaAll(all), "Documents is not equal")
+	})
+
+	t.Run("Keyset pagination with missing values", func(t *testing.T) {
+
+		for _, u := range []mongodb.DocUser{{Name: "Egor", Age: 50}, {Name: "Fedor", Age: 51}, {Name: "Gleb", Age: 52}} {
+			_, err := db.SendDocumentUser(collections[0], u)
+			require.NoErrorf(t, err, "Unexpected error send")
+		}
+
+		sorts := []func(q *mongodb.UserQuery) *mongodb.UserQuery{
+			func(q *mongodb.UserQuery) *mongodb.UserQuery { return q.SortBy("email", false) },
+			func(q *mongodb.UserQuery) *mongodb.UserQuery { return q.SortBy("email", true) },
+			func(q *mongodb.UserQuery) *mongodb.UserQuery { return q.SortBy("email", true).SortBy("_id", true) },
+			func(q *mongodb.UserQuery) *mongodb.UserQuery { return q.SortBy("_id", false) },
+		}
+
+		for i, sortBy := range sorts {
+
+			want, _, err := db.FindDocumentsUser(ctx, collections[0], sortBy(mongodb.NewUserQuery()))
+			require.NoErrorf(t, err, "Unexpected error")
+			require.Lenf(t, want, len(users)+3, "Count is not equal")
+
+			var all []mongodb.DocUser
+			next := ""
+
+			for pages := 0; pages < 10; pages++ {
+
+				docs, token, err := db.FindDocumentsUser(ctx, collections[0], sortBy(mongodb.NewUserQuery()).Limit(2).After(next))
+				require.NoErrorf(t, err, "Unexpected error")
+
+				all = append(all, docs...)
+				next = token
+				if next == "" {
+					break
+				}
+
+				// Token has only values of sorting fields
+				spec, err := sortBy(mongodb.NewUserQuery()).Spec()
+				require.NoErrorf(t, err, "Unexpected error")
+				pt, err := mongodb.DecodePageToken(next)
+				require.NoErrorf(t, err, "Unexpected error decode")
+				assert.Lenf(t, pt.Keys, len(spec.Sort), "Count of keys is not equal")
+			}
+
+			assert.Equalf(t, want, all, "Documents is not equal for sorting %d", i)
+		}
+	})
+}
+
+// Users of tests of aggregation.
+var aggregateUsers = []mongodb.DocUser{
+	{Name: "Anna", Age: 25, Email: "anna@example.com"},
+	{Name: "Andrew", Age: 40, Email: "andrew@mail.com"},
+	{Name: "Boris", Age: 33, Email: "boris@example.com"},
+	{Name: "Clara", Age: 25, Email: "clara@mail.com"},
+	{Name: "Dmitry", Age: 19, Email: "dmitry@example.com"},
+	{Name: "Eva", Age: 65, Email: "eva@corp.org"},
+	{Name: "Fedor", Age: 45},
+}
+
+// Test Aggregate
+func testAggregate(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	for _, u := range aggregateUsers {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+	_, err := db.SendDocumentUser(collections[1], mongodb.DocUser{Name: "Anna", Age: 70, Email: "anna@other.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		var results []bson.M
+		err := db.Aggregate(ctx, "", nil, &results)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Not correct results", func(t *testing.T) {
+
+		err := db.Aggregate(ctx, collections[0], nil, []bson.M{})
+		require.Equalf(t, mongodb.ErrNotCorrectResults, err, "Error is not equal")
+
+		err = db.Aggregate(ctx, collections[0], nil, nil)
+		require.Equalf(t, mongodb.ErrNotCorrectResults, err, "Error is not equal")
+	})
+
+	t.Run("Not correct pipeline", func(t *testing.T) {
+
+		var results []bson.M
+		err := db.Aggregate(ctx, collections[0], mongodb.NewPipeline().Lookup("", "name", "name", "other"), &results)
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPipeline), "Error is not equal")
+
+		q := mongodb.NewUserQuery().AgeMin(1).SortBy("name", false)
+		err = db.Aggregate(ctx, collections[0], mongodb.NewPipeline().MatchUsers(q), &results)
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPipeline), "Error is not equal")
+	})
+
+	t.Run("Without pipeline", func(t *testing.T) {
+
+		docs, err := mongodb.AggregateAs[mongodb.DocUser](ctx, db, collections[0], nil)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Lenf(t, docs, len(aggregateUsers), "Count is not equal")
+	})
+
+	t.Run("Match", func(t *testing.T) {
+
+		p := mongodb.NewPipeline().MatchUsers(mongodb.NewUserQuery().EmailDomain("example.com").AgeMin(20))
+
+		docs, err := mongodb.AggregateAs[mongodb.DocUser](ctx, db, collections[0], p)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.ElementsMatchf(t, []mongodb.DocUser{aggregateUsers[0], aggregateUsers[2]}, withoutMetaAll(docs), "Documents is not equal")
+
+		p = mongodb.NewPipeline().Match(bson.M{"$or": bson.A{bson.M{"age": bson.M{"$gt": 60}}, bson.M{"name": bson.M{"$in": bson.A{"Anna"}}}}})
+
+		docs, err = mongodb.AggregateAs[mongodb.DocUser](ctx, db, collections[0], p)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.ElementsMatchf(t, []mongodb.DocUser{aggregateUsers[0], aggregateUsers[5]}, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Group and sort", func(t *testing.T) {
+
+		type byAge struct {
+			Age   int   `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+
+		p := mongodb.NewPipeline().
+			MatchUsers(mongodb.NewUserQuery().AgeMin(20)).
+			Group(mongodb.Field("age"), mongodb.Accumulators{"count": mongodb.Count()}).
+			Sort(mongodb.SortField{Field: "count", Desc: true}, mongodb.SortField{Field: "_id"})
+
+		results, err := mongodb.AggregateAs[byAge](ctx, db, collections[0], p)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []byAge{{25, 2}, {33, 1}, {40, 1}, {45, 1}, {65, 1}}
+		assert.Equalf(t, want, results, "Results is not equal")
+	})
+
+	t.Run("Accumulators", func(t *testing.T) {
+
+		type byAge struct {
+			Age   int    `bson:"_id"`
+			First string `bson:"first"`
+		}
+
+		p := mongodb.NewPipeline().
+			MatchUsers(mongodb.NewUserQuery().AgeMin(20)).
+			Group(mongodb.Field("age"), mongodb.Accumulators{"first": mongodb.Min(mongodb.Field("name"))}).
+			Sort(mongodb.SortField{Field: "_id"})
+
+		results, err := mongodb.AggregateAs[byAge](ctx, db, collections[0], p)
+		skipNotSupported(t, err)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []byAge{{25, "Anna"}, {33, "Boris"}, {40, "Andrew"}, {45, "Fedor"}, {65, "Eva"}}
+		assert.Equalf(t, want, results, "Results is not equal")
+
+		type total struct {
+			Sum int64   `bson:"sum"`
+			Avg float64 `bson:"avg"`
+			Max int     `bson:"max"`
+		}
+
+		p = mongodb.NewPipeline().Group(nil, mongodb.Accumulators{
+			"sum": mongodb.Sum(mongodb.Field("age")),
+			"avg": mongodb.Avg(mongodb.Field("age")),
+			"max": mongodb.Max(mongodb.Field("age")),
+		})
+
+		totals, err := mongodb.AggregateAs[total](ctx, db, collections[0], p)
+		skipNotSupported(t, err)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []total{{252, 36, 65}}, totals, "Results is not equal")
+	})
+
+	t.Run("Project, unwind, skip and limit", func(t *testing.T) {
+
+		type name struct {
+			Name  string `bson:"name"`
+			Upper string `bson:"upper"`
+		}
+
+		p := mongodb.NewPipeline().
+			Group(nil, mongodb.Accumulators{"names": mongodb.Push(mongodb.Field("name"))}).
+			Unwind("names", false).
+			Project(bson.M{"_id": 0, "name": "$names", "upper": bson.M{"$toUpper": "$names"}}).
+			Sort(mongodb.SortField{Field: "name"}).
+			Skip(1).
+			Limit(2)
+
+		results, err := mongodb.AggregateAs[name](ctx, db, collections[0], p)
+		skipNotSupported(t, err)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []name{{"Anna", "ANNA"}, {"Boris", "BORIS"}}, results, "Results is not equal")
+	})
+
+	t.Run("Lookup", func(t *testing.T) {
+
+		type joined struct {
+			Name  string            `bson:"name"`
+			Other []mongodb.DocUser `bson:"other"`
+		}
+
+		p := mongodb.NewPipeline().
+			Match(bson.M{"name": bson.M{"$in": bson.A{"Anna", "Boris"}}}).
+			Lookup(collections[1], "name", "name", "other").
+			Sort(mongodb.SortField{Field: "name"}).
+			Project(bson.M{"_id": 0, "name": 1, "other": 1})
+
+		results, err := mongodb.AggregateAs[joined](ctx, db, collections[0], p)
+		skipNotSupported(t, err)
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Lenf(t, results, 2, "Count is not equal")
+
+		assert.Equalf(t, "Anna", results[0].Name, "Name is not equal")
+		require.Lenf(t, results[0].Other, 1, "Count is not equal")
+		assert.Equalf(t, 70, results[0].Other[0].Age, "Age is not equal")
+		assert.Equalf(t, "Boris", results[1].Name, "Name is not equal")
+		assert.Emptyf(t, results[1].Other, "Joined documents is not empty")
+	})
+
+	t.Run("Facet", func(t *testing.T) {
+
+		type facets struct {
+			Total []struct {
+				Count int64 `bson:"count"`
+			} `bson:"total"`
+			Young []struct {
+				Name string `bson:"name"`
+			} `bson:"young"`
+		}
+
+		p := mongodb.NewPipeline().Facet(map[string]*mongodb.Pipeline{
+			"total": mongodb.NewPipeline().Group(nil, mongodb.Accumulators{"count": mongodb.Count()}),
+			"young": mongodb.NewPipeline().
+				Match(bson.M{"age": bson.M{"$lt": 30}}).
+				Sort(mongodb.SortField{Field: "name"}).
+				Project(bson.M{"_id": 0, "name": 1}),
+		})
+
+		results, err := mongodb.AggregateAs[facets](ctx, db, collections[0], p)
+		skipNotSupported(t, err)
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Lenf(t, results, 1, "Count is not equal")
+		require.Lenf(t, results[0].Total, 1, "Count is not equal")
+		assert.Equalf(t, int64(len(aggregateUsers)), results[0].Total[0].Count, "Count is not equal")
+
+		names := []string{}
+		for _, y := range results[0].Young {
+			names = append(names, y.Name)
+		}
+		assert.Equalf(t, []string{"Anna", "Clara", "Dmitry"}, names, "Names is not equal")
+	})
+
+	t.Run("Bucket", func(t *testing.T) {
+
+		type bucket struct {
+			ID    interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		}
+
+		p := mongodb.NewPipeline().Bucket(mongodb.Field("age"), []interface{}{0, 30, 60}, "other", nil)
+
+		results, err := mongodb.AggregateAs[bucket](ctx, db, collections[0], p)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []bucket{{int32(0), 3}, {int32(30), 3}, {"other", 1}}
+		assert.Equalf(t, want, results, "Results is not equal")
+	})
+}
+
+// Skip test if implementation does not support operator of pipeline.
+func skipNotSupported(t *testing.T, err error) {
+
+	t.Helper()
+	if errors.Is(err, mongodb.ErrNotSupportedOperator) {
+		t.Skipf("Not supported: %v", err)
+	}
+}
+
+// Test UserStatsByAgeBucket and UserStatsByEmailDomain
+func testUserStats(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	for _, u := range aggregateUsers {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.UserStatsByAgeBucket(ctx, "", []int{0, 100})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+
+		_, err = db.UserStatsByEmailDomain(ctx, "")
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Not correct boundaries", func(t *testing.T) {
+
+		for _, boundaries := range [][]int{nil, {18}, {30, 18}, {18, 18, 30}} {
+			_, err := db.UserStatsByAgeBucket(ctx, collections[0], boundaries)
+			require.Equalf(t, mongodb.ErrValueBoundaries, err, "Error is not equal")
+		}
+	})
+
+	t.Run("By age bucket", func(t *testing.T) {
+
+		buckets, err := db.UserStatsByAgeBucket(ctx, collections[0], []int{18, 30, 50, 60})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []mongodb.AgeBucket{{Min: 18, Max: 30, Count: 3}, {Min: 30, Max: 50, Count: 3}, {Min: 50, Max: 60, Count: 0}}
+		assert.Equalf(t, want, buckets, "Buckets is not equal")
+	})
+
+	t.Run("By email domain", func(t *testing.T) {
+
+		domains, err := db.UserStatsByEmailDomain(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := []mongodb.DomainCount{{Domain: "example.com", Count: 3}, {Domain: "mail.com", Count: 2}, {Domain: "corp.org", Count: 1}}
+		assert.Equalf(t, want, domains, "Counts is not equal")
+	})
+
+	t.Run("Empty collection", func(t *testing.T) {
+
+		buckets, err := db.UserStatsByAgeBucket(ctx, collections[1], []int{0, 100})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []mongodb.AgeBucket{{Min: 0, Max: 100}}, buckets, "Buckets is not equal")
+
+		domains, err := db.UserStatsByEmailDomain(ctx, collections[1])
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Emptyf(t, domains, "Counts is not empty")
+	})
+}
+
+// Names of documents of sequence. Returns names and the first error.
+//
+// Params:
+//
+//	seq - sequence of documents
+func iterNames(seq iter.Seq2[mongodb.DocUser, error]) ([]string, error) {
+
+	names := []string{}
+	for doc, err := range seq {
+		if err != nil {
+			return names, err
+		}
+		names = append(names, doc.Name)
+	}
+
+	return names, nil
+}
+
+// Test IterDocumentsUser and IterAggregate
+func testIter(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	for _, u := range aggregateUsers {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	batch := mongodb.IterOptions{BatchSize: 2, MaxTime: 10 * time.Second}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := iterNames(db.IterDocumentsUser(ctx, "", nil, batch))
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+
+		for _, err := range db.IterAggregate(ctx, "", nil, batch) {
+			require.Truef(t, errors.Is(err, mongodb.ErrEmptyCollectionsName), "Error is not equal")
+		}
+	})
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		_, err := iterNames(db.IterDocumentsUser(ctx, collections[0], nil, mongodb.IterOptions{BatchSize: -1}))
+		require.Equalf(t, mongodb.ErrValueIterOptions, err, "Error is not equal")
+
+		_, err = iterNames(db.IterDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().Limit(-1), batch))
+		require.Equalf(t, mongodb.ErrValuePagination, err, "Error is not equal")
+
+		for _, err := range db.IterAggregate(ctx, collections[0], nil, mongodb.IterOptions{MaxTime: -time.Second}) {
+			require.Equalf(t, mongodb.ErrValueIterOptions, err, "Error is not equal")
+		}
+	})
+
+	t.Run("All documents", func(t *testing.T) {
+
+		names, err := iterNames(db.IterDocumentsUser(ctx, collections[0], nil, batch))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Lenf(t, names, len(aggregateUsers), "Count is not equal")
+	})
+
+	t.Run("Query", func(t *testing.T) {
+
+		q := mongodb.NewUserQuery().AgeMin(20).SortBy("age", false).SortBy("name", false).Skip(1).Limit(3).Project("name")
+
+		var docs []mongodb.DocUser
+		for doc, err := range db.IterDocumentsUser(ctx, collections[0], q, batch) {
+			require.NoErrorf(t, err, "Unexpected error")
+			docs = append(docs, doc)
+		}
+
+		want := []mongodb.DocUser{{Name: "Clara"}, {Name: "Boris"}, {Name: "Andrew"}}
+		assert.Equalf(t, want, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Break", func(t *testing.T) {
+
+		seq := db.IterDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().SortBy("name", false), batch)
+
+		names := []string{}
+		for doc, err := range seq {
+			require.NoErrorf(t, err, "Unexpected error")
+			names = append(names, doc.Name)
+			if len(names) == 3 {
+				break
+			}
+		}
+		assert.Equalf(t, []string{"Andrew", "Anna", "Boris"}, names, "Names is not equal")
+
+		// Sequence executes query again
+		all, err := iterNames(seq)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Lenf(t, all, len(aggregateUsers), "Count is not equal")
+	})
+
+	t.Run("Write in loop", func(t *testing.T) {
+
+		for doc, err := range db.IterDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().Name("Anna"), batch) {
+			require.NoErrorf(t, err, "Unexpected error")
+
+			_, err = db.SendDocumentUser(collections[1], withoutMeta(doc))
+			require.NoErrorf(t, err, "Unexpected error send")
+		}
+
+		_, err := db.RecvDocumentUserByName(collections[1], "Anna")
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		count := 0
+		var last error
+		for _, err := range db.IterDocumentsUser(ctx, collections[0], nil, batch) {
+			if err != nil {
+				last = err
+				continue
+			}
+			count++
+			cancel()
+		}
+
+		assert.Equalf(t, 1, count, "Count is not equal")
+		require.Truef(t, errors.Is(last, context.Canceled), "Error is not equal")
+	})
+
+	t.Run("Aggregate", func(t *testing.T) {
+
+		type byDomain struct {
+			Domain string `bson:"_id"`
+			Count  int64  `bson:"count"`
+		}
+
+		p := mongodb.NewPipeline().
+			Match(bson.M{"email": bson.M{"$regex": primitive.Regex{Pattern: "@mail.com$"}}}).
+			Group(mongodb.Field("email"), mongodb.Accumulators{"count": mongodb.Count()}).
+			Sort(mongodb.SortField{Field: "_id"})
+
+		var results []byDomain
+		for r, err := range mongodb.IterAggregateAs[byDomain](ctx, db, collections[0], p, batch) {
+			require.NoErrorf(t, err, "Unexpected error")
+			results = append(results, r)
+		}
+
+		want := []byDomain{{"andrew@mail.com", 1}, {"clara@mail.com", 1}}
+		assert.Equalf(t, want, results, "Results is not equal")
+
+		for raw, err := range db.IterAggregate(ctx, collections[0], nil, batch) {
+			require.NoErrorf(t, err, "Unexpected error")
+			_, ok := raw.Lookup("name").StringValueOK()
+			require.Truef(t, ok, "Document has no name")
+			break
+		}
+	})
+}
+
+// Test Export and Import
+func testTransfer(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	for _, u := range aggregateUsers {
+		_, err := db.SendDocumentUser(collections[0], u)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	byName := mongodb.NewUserQuery().SortBy("name", false)
+
+	src, _, err := db.FindDocumentsUser(ctx, collections[0], byName)
+	require.NoErrorf(t, err, "Unexpected error find")
+
+	// Collection of import is empty, with indexes
+	reset := func(t *testing.T) {
+		err := db.DropCollection(collections[1])
+		require.NoErrorf(t, err, "Unexpected error DropCollection")
+		err = db.CheckCreateDB([]string{collections[1]})
+		require.NoErrorf(t, err, "Unexpected error CheckCreateDB")
+	}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := mongodb.Export(ctx, db, "", &bytes.Buffer{}, mongodb.ExportOptions{Format: mongodb.FormatJSONL})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+
+		_, err = mongodb.Import(ctx, db, "", &bytes.Buffer{}, mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	for _, format := range []mongodb.TransferFormat{mongodb.FormatJSONL, mongodb.FormatJSONLRelaxed, mongodb.FormatCSV} {
+
+		t.Run("Round trip "+string(format), func(t *testing.T) {
+
+			reset(t)
+
+			buf := &bytes.Buffer{}
+			n, err := mongodb.Export(ctx, db, collections[0], buf, mongodb.ExportOptions{Format: format, BatchSize: 2})
+			require.NoErrorf(t, err, "Unexpected error export")
+			assert.Equalf(t, int64(len(aggregateUsers)), n, "Count is not equal")
+
+			res, err := mongodb.Import(ctx, db, collections[1], buf, mongodb.ImportOptions{Format: format, BatchSize: 3})
+			require.NoErrorf(t, err, "Unexpected error import")
+			assert.Equalf(t, mongodb.ImportResult{Read: n, Inserted: n}, res, "Result is not equal")
+
+			dst, _, err := db.FindDocumentsUser(ctx, collections[1], byName)
+			require.NoErrorf(t, err, "Unexpected error find")
+			require.Equalf(t, withoutMetaAll(src), withoutMetaAll(dst), "Documents is not equal")
+
+			for i := range src {
+				assert.Equalf(t, src[i].ID, dst[i].ID, "Id is not kept")
+				assert.Equalf(t, src[i].CreatedAt, dst[i].CreatedAt, "Time of creation is not kept")
+			}
+		})
+	}
+
+	t.Run("Filter and mapping", func(t *testing.T) {
+
+		opts := mongodb.ExportOptions{
+			Format: mongodb.FormatCSV,
+			Query:  mongodb.NewUserQuery().AgeMin(40).SortBy("name", false),
+			Fields: []mongodb.FieldMapping{{Field: "name", Column: "Name"}, {Field: "age"}, {Field: "email", Column: "Email"}},
+		}
+
+		buf := &bytes.Buffer{}
+		n, err := mongodb.Export(ctx, db, collections[0], buf, opts)
+		require.NoErrorf(t, err, "Unexpected error export")
+		assert.Equalf(t, int64(3), n, "Count is not equal")
+
+		want := "Name,age,Email\nAndrew,40,andrew@mail.com\nEva,65,eva@corp.org\nFedor,45,\n"
+		assert.Equalf(t, want, buf.String(), "Output is not equal")
+
+		reset(t)
+
+		res, err := mongodb.Import(ctx, db, collections[1], buf, mongodb.ImportOptions{Format: mongodb.FormatCSV, Fields: opts.Fields})
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: 3, Inserted: 3}, res, "Result is not equal")
+
+		doc, err := db.RecvDocumentUserByName(collections[1], "Eva")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Eva", Age: 65, Email: "eva@corp.org"}, withoutMeta(doc), "Document is not equal")
+	})
+
+	t.Run("Not correct record", func(t *testing.T) {
+
+		reset(t)
+
+		input := `{"name":"Anna","age":25}` + "\n" + `{"name":"Boris","age":0}` + "\n" + `{"name":"Clara","age":35}` + "\n"
+
+		res, err := mongodb.Import(ctx, db, collections[1], strings.NewReader(input), mongodb.ImportOptions{Format: mongodb.FormatJSONLRelaxed})
+		require.Errorf(t, err, "Expected error")
+		assert.Containsf(t, err.Error(), "line <2>", "Line is not reported")
+		assert.Equalf(t, mongodb.ImportResult{Read: 3, Inserted: 2}, res, "Result is not equal")
+
+		// Batch is written unordered, so record after the failed one stays
+		_, err = db.RecvDocumentUserByName(collections[1], "Clara")
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Duplicates", func(t *testing.T) {
+
+		reset(t)
+
+		buf := &bytes.Buffer{}
+		_, err := mongodb.Export(ctx, db, collections[0], buf, mongodb.ExportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error export")
+
+		_, err = mongodb.Import(ctx, db, collections[1], buf, mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error import")
+
+		// Exported documents with changed email of Anna and new document
+		changed := append([]mongodb.DocUser{}, src...)
+		changed[1].Email = "anna@corp.org"
+		changed = append(changed, mongodb.DocUser{Name: "Gleb", Age: 30})
+
+		input := &strings.Builder{}
+		for _, doc := range changed {
+			line, err := bson.MarshalExtJSON(doc, false, false)
+			require.NoErrorf(t, err, "Unexpected error")
+			input.Write(append(line, '\n'))
+		}
+
+		importAll := func(policy mongodb.DuplicatePolicy) (mongodb.ImportResult, error) {
+			opts := mongodb.ImportOptions{Format: mongodb.FormatJSONLRelaxed, BatchSize: 4, OnDuplicate: policy}
+			return mongodb.Import(ctx, db, collections[1], strings.NewReader(input.String()), opts)
+		}
+
+		_, err = importAll(mongodb.DuplicateFail)
+		require.Truef(t, errors.Is(err, mongodb.ErrDocumentExists), "Error is not equal")
+
+		res, err := importAll(mongodb.DuplicateSkip)
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: 8, Inserted: 1, Skipped: 7}, res, "Result is not equal")
+
+		doc, err := db.RecvDocumentUserByName(collections[1], "Anna")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "anna@example.com", doc.Email, "Document is replaced")
+
+		res, err = importAll(mongodb.DuplicateOverwrite)
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: 8, Replaced: 8}, res, "Result is not equal")
+
+		doc, err = db.RecvDocumentUserByName(collections[1], "Anna")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "anna@corp.org", doc.Email, "Document is not replaced")
+		assert.Equalf(t, changed[1].ID, doc.ID, "Id is not kept")
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[1], nil)
+		require.NoErrorf(t, err, "Unexpected error find")
+		assert.Lenf(t, docs, len(changed), "Count is not equal")
+	})
+
+	t.Run("Round trip into not empty collection", func(t *testing.T) {
+
+		reset(t)
+
+		buf := &bytes.Buffer{}
+		_, err := mongodb.Export(ctx, db, collections[0], buf, mongodb.ExportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error export")
+		output := buf.String()
+
+		_, err = mongodb.Import(ctx, db, collections[1], strings.NewReader(output), mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error import")
+
+		opts := mongodb.ImportOptions{Format: mongodb.FormatJSONL, OnDuplicate: mongodb.DuplicateOverwrite}
+		res, err := mongodb.Import(ctx, db, collections[1], strings.NewReader(output), opts)
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: int64(len(src)), Replaced: int64(len(src))}, res, "Result is not equal")
+
+		dst, _, err := db.FindDocumentsUser(ctx, collections[1], byName)
+		require.NoErrorf(t, err, "Unexpected error find")
+		require.Equalf(t, withoutMetaAll(src), withoutMetaAll(dst), "Documents is not equal")
+
+		for i := range src {
+			assert.Equalf(t, src[i].ID, dst[i].ID, "Id is not kept")
+		}
+	})
+
+	t.Run("Overwrite by id and by unique key", func(t *testing.T) {
+
+		reset(t)
+
+		buf := &bytes.Buffer{}
+		_, err := mongodb.Export(ctx, db, collections[0], buf, mongodb.ExportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error export")
+
+		_, err = mongodb.Import(ctx, db, collections[1], buf, mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error import")
+
+		// Namesake of Anna with other unique key
+		other := mongodb.DocUser{Name: "Anna", Age: 70, Email: "anna@other.com"}
+		otherID, err := db.SendDocumentUser(collections[1], other)
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		// Boris is renamed, Anna has no id and is found by unique key
+		renamed := src[2]
+		renamed.Name = "Bogdan"
+		byKey := withoutMeta(src[1])
+
+		input := &strings.Builder{}
+		for _, doc := range []mongodb.DocUser{renamed, byKey} {
+			line, err := bson.MarshalExtJSON(doc, false, false)
+			require.NoErrorf(t, err, "Unexpected error")
+			input.Write(append(line, '\n'))
+		}
+
+		opts := mongodb.ImportOptions{Format: mongodb.FormatJSONLRelaxed, OnDuplicate: mongodb.DuplicateOverwrite}
+		res, err := mongodb.Import(ctx, db, collections[1], strings.NewReader(input.String()), opts)
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: 2, Replaced: 2}, res, "Result is not equal")
+
+		doc, err := db.RecvDocumentUserByID(ctx, collections[1], src[2].ID)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "Bogdan", doc.Name, "Document is not replaced")
+
+		_, err = db.RecvDocumentUserByName(collections[1], "Boris")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+
+		doc, err = db.RecvDocumentUserByID(ctx, collections[1], src[1].ID)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, withoutMeta(src[1]), withoutMeta(doc), "Document is not equal")
+
+		doc, err = db.RecvDocumentUserByID(ctx, collections[1], otherID)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, other, withoutMeta(doc), "Namesake is changed")
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[1], nil)
+		require.NoErrorf(t, err, "Unexpected error find")
+		assert.Lenf(t, docs, len(src)+1, "Count is not equal")
+	})
+
+	t.Run("Overwrite replaces whole document", func(t *testing.T) {
+
+		reset(t)
+
+		buf := &bytes.Buffer{}
+		_, err := mongodb.Export(ctx, db, collections[0], buf, mongodb.ExportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error export")
+
+		_, err = mongodb.Import(ctx, db, collections[1], buf, mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+		require.NoErrorf(t, err, "Unexpected error import")
+
+		// Email of Anna is cleared, time of creation is taken from record
+		created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+		cleared := src[1]
+		cleared.Email = ""
+		cleared.CreatedAt = created
+
+		line, err := bson.MarshalExtJSON(cleared, true, false)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		opts := mongodb.ImportOptions{Format: mongodb.FormatJSONL, OnDuplicate: mongodb.DuplicateOverwrite}
+		res, err := mongodb.Import(ctx, db, collections[1], bytes.NewReader(append(line, '\n')), opts)
+		require.NoErrorf(t, err, "Unexpected error import")
+		assert.Equalf(t, mongodb.ImportResult{Read: 1, Replaced: 1}, res, "Result is not equal")
+
+		doc, err := db.RecvDocumentUserByID(ctx, collections[1], src[1].ID)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "", doc.Email, "Email is not removed")
+		assert.Equalf(t, created, doc.CreatedAt, "Time of creation is not kept")
+		assert.Equalf(t, src[1].CreatedBy, doc.CreatedBy, "Author is not kept")
+	})
+}
+
+// Test NewRepository: repository is supported by connection of mongodb.New only
+func testNewRepository(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	repo, err := mongodb.NewRepository(db, mongodb.RepositoryConfig[mongodb.DocUser]{KeyField: "name"})
+	if errors.Is(err, mongodb.ErrNotSupportedDB) {
+		t.Skip("Repository is not supported by implementation")
+	}
+	require.NoErrorf(t, err, "Unexpected error")
+	require.NotNilf(t, repo, "Pointer repository is nil")
+}
+
+// Test EnsureIndexes
+func testEnsureIndexes(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	byEmail := []mongodb.IndexSpec{{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		err := db.EnsureIndexes(ctx, "", byEmail)
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Missing indexes", func(t *testing.T) {
+
+		err := db.EnsureIndexes(ctx, collections[0], nil)
+		require.Equalf(t, mongodb.ErrEmptyIndexes, err, "Error is not equal")
+	})
+
+	t.Run("Missing keys", func(t *testing.T) {
+
+		err := db.EnsureIndexes(ctx, collections[0], []mongodb.IndexSpec{{Name: "empty"}})
+		require.Equalf(t, mongodb.ErrEmptyIndexKeys, err, "Error is not equal")
+	})
+
+	t.Run("Existing duplicates", func(t *testing.T) {
+
+		_, err := db.SendDocumentUser(collections[1], mongodb.DocUser{Name: "A", Age: 20, Email: "same@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+		_, err = db.SendDocumentUser(collections[1], mongodb.DocUser{Name: "B", Age: 20, Email: "same@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.EnsureIndexes(ctx, collections[1], byEmail)
+		require.Truef(t, errors.Is(err, mongodb.ErrDocumentExists), "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		err := db.EnsureIndexes(ctx, collections[0], byEmail)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		err = db.EnsureIndexes(ctx, collections[0], byEmail)
+		require.NoErrorf(t, err, "Unexpected error repeat")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "A", Age: 20, Email: "a@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "B", Age: 30, Email: "a@mail.com"})
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "B", Age: 30, Email: "b@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		err = db.UpdateDocumentUserByName(collections[0], "B", mongodb.DocUser{Age: 30, Email: "a@mail.com"})
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	})
+}
+
+// Test ListIndexes, DropIndex and SyncIndexes
+func testSyncIndexes(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	ttl := int32(3600)
+	specs := []mongodb.IndexSpec{
+		{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true, Sparse: true},
+		{Name: "by_age", Keys: bson.D{{Key: "age", Value: -1}}, PartialFilter: bson.M{"age": bson.M{"$gt": 18}}},
+		{Keys: bson.D{{Key: "createdAt", Value: 1}}, ExpireAfterSeconds: &ttl},
+	}
+
+	names := func(specs []mongodb.IndexSpec) []string {
+		out := []string{}
+		for _, s := range specs {
+			out = append(out, s.IndexName())
+		}
+		return out
+	}
+
+	t.Run("Missing collection name", func(t *testing.T) {
+
+		_, err := db.ListIndexes(ctx, "")
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+
+		_, err = db.SyncIndexes(ctx, "", specs, mongodb.IndexSyncOptions{})
+		require.Equalf(t, mongodb.ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+
+	t.Run("Drop index by _id", func(t *testing.T) {
+
+		err := db.DropIndex(ctx, collections[0], "_id_")
+		require.Equalf(t, mongodb.ErrNotCorrectIndex, err, "Error is not equal")
+	})
+
+	t.Run("Drop not exists index", func(t *testing.T) {
+
+		err := db.DropIndex(ctx, collections[0], "missing")
+		require.Truef(t, errors.Is(err, mongodb.ErrIndexNotFound), "Error is not equal")
+	})
+
+	t.Run("Not exists collection", func(t *testing.T) {
+
+		list, err := db.ListIndexes(ctx, "conformance-missing")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Emptyf(t, list, "List is not empty")
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+
+		report, err := db.SyncIndexes(ctx, collections[0], specs, mongodb.IndexSyncOptions{DryRun: true, DropUnknown: true})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, names(specs), report.Created, "Created is not equal")
+		assert.Equalf(t, []string{"name_1_age_1_email_1"}, report.Dropped, "Dropped is not equal")
+
+		list, err := db.ListIndexes(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []string{"_id_", "name_1_age_1_email_1"}, names(list), "Indexes are not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		report, err := db.SyncIndexes(ctx, collections[0], specs, mongodb.IndexSyncOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, names(specs), report.Created, "Created is not equal")
+		assert.Equalf(t, []string{"name_1_age_1_email_1"}, report.Unknown, "Unknown is not equal")
+		assert.Truef(t, report.Changed(), "Report has no changes")
+
+		// Repeat without changes
+		report, err = db.SyncIndexes(ctx, collections[0], specs, mongodb.IndexSyncOptions{})
+		require.NoErrorf(t, err, "Unexpected error repeat")
+		assert.Equalf(t, names(specs), report.Unchanged, "Unchanged is not equal")
+		assert.Falsef(t, report.Changed(), "Report has changes")
+
+		// Changed declaration and drop of unknown
+		changed := append([]mongodb.IndexSpec{}, specs...)
+		changed[0].Sparse = false
+
+		report, err = db.SyncIndexes(ctx, collections[0], changed, mongodb.IndexSyncOptions{DropUnknown: true})
+		require.NoErrorf(t, err, "Unexpected error change")
+		assert.Equalf(t, []string{"email_1"}, report.Recreated, "Recreated is not equal")
+		assert.Equalf(t, []string{"name_1_age_1_email_1"}, report.Dropped, "Dropped is not equal")
+
+		list, err := db.ListIndexes(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error list")
+		assert.ElementsMatchf(t, []string{"_id_", "email_1", "by_age", "createdAt_1"}, names(list), "Indexes are not equal")
+
+		err = db.DropIndex(ctx, collections[0], "by_age")
+		require.NoErrorf(t, err, "Unexpected error drop")
+	})
+}
+
+// Test concurrent send of the same document
+func testConcurrentSend(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	doc := mongodb.DocUser{Name: "Race", Age: 30, Email: "race@mail.com"}
+
+	const workers = 10
+	errs := make(chan error, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := db.SendDocumentUser(collections[0], doc)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	success := 0
+	for err := range errs {
+		if err == nil {
+			success++
+			continue
+		}
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+	}
+	assert.Equalf(t, 1, success, "Count of inserted documents is not equal")
+}
+
+// Test operations with canceled context
+func testCanceledContext(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	doc := mongodb.DocUser{Name: "Aaa", Age: 20, Email: "aaa@mail.com"}
+
+	_, err := db.SendDocumentUserCtx(ctx, collections[0], doc)
+	require.Truef(t, errors.Is(err, context.Canceled), "Error is not equal")
+
+	_, err = db.RecvDocumentUserByNameCtx(ctx, collections[0], doc.Name)
+	require.Truef(t, errors.Is(err, context.Canceled), "Error is not equal")
+
+	_, err = db.DelDocumentUserByNameCtx(ctx, collections[0], doc.Name)
+	require.Truef(t, errors.Is(err, context.Canceled), "Error is not equal")
+
+	err = db.UpdateDocumentUserByNameCtx(ctx, collections[0], doc.Name, doc)
+	require.Truef(t, errors.Is(err, context.Canceled), "Error is not equal")
+}
+
+// Test UpdateDocumentUserByNameVersioned without versioning
+func testWithoutVersioning(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, int64(0), rxDoc.Version, "Version is maintained")
+
+	_, err = db.UpdateDocumentUserByNameVersioned(context.Background(), collections[0], "Aaa", rxDoc)
+	require.Equalf(t, mongodb.ErrNotVersioned, err, "Error is not equal")
+}
+
+// Test maintenance of version by write paths
+func testVersionWritePaths(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	version := func(t *testing.T, name string) int64 {
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], name)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		return rxDoc.Version
+	}
+
+	t.Run("Send", func(t *testing.T) {
+
+		_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30, Version: 7})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), version(t, "Aaa"), "Version is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		err := db.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{Age: 31, Version: 7})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(2), version(t, "Aaa"), "Version is not equal")
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+
+		doc, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Inc("age", 1))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(3), doc.Version, "Version is not equal")
+
+		_, err = db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("version", int64(1)))
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPatch), "Error is not equal")
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+
+		_, err := db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(4), version(t, "Aaa"), "Version is not equal")
+
+		_, err = db.UpsertDocumentUserByName(ctx, collections[0], "Bbb", mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), version(t, "Bbb"), "Version is not equal")
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+
+		_, err := db.SendDocumentsUser(ctx, collections[0], []mongodb.DocUser{{Name: "Ccc", Age: 30}}, mongodb.BulkOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), version(t, "Ccc"), "Version is not equal")
+
+		_, err = db.UpdateDocumentsUserByName(ctx, collections[0], []mongodb.UserUpdate{{Name: "Ccc", Doc: mongodb.DocUser{Age: 30}}}, mongodb.BulkOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(2), version(t, "Ccc"), "Version is not equal")
+	})
+}
+
+// Test UpdateDocumentUserByNameVersioned
+func testUpdateDocumentUserByNameVersioned(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		_, err := db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "", mongodb.DocUser{Age: 31, Version: 1})
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Wrong age", func(t *testing.T) {
+
+		_, err := db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "Aaa", mongodb.DocUser{Email: "aaa@mail.com", Version: 1})
+		require.Truef(t, errors.Is(err, mongodb.ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Not exists entry", func(t *testing.T) {
+
+		_, err := db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "Zzz", mongodb.DocUser{Age: 31, Version: 1})
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Equalf(t, int64(1), rxDoc.Version, "Version is not equal")
+
+		rxDoc.Age = 31
+		doc, err := db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "Aaa", rxDoc)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 31, Email: "aaa@mail.com", Version: 2}, withoutMeta(doc), "Document is not equal")
+	})
+
+	t.Run("Stale version", func(t *testing.T) {
+
+		_, err := db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "Aaa", mongodb.DocUser{Age: 32, Version: 1})
+		require.Equalf(t, mongodb.ErrConcurrentModification, err, "Error is not equal")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 31, rxDoc.Age, "Document is changed")
+	})
+}
+
+// Test concurrent read-modify-write by versioned update
+func testConcurrentVersionedUpdates(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 20})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				doc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+				if err != nil {
+					errs <- err
+					return
+				}
+
+				doc.Age++
+				_, err = db.UpdateDocumentUserByNameVersioned(ctx, collections[0], "Aaa", doc)
+				if errors.Is(err, mongodb.ErrConcurrentModification) {
+					continue
+				}
+				errs <- err
+				return
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoErrorf(t, err, "Unexpected error")
+	}
+
+	rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, 20+n, rxDoc.Age, "Update is lost")
+	assert.Equalf(t, int64(1+n), rxDoc.Version, "Version is not equal")
+}
+
+// Manual clock of audit tests.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// Current time of clock. Returns time.
+func (c *clock) Now() time.Time {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Move clock forward.
+//
+// Params:
+//
+//	d - duration
+func (c *clock) Advance(d time.Duration) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+// Document without id and fields of audit. Returns document.
+//
+// Params:
+//
+//	doc - document
+func withoutMeta(doc mongodb.DocUser) mongodb.DocUser {
+
+	doc.ID = primitive.NilObjectID
+	doc.CreatedAt, doc.UpdatedAt = time.Time{}, time.Time{}
+	doc.CreatedBy, doc.UpdatedBy = "", ""
+
+	return doc
+}
+
+// Documents without id and fields of audit. Returns documents.
+//
+// Params:
+//
+//	docs - documents
+func withoutMetaAll(docs []mongodb.DocUser) []mongodb.DocUser {
+
+	if docs == nil {
+		return nil
+	}
+
+	result := make([]mongodb.DocUser, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, withoutMeta(doc))
+	}
+
+	return result
+}
+
+// Test maintenance of fields of audit by write paths
+func testAuditWritePaths(t *testing.T, newDB ClockFactory) {
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	c := &clock{now: start}
+
+	db := setup(t, newDB(c.Now))
+	alice := mongodb.WithActor(context.Background(), "alice")
+	bob := mongodb.WithActor(context.Background(), "bob")
+
+	recv := func(t *testing.T, collectionName, name string) mongodb.DocUser {
+
+		rxDoc, err := db.RecvDocumentUserByNameCtx(context.Background(), collectionName, name)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		return rxDoc
+	}
+
+	t.Run("Send", func(t *testing.T) {
+
+		forged := mongodb.DocUser{Name: "Aaa", Age: 30, CreatedAt: start.Add(-time.Hour), CreatedBy: "eve", UpdatedBy: "eve"}
+
+		_, err := db.SendDocumentUserCtx(alice, collections[0], forged)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc := recv(t, collections[0], "Aaa")
+		assert.Equalf(t, start, rxDoc.CreatedAt, "CreatedAt is not equal")
+		assert.Equalf(t, start, rxDoc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", rxDoc.CreatedBy, "CreatedBy is not equal")
+		assert.Equalf(t, "alice", rxDoc.UpdatedBy, "UpdatedBy is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		err := db.UpdateDocumentUserByNameCtx(bob, collections[0], "Aaa", mongodb.DocUser{Age: 31, CreatedBy: "eve"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc := recv(t, collections[0], "Aaa")
+		assert.Equalf(t, start, rxDoc.CreatedAt, "CreatedAt is changed")
+		assert.Equalf(t, start.Add(time.Minute), rxDoc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", rxDoc.CreatedBy, "CreatedBy is changed")
+		assert.Equalf(t, "bob", rxDoc.UpdatedBy, "UpdatedBy is not equal")
+	})
+
+	t.Run("Patch without actor", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		doc, err := db.PatchDocumentUserByName(context.Background(), collections[0], "Aaa", mongodb.NewPatch().Inc("age", 1))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, start, doc.CreatedAt, "CreatedAt is changed")
+		assert.Equalf(t, start.Add(2*time.Minute), doc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", doc.CreatedBy, "CreatedBy is changed")
+		assert.Emptyf(t, doc.UpdatedBy, "UpdatedBy is not empty")
+	})
+
+	t.Run("Upsert", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		_, err := db.UpsertDocumentUserByName(bob, collections[0], "Aaa", mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc := recv(t, collections[0], "Aaa")
+		assert.Equalf(t, start, rxDoc.CreatedAt, "CreatedAt is changed")
+		assert.Equalf(t, start.Add(3*time.Minute), rxDoc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", rxDoc.CreatedBy, "CreatedBy is changed")
+		assert.Equalf(t, "bob", rxDoc.UpdatedBy, "UpdatedBy is not equal")
+
+		_, err = db.UpsertDocumentUserByName(bob, collections[0], "Bbb", mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc = recv(t, collections[0], "Bbb")
+		assert.Equalf(t, start.Add(3*time.Minute), rxDoc.CreatedAt, "CreatedAt is not equal")
+		assert.Equalf(t, "bob", rxDoc.CreatedBy, "CreatedBy is not equal")
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		_, err := db.SendDocumentsUser(alice, collections[0], []mongodb.DocUser{{Name: "Ccc", Age: 30}}, mongodb.BulkOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		c.Advance(time.Minute)
+
+		_, err = db.UpdateDocumentsUserByName(bob, collections[0], []mongodb.UserUpdate{{Name: "Ccc", Doc: mongodb.DocUser{Age: 31}}}, mongodb.BulkOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc := recv(t, collections[0], "Ccc")
+		assert.Equalf(t, start.Add(4*time.Minute), rxDoc.CreatedAt, "CreatedAt is not equal")
+		assert.Equalf(t, start.Add(5*time.Minute), rxDoc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", rxDoc.CreatedBy, "CreatedBy is not equal")
+		assert.Equalf(t, "bob", rxDoc.UpdatedBy, "UpdatedBy is not equal")
+	})
+
+	t.Run("Move", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		err := db.MoveDocumentUserTxCtx(bob, collections[0], collections[1], mongodb.DocUser{Name: "Bbb"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc := recv(t, collections[1], "Bbb")
+		assert.Equalf(t, start.Add(3*time.Minute), rxDoc.CreatedAt, "CreatedAt is changed")
+		assert.Equalf(t, start.Add(6*time.Minute), rxDoc.UpdatedAt, "UpdatedAt is not equal")
+	})
+}
+
+// Test rejection of patch of fields of audit
+func testAuditManagedFields(t *testing.T, newDB ClockFactory) {
+
+	c := &clock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	for _, field := range []string{"createdAt", "updatedAt", "createdBy", "updatedBy"} {
+		t.Run(field, func(t *testing.T) {
+
+			_, err := db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Unset(field))
+			require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPatch), "Error is not equal")
+		})
+	}
+}
+
+// Test methods of soft delete without soft delete
+func testWithoutSoftDelete(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	n, err := db.DelDocumentUserByName(collections[0], "Aaa")
+	require.NoErrorf(t, err, "Unexpected error delete")
+	require.Equalf(t, int64(1), n, "Count is not equal")
+
+	_, err = db.FindDeletedDocumentsUser(ctx, collections[0])
+	require.Equalf(t, mongodb.ErrNotSoftDelete, err, "Error is not equal")
+
+	err = db.RestoreDocumentUserByName(ctx, collections[0], "Aaa")
+	require.Equalf(t, mongodb.ErrNotSoftDelete, err, "Error is not equal")
+
+	_, err = db.PurgeDeletedDocumentsUser(ctx, collections[0], 0)
+	require.Equalf(t, mongodb.ErrNotSoftDelete, err, "Error is not equal")
+
+	_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Document is not removed")
+}
+
+// Test exclusion of softly deleted documents by read and write paths
+func testSoftDeletePaths(t *testing.T, newDB ClockFactory) {
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	c := &clock{now: start}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{{Name: "Aaa", Age: 30}, {Name: "Bbb", Age: 31}, {Name: "Ccc", Age: 32, DeletedAt: start}} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Send of deleted document", func(t *testing.T) {
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Ccc")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Truef(t, rxDoc.DeletedAt.IsZero(), "Document is deleted")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+
+		c.Advance(time.Minute)
+
+		n, err := db.DelDocumentUserByNameCtx(mongodb.WithActor(ctx, "alice"), collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		n, err = db.DelDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(0), n, "Count is not equal")
+
+		docs, err := db.FindDeletedDocumentsUser(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Lenf(t, docs, 1, "Count is not equal")
+		assert.Equalf(t, "Aaa", docs[0].Name, "Name is not equal")
+		assert.Equalf(t, start.Add(time.Minute), docs[0].DeletedAt, "DeletedAt is not equal")
+		assert.Equalf(t, start.Add(time.Minute), docs[0].UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "alice", docs[0].UpdatedBy, "UpdatedBy is not equal")
+	})
+
+	t.Run("Reads", func(t *testing.T) {
+
+		_, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.Truef(t, errors.Is(err, mongo.ErrNoDocuments), "Error is not equal")
+
+		docs, _, err := db.FindDocumentsUser(ctx, collections[0], mongodb.NewUserQuery().SortBy("name", false).Project("name"))
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []mongodb.DocUser{{Name: "Bbb"}, {Name: "Ccc"}}, withoutMetaAll(docs), "Documents is not equal")
+	})
+
+	t.Run("Writes", func(t *testing.T) {
+
+		err := db.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{Age: 40})
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+
+		_, err = db.PatchDocumentUserByName(ctx, collections[0], "Aaa", mongodb.NewPatch().Set("age", 40))
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+
+		_, err = db.PatchDocumentUserByName(ctx, collections[0], "Bbb", mongodb.NewPatch().Unset("deletedAt"))
+		require.Truef(t, errors.Is(err, mongodb.ErrNotCorrectPatch), "Error is not equal")
+
+		err = db.MoveDocumentUserTx(collections[0], collections[1], mongodb.DocUser{Name: "Aaa"})
+		require.Errorf(t, err, "Error is not exists")
+
+		// Deleted document does not hold values of unique index
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+
+		err = db.RestoreDocumentUserByName(ctx, collections[0], "Aaa")
+		require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+
+		result, err := db.UpsertDocumentUserByName(ctx, collections[0], "Aaa", mongodb.DocUser{Age: 40})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Falsef(t, result.Inserted, "Document is inserted")
+
+		n, err := db.DelDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+	})
+
+	t.Run("Bulk", func(t *testing.T) {
+
+		result, err := db.DelDocumentsUserByName(ctx, collections[0], []string{"Aaa", "Bbb"}, mongodb.BulkOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), result.Deleted, "Count is not equal")
+
+		docs, err := db.FindDeletedDocumentsUser(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Lenf(t, docs, 3, "Count is not equal")
+
+		result, err = db.UpdateDocumentsUserByName(ctx, collections[0], []mongodb.UserUpdate{{Name: "Bbb", Doc: mongodb.DocUser{Age: 40}}}, mongodb.BulkOptions{})
+		require.Truef(t, errors.Is(err, mongodb.ErrBulkWrite), "Error is not equal")
+		assert.Equalf(t, int64(0), result.Matched, "Deleted document is matched")
+		assert.Equalf(t, mongodb.ErrUpdateDocument, result.Items[0].Err, "Error is not equal")
+	})
+}
+
+// Test RestoreDocumentUserByName
+func testRestoreDocumentUserByName(t *testing.T, newDB ClockFactory) {
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	c := &clock{now: start}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		err := db.RestoreDocumentUserByName(ctx, collections[0], "")
+		require.Equalf(t, mongodb.ErrEmptyValueName, err, "Error is not equal")
+	})
+
+	t.Run("Not deleted entry", func(t *testing.T) {
+
+		err := db.RestoreDocumentUserByName(ctx, collections[0], "Aaa")
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		_, err := db.DelDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error delete")
+
+		c.Advance(time.Minute)
+
+		err = db.RestoreDocumentUserByName(mongodb.WithActor(ctx, "bob"), collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 30, Email: "aaa@mail.com"}, withoutMeta(rxDoc), "Document is not equal")
+		assert.Equalf(t, start.Add(time.Minute), rxDoc.UpdatedAt, "UpdatedAt is not equal")
+		assert.Equalf(t, "bob", rxDoc.UpdatedBy, "UpdatedBy is not equal")
+
+		docs, err := db.FindDeletedDocumentsUser(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Emptyf(t, docs, "Documents is not empty")
+	})
+}
+
+// Test PurgeDeletedDocumentsUser
+func testPurgeDeletedDocumentsUser(t *testing.T, newDB ClockFactory) {
+
+	c := &clock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{{Name: "Aaa", Age: 30}, {Name: "Bbb", Age: 31}, {Name: "Ccc", Age: 32}} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Negative retention", func(t *testing.T) {
+
+		_, err := db.PurgeDeletedDocumentsUser(ctx, collections[0], -time.Hour)
+		require.Equalf(t, mongodb.ErrNegativeRetention, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		_, err := db.DelDocumentUserByName(collections[0], "Aaa")
+		require.NoErrorf(t, err, "Unexpected error delete")
+
+		c.Advance(time.Hour)
+
+		_, err = db.DelDocumentUserByName(collections[0], "Bbb")
+		require.NoErrorf(t, err, "Unexpected error delete")
+
+		c.Advance(time.Minute)
+
+		n, err := db.PurgeDeletedDocumentsUser(ctx, collections[0], 30*time.Minute)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		docs, err := db.FindDeletedDocumentsUser(ctx, collections[0])
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Lenf(t, docs, 1, "Count is not equal")
+		assert.Equalf(t, "Bbb", docs[0].Name, "Name is not equal")
+
+		n, err = db.PurgeDeletedDocumentsUser(ctx, collections[0], 0)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, int64(1), n, "Count is not equal")
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+		require.NoErrorf(t, err, "Document is not removed")
+
+		_, err = db.RecvDocumentUserByName(collections[0], "Ccc")
+		require.NoErrorf(t, err, "Document is removed")
+	})
+}
+
+// Test delete of many documents softly
+func testSoftDeleteMany(t *testing.T, newDB ClockFactory) {
+
+	c := &clock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{{Name: "Aaa", Age: 30}, {Name: "Aaa", Age: 31}, {Name: "Bbb", Age: 40}} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	n, err := db.DelAllDocumentsUserByName(ctx, collections[0], "Aaa", mongodb.DeleteOptions{})
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, int64(2), n, "Count is not equal")
+
+	n, err = db.DelDocumentsUserByQuery(ctx, collections[0], mongodb.NewUserQuery().AgeMin(1), mongodb.DeleteOptions{DryRun: true})
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, int64(1), n, "Deleted documents are counted")
+
+	docs, err := db.FindDeletedDocumentsUser(ctx, collections[0])
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Lenf(t, docs, 2, "Count is not equal")
+}
+
+// Test user stats skip softly deleted documents
+func testSoftDeleteStats(t *testing.T, newDB ClockFactory) {
+
+	c := &clock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{{Name: "Aaa", Age: 30, Email: "aaa@mail.com"}, {Name: "Bbb", Age: 40, Email: "bbb@mail.com"}} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	_, err := db.DelDocumentUserByName(collections[0], "Aaa")
+	require.NoErrorf(t, err, "Unexpected error")
+
+	buckets, err := db.UserStatsByAgeBucket(ctx, collections[0], []int{0, 100})
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, []mongodb.AgeBucket{{Min: 0, Max: 100, Count: 1}}, buckets, "Buckets is not equal")
+
+	domains, err := db.UserStatsByEmailDomain(ctx, collections[0])
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, []mongodb.DomainCount{{Domain: "mail.com", Count: 1}}, domains, "Counts is not equal")
+
+	// Pipeline is executed on stored documents
+	docs, err := mongodb.AggregateAs[mongodb.DocUser](ctx, db, collections[0], nil)
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Lenf(t, docs, 2, "Count is not equal")
+}
+
+// Test iteration skips softly deleted documents
+func testSoftDeleteIter(t *testing.T, newDB ClockFactory) {
+
+	c := &clock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+
+	db := setup(t, newDB(c.Now))
+	ctx := context.Background()
+
+	for _, doc := range []mongodb.DocUser{{Name: "Aaa", Age: 30}, {Name: "Bbb", Age: 40}} {
+		_, err := db.SendDocumentUser(collections[0], doc)
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	_, err := db.DelDocumentUserByName(collections[0], "Aaa")
+	require.NoErrorf(t, err, "Unexpected error")
+
+	names, err := iterNames(db.IterDocumentsUser(ctx, collections[0], nil, mongodb.IterOptions{}))
+	require.NoErrorf(t, err, "Unexpected error")
+	assert.Equalf(t, []string{"Bbb"}, names, "Names is not equal")
+}
+
+// Create collections of suite with empty outbox. Returns implementation.
+func setupOutbox(t *testing.T, newDB Factory) mongodb.MongoDBI {
+
+	db := setup(t, newDB)
+
+	require.NoErrorf(t, db.DropCollection(OutboxCollection), "Unexpected error DropCollection")
+	t.Cleanup(func() {
+		assert.NoErrorf(t, db.DropCollection(OutboxCollection), "Unexpected error DropCollection")
+	})
+
+	return db
+}
+
+// Test methods of outbox without outbox
+func testWithoutOutbox(t *testing.T, newDB Factory) {
+
+	db := setup(t, newDB)
+	ctx := context.Background()
+
+	_, err := db.ClaimOutboxEvents(ctx, 10, time.Minute)
+	require.Equalf(t, mongodb.ErrNotOutbox, err, "Error is not equal")
+
+	err = db.AckOutboxEvent(ctx, primitive.NewObjectID())
+	require.Equalf(t, mongodb.ErrNotOutbox, err, "Error is not equal")
+
+	err = db.FailOutboxEvent(ctx, primitive.NewObjectID(), errors.New("fault"), 0)
+	require.Equalf(t, mongodb.ErrNotOutbox, err, "Error is not equal")
+
+	_, err = db.PurgeOutboxEvents(ctx, 0)
+	require.Truef(t, errors.Is(err, mongodb.ErrNotOutbox), "Error is not equal")
+}
+
+// Test events of outbox by write paths
+func testOutboxWritePaths(t *testing.T, newDB Factory) {
+
+	db := setupOutbox(t, newDB)
+	ctx := context.Background()
+
+	id, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	// Failed writes have no events
+	_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Aaa", Age: 30})
+	require.Equalf(t, mongodb.ErrDocumentExists, err, "Error is not equal")
+
+	errAbort := errors.New("abort")
+	err = db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+		if _, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Bbb", Age: 30}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	require.Truef(t, errors.Is(err, errAbort), "Error is not equal")
+
+	err = db.MoveDocumentUserTx(collections[0], collections[1], mongodb.DocUser{Name: "Zzz"})
+	require.Errorf(t, err, "Error is not exists")
+
+	// Other writes have no events
+	err = db.UpdateDocumentUserByName(collections[0], "Aaa", mongodb.DocUser{Age: 31})
+	require.NoErrorf(t, err, "Unexpected error update")
+
+	err = db.MoveDocumentUserTx(collections[0], collections[1], mongodb.DocUser{Name: "Aaa"})
+	require.NoErrorf(t, err, "Unexpected error move")
+
+	events, err := db.ClaimOutboxEvents(ctx, 10, time.Minute)
+	require.NoErrorf(t, err, "Unexpected error claim")
+	require.Equalf(t, 2, len(events), "Count of events is not equal")
+
+	created := events[0]
+	assert.Equalf(t, mongodb.EventUserCreated, created.Type, "Type is not equal")
+	assert.Equalf(t, collections[0], created.Collection, "Collection is not equal")
+	assert.Equalf(t, "", created.Source, "Source is not equal")
+	assert.Equalf(t, id, created.DocumentID, "Id is not equal")
+	assert.Equalf(t, 1, created.Attempts, "Attempts are not equal")
+	assert.Falsef(t, created.CreatedAt.IsZero(), "Time of creation is zero")
+
+	var doc mongodb.DocUser
+	require.NoErrorf(t, created.Decode(&doc), "Unexpected error decode")
+	assert.Equalf(t, id, doc.ID, "Id is not equal")
+	assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 30}, withoutMeta(doc), "Document is not equal")
+
+	moved := events[1]
+	assert.Equalf(t, mongodb.EventUserMoved, moved.Type, "Type is not equal")
+	assert.Equalf(t, collections[1], moved.Collection, "Collection is not equal")
+	assert.Equalf(t, collections[0], moved.Source, "Source is not equal")
+	assert.Equalf(t, id, moved.DocumentID, "Id is not equal")
+
+	require.NoErrorf(t, moved.Decode(&doc), "Unexpected error decode")
+	assert.Equalf(t, mongodb.DocUser{Name: "Aaa", Age: 31}, withoutMeta(doc), "Document is not equal")
+
+	// Send in transaction
+	err = db.WithTransaction(ctx, func(tx mongodb.Tx) error {
+		_, err := tx.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ccc", Age: 30})
+		return err
+	})
+	require.NoErrorf(t, err, "Unexpected error")
+
+	events, err = db.ClaimOutboxEvents(ctx, 10, time.Minute)
+	require.NoErrorf(t, err, "Unexpected error claim")
+	require.Equalf(t, 1, len(events), "Count of events is not equal")
+	assert.Equalf(t, mongodb.EventUserCreated, events[0].Type, "Type is not equal")
+
+	// Other paths of insert, replace by upsert has no event
+	upserted, err := db.UpsertDocumentUserByName(ctx, collections[0], "Ddd", mongodb.DocUser{Age: 30})
+	require.NoErrorf(t, err, "Unexpected error upsert")
+	require.Truef(t, upserted.Inserted, "Document is not inserted")
+
+	_, err = db.UpsertDocumentUserByName(ctx, collections[0], "Ddd", mongodb.DocUser{Age: 31})
+	require.NoErrorf(t, err, "Unexpected error upsert")
+
+	bulk, err := db.SendDocumentsUser(ctx, collections[0], []mongodb.DocUser{
+		{Name: "Eee", Age: 30},
+		{Name: "Ccc", Age: 30},
+	}, mongodb.BulkOptions{})
+	require.Truef(t, errors.Is(err, mongodb.ErrBulkWrite), "Error is not equal")
+	require.Equalf(t, mongodb.ErrDocumentExists, bulk.Items[1].Err, "Error is not equal")
+
+	imported, err := mongodb.Import(ctx, db, collections[0], strings.NewReader(`{"name":"Fff","age":30}`+"\n"),
+		mongodb.ImportOptions{Format: mongodb.FormatJSONL})
+	require.NoErrorf(t, err, "Unexpected error import")
+	require.Equalf(t, int64(1), imported.Inserted, "Count of inserted documents is not equal")
+
+	events, err = db.ClaimOutboxEvents(ctx, 10, time.Minute)
+	require.NoErrorf(t, err, "Unexpected error claim")
+
+	got := map[string]primitive.ObjectID{}
+	for _, ev := range events {
+		assert.Equalf(t, mongodb.EventUserCreated, ev.Type, "Type is not equal")
+		require.NoErrorf(t, ev.Decode(&doc), "Unexpected error decode")
+		assert.Equalf(t, ev.DocumentID, doc.ID, "Id is not equal")
+		got[doc.Name] = doc.ID
+	}
+	require.Equalf(t, 3, len(got), "Count of events is not equal")
+	assert.Equalf(t, upserted.ID, got["Ddd"], "Id is not equal")
+	assert.Equalf(t, bulk.Items[0].ID, got["Eee"], "Id is not equal")
+	assert.Falsef(t, got["Fff"].IsZero(), "Id is zero")
+}
+
+// Test claim, acknowledge, fail and purge of events of outbox
+func testOutboxDelivery(t *testing.T, newDB Factory) {
+
+	db := setupOutbox(t, newDB)
+	ctx := context.Background()
+
+	for _, name := range []string{"Aaa", "Bbb", "Ccc"} {
+		_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: name, Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+	}
+
+	t.Run("Wrong values", func(t *testing.T) {
+
+		_, err := db.ClaimOutboxEvents(ctx, 0, time.Minute)
+		require.Equalf(t, mongodb.ErrValueRelayOptions, err, "Error is not equal")
+
+		_, err = db.ClaimOutboxEvents(ctx, 10, 0)
+		require.Equalf(t, mongodb.ErrValueRelayOptions, err, "Error is not equal")
+
+		err = db.AckOutboxEvent(ctx, primitive.NilObjectID)
+		require.Equalf(t, mongodb.ErrEmptyID, err, "Error is not equal")
+
+		err = db.FailOutboxEvent(ctx, primitive.NewObjectID(), nil, -time.Second)
+		require.Equalf(t, mongodb.ErrValueRelayOptions, err, "Error is not equal")
+
+		_, err = db.PurgeOutboxEvents(ctx, -time.Second)
+		require.Equalf(t, mongodb.ErrNegativeRetention, err, "Error is not equal")
+
+		err = db.AckOutboxEvent(ctx, primitive.NewObjectID())
+		require.Equalf(t, mongodb.ErrUpdateDocument, err, "Error is not equal")
+	})
+
+	t.Run("Claim in order with limit", func(t *testing.T) {
+
+		events, err := db.ClaimOutboxEvents(ctx, 2, time.Minute)
+		require.NoErrorf(t, err, "Unexpected error claim")
+		require.Equalf(t, 2, len(events), "Count of events is not equal")
+
+		var doc mongodb.DocUser
+		require.NoErrorf(t, events[0].Decode(&doc), "Unexpected error decode")
+		assert.Equalf(t, "Aaa", doc.Name, "Name is not equal")
+		require.NoErrorf(t, events[1].Decode(&doc), "Unexpected error decode")
+		assert.Equalf(t, "Bbb", doc.Name, "Name is not equal")
+
+		// Claimed events are leased
+		rest, err := db.ClaimOutboxEvents(ctx, 10, time.Minute)
+		require.NoErrorf(t, err, "Unexpected error claim")
+		require.Equalf(t, 1, len(rest), "Count of events is not equal")
+		require.NoErrorf(t, rest[0].Decode(&doc), "Unexpected error decode")
+		assert.Equalf(t, "Ccc", doc.Name, "Name is not equal")
+
+		// Acknowledge
+		require.NoErrorf(t, db.AckOutboxEvent(ctx, events[0].ID), "Unexpected error ack")
+		require.Equalf(t, mongodb.ErrUpdateDocument, db.AckOutboxEvent(ctx, events[0].ID), "Error is not equal")
+
+		// Fail without delay makes event claimable
+		require.NoErrorf(t, db.FailOutboxEvent(ctx, events[1].ID, errors.New("fault"), 0), "Unexpected error fail")
+
+		again, err := db.ClaimOutboxEvents(ctx, 10, time.Minute)
+		require.NoErrorf(t, err, "Unexpected error claim")
+		require.Equalf(t, 1, len(again), "Count of events is not equal")
+		assert.Equalf(t, events[1].ID, again[0].ID, "Id is not equal")
+		assert.Equalf(t, 2, again[0].Attempts, "Attempts are not equal")
+		assert.Equalf(t, "fault", again[0].LastError, "Error is not equal")
+
+		// Fail with delay keeps event leased
+		require.NoErrorf(t, db.FailOutboxEvent(ctx, rest[0].ID, errors.New("fault"), time.Hour), "Unexpected error fail")
+		require.NoErrorf(t, db.AckOutboxEvent(ctx, again[0].ID), "Unexpected error ack")
+
+		none, err := db.ClaimOutboxEvents(ctx, 10, time.Minute)
+		require.NoErrorf(t, err, "Unexpected error claim")
+		assert.Equalf(t, 0, len(none), "Count of events is not equal")
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+
+		n, err := db.PurgeOutboxEvents(ctx, time.Hour)
+		require.NoErrorf(t, err, "Unexpected error purge")
+		assert.Equalf(t, int64(0), n, "Count is not equal")
+
+		n, err = db.PurgeOutboxEvents(ctx, 0)
+		require.NoErrorf(t, err, "Unexpected error purge")
+		assert.Equalf(t, int64(2), n, "Count is not equal")
+	})
+}
+
+// Publisher, which records events.
+type recordPublisher struct {
+	mu     sync.Mutex
+	events []mongodb.OutboxEvent
+	fail   bool
+}
+
+// Publish event. Returns error.
+func (p *recordPublisher) Publish(ctx context.Context, ev mongodb.OutboxEvent) error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail {
+		return errors.New("publisher is not available")
+	}
+	p.events = append(p.events, ev)
+
+	return nil
+}
+
+// Count of published events. Returns count.
+func (p *recordPublisher) count() int {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.events)
+}
+
+// Test Relay
+func testRelay(t *testing.T, newDB Factory) {
+
+	db := setupOutbox(t, newDB)
+	ctx := context.Background()
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		_, err := mongodb.NewRelay(nil, &recordPublisher{}, mongodb.RelayOptions{})
+		require.Equalf(t, mongodb.ErrNilPtrDB, err, "Error is not equal")
+
+		_, err = mongodb.NewRelay(db, nil, mongodb.RelayOptions{})
+		require.Equalf(t, mongodb.ErrNilPublisher, err, "Error is not equal")
+
+		_, err = mongodb.NewRelay(db, &recordPublisher{}, mongodb.RelayOptions{Lease: -time.Second})
+		require.Equalf(t, mongodb.ErrValueRelayOptions, err, "Error is not equal")
+	})
+
+	t.Run("Once with fault of publisher", func(t *testing.T) {
+
+		pub := &recordPublisher{fail: true}
+		relay, err := mongodb.NewRelay(db, pub, mongodb.RelayOptions{RetryAfter: time.Nanosecond, Retention: time.Hour})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		for _, name := range []string{"Aaa", "Bbb"} {
+			_, err := db.SendDocumentUser(collections[0], mongodb.DocUser{Name: name, Age: 30})
+			require.NoErrorf(t, err, "Unexpected error send")
+		}
+
+		n, err := relay.RelayOnce(ctx)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 0, n, "Count is not equal")
+
+		pub.mu.Lock()
+		pub.fail = false
+		pub.mu.Unlock()
+
+		time.Sleep(2 * time.Millisecond)
+
+		n, err = relay.RelayOnce(ctx)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 2, n, "Count is not equal")
+		require.Equalf(t, 2, pub.count(), "Count of published events is not equal")
+		assert.Equalf(t, 2, pub.events[0].Attempts, "Attempts are not equal")
+
+		n, err = relay.RelayOnce(ctx)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, 0, n, "Count is not equal")
+	})
+
+	t.Run("Run", func(t *testing.T) {
+
+		pub := &recordPublisher{}
+		relay, err := mongodb.NewRelay(db, pub, mongodb.RelayOptions{Interval: 10 * time.Millisecond})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rctx, cancel := context.WithCancel(ctx)
+		done := make(chan error, 1)
+		go func() { done <- relay.Run(rctx) }()
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ccc", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		require.Eventuallyf(t, func() bool { return pub.count() == 1 }, 5*time.Second, 10*time.Millisecond, "Event is not published")
+
+		cancel()
+		select {
+		case err := <-done:
+			require.NoErrorf(t, err, "Unexpected error of relay")
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "Relay is not stopped")
+		}
+	})
+
+	t.Run("Run with watch", func(t *testing.T) {
+
+		pub := &recordPublisher{}
+		relay, err := mongodb.NewRelay(db, pub, mongodb.RelayOptions{Interval: time.Hour, WatchCollection: OutboxCollection})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		rctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() { _ = relay.Run(rctx) }()
+
+		// First pass of relay is done on start
+		time.Sleep(50 * time.Millisecond)
+
+		_, err = db.SendDocumentUser(collections[0], mongodb.DocUser{Name: "Ddd", Age: 30})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		require.Eventuallyf(t, func() bool { return pub.count() == 1 }, 5*time.Second, 10*time.Millisecond, "Event is not published")
+	})
+}
@@ -0,0 +1,62 @@
+package mongodb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory of adapter with options.
+func newFactory(opts ...mongodb.Option) conformance.Factory {
+
+	return func(t *testing.T) mongodb.MongoDBI {
+
+		db, err := mongodb.New("mongodb://localhost:27017/myDatabase", opts...)
+		require.NoErrorf(t, err, "Unexpected error New")
+
+		t.Cleanup(func() {
+			err := db.Close()
+			assert.NoErrorf(t, err, "Unexpected error Close")
+		})
+
+		return db
+	}
+}
+
+// Test conformance of MongoDB adapter.
+func TestConformance(t *testing.T) {
+
+	conformance.Run(t, newFactory())
+}
+
+// Test conformance of MongoDB adapter with versioning.
+func TestConformanceVersioning(t *testing.T) {
+
+	conformance.RunVersioning(t, newFactory(mongodb.WithVersioning()))
+}
+
+// Test conformance of MongoDB adapter with clock of audit.
+func TestConformanceAudit(t *testing.T) {
+
+	conformance.RunAudit(t, func(clock func() time.Time) conformance.Factory {
+		return newFactory(mongodb.WithClock(clock))
+	})
+}
+
+// Test conformance of MongoDB adapter with soft delete.
+func TestConformanceSoftDelete(t *testing.T) {
+
+	conformance.RunSoftDelete(t, func(clock func() time.Time) conformance.Factory {
+		return newFactory(mongodb.WithSoftDelete(), mongodb.WithClock(clock))
+	})
+}
+
+// Test conformance of MongoDB adapter with outbox.
+func TestConformanceOutbox(t *testing.T) {
+
+	conformance.RunOutbox(t, newFactory(mongodb.WithOutbox(conformance.OutboxCollection)))
+}
@@ -0,0 +1,139 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Options of delete of many documents.
+type DeleteOptions struct {
+	// Only count documents, which would be deleted
+	DryRun bool
+	// Allow empty filter, which deletes all documents of collection
+	AllowEmptyFilter bool
+}
+
+// Delete all documents by filter. With DeletedField documents are deleted softly.
+// Empty filter is refused without opts.AllowEmptyFilter.
+// Returns count deleted documents (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	opts - options
+func (r *Repository[T]) DeleteMany(ctx context.Context, collectionName string, filter interface{}, opts DeleteOptions) (int64, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return 0, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	empty, err := isEmptyFilter(filter)
+	if err != nil {
+		return 0, err
+	}
+	if empty && !opts.AllowEmptyFilter {
+		return 0, ErrEmptyFilter
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if opts.DryRun {
+		n, err := collection.CountDocuments(ctx, r.liveFilter(filter))
+		if err != nil {
+			return 0, fmt.Errorf("Function CountDocuments, returned error: <%w>", err)
+		}
+
+		return n, nil
+	}
+
+	if r.cfg.DeletedField != "" {
+		result, err := collection.UpdateMany(ctx, r.liveFilter(filter), r.deleteDoc(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("Function UpdateMany, returned error: <%w>", err)
+		}
+
+		return result.ModifiedCount, nil
+	}
+
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// Delete all documents by key. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+//	opts - options
+func (r *Repository[T]) DeleteManyByKey(ctx context.Context, collectionName string, key interface{}, opts DeleteOptions) (int64, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return 0, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.DeleteMany(ctx, collectionName, filter, opts)
+}
+
+// Filter of delete by specification of query. Sorting, projection and pagination are refused.
+// Returns filter and error.
+func (s UserQuerySpec) deleteFilter() (bson.M, error) {
+
+	if err := s.CheckDelete(); err != nil {
+		return nil, err
+	}
+
+	return s.filter(), nil
+}
+
+// Check, that specification of query has only conditions. Returns error.
+func (s UserQuerySpec) CheckDelete() error {
+
+	if len(s.Sort) > 0 || len(s.Fields) > 0 || s.Skip > 0 || s.Limit > 0 || s.After != nil {
+		return ErrNotCorrectDeleteQuery
+	}
+
+	return nil
+}
+
+// Check, that specification of query has conditions of documents. Returns flag.
+func (s UserQuerySpec) HasConditions() bool {
+
+	return s.AgeMin != nil || s.AgeMax != nil || s.EmailDomain != "" || s.NamePrefix != "" || s.Name != "" || s.Email != ""
+}
+
+// Check, that filter has no conditions. Returns flag and error.
+//
+// Params:
+//
+//	filter - filter
+func isEmptyFilter(filter interface{}) (bool, error) {
+
+	d, err := marshalDoc(filter)
+	if err != nil {
+		return false, err
+	}
+
+	return len(d) == 0, nil
+}
@@ -0,0 +1,72 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Test filter of delete by query.
+func TestDeleteFilter(t *testing.T) {
+
+	t.Run("Pagination", func(t *testing.T) {
+
+		for _, q := range []*UserQuery{
+			NewUserQuery().AgeMin(1).SortBy("name", false),
+			NewUserQuery().AgeMin(1).Project("name"),
+			NewUserQuery().AgeMin(1).Skip(1),
+			NewUserQuery().AgeMin(1).Limit(1),
+		} {
+			spec, err := q.Spec()
+			require.NoErrorf(t, err, "Unexpected error")
+
+			_, err = spec.deleteFilter()
+			require.Equalf(t, ErrNotCorrectDeleteQuery, err, "Error is not equal")
+		}
+	})
+
+	t.Run("Conditions", func(t *testing.T) {
+
+		spec, err := NewUserQuery().Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Falsef(t, spec.HasConditions(), "Query has conditions")
+
+		spec, err = NewUserQuery().Email("a@mail.com").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Truef(t, spec.HasConditions(), "Query has not conditions")
+
+		filter, err := spec.deleteFilter()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.M{"email": "a@mail.com"}, filter, "Filter is not equal")
+	})
+}
+
+// Test check of empty filter.
+func TestIsEmptyFilter(t *testing.T) {
+
+	cases := []struct {
+		name   string
+		filter interface{}
+		want   bool
+	}{
+		{"Empty map", bson.M{}, true},
+		{"Empty document", bson.D{}, true},
+		{"Empty struct", struct{}{}, true},
+		{"Map", bson.M{"name": "Aaa"}, false},
+		{"Document", bson.D{{Key: "age", Value: bson.M{"$gt": 1}}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+
+			empty, err := isEmptyFilter(c.filter)
+			require.NoErrorf(t, err, "Unexpected error")
+			assert.Equalf(t, c.want, empty, "Flag is not equal")
+		})
+	}
+
+	_, err := isEmptyFilter("name")
+	require.Errorf(t, err, "Error is not exists")
+}
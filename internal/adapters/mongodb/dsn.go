@@ -0,0 +1,106 @@
+package mongodb
+
+import (
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+// Characters, which are not allowed in the name of DB.
+const invalidNameDBChars = "/\\. \"$"
+
+// Maximum length of the name of DB.
+const maxLenNameDB = 63
+
+// Parse DSN and extract the name of DB. Returns name of DB and error.
+//
+// Params:
+//
+//	dsn - connection string
+//	nameDB - name of DB, which overrides the name from DSN
+func parseDSN(dsn, nameDB string) (string, error) {
+
+	// Check
+	if dsn == "" {
+		return "", ErrEmptyValueDSN
+	}
+
+	// Parse. Hosts of mongodb+srv are not resolved: lookups of SRV and TXT records are done by driver on connect
+	uri, srv := strings.CutPrefix(dsn, connstring.SchemeMongoDBSRV+"://")
+	if srv {
+		var err error
+		if uri, err = withoutSRVOptions(uri); err != nil {
+			return "", err
+		}
+		uri = connstring.SchemeMongoDB + "://" + uri
+	}
+
+	cs, err := connstring.Parse(uri)
+	if err != nil {
+		return "", &DSNError{Detail: "failed to parse", Err: err}
+	}
+
+	if cs.Scheme != connstring.SchemeMongoDB {
+		return "", &DSNError{Detail: "unsupported scheme " + cs.Scheme}
+	}
+	if len(cs.RawHosts) == 0 {
+		return "", &DSNError{Detail: "missing hosts"}
+	}
+	if srv && len(cs.RawHosts) != 1 {
+		return "", &DSNError{Detail: "scheme mongodb+srv requires exactly one host"}
+	}
+	if srv && strings.Contains(cs.RawHosts[0], ":") {
+		return "", &DSNError{Detail: "scheme mongodb+srv does not allow port"}
+	}
+
+	// Name of DB
+	if nameDB == "" {
+		nameDB = cs.Database
+	}
+	if nameDB == "" {
+		return "", &DSNError{Detail: "missing database name"}
+	}
+	if len(nameDB) > maxLenNameDB || strings.ContainsAny(nameDB, invalidNameDBChars) {
+		return "", &DSNError{Detail: "not correct database name " + nameDB}
+	}
+
+	return nameDB, nil
+}
+
+// Remove options, which are allowed for mongodb+srv only, from DSN without scheme. Returns DSN and error.
+//
+// Params:
+//
+//	uri - DSN without scheme
+func withoutSRVOptions(uri string) (string, error) {
+
+	base, query, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri, nil
+	}
+
+	kept := []string{}
+	for _, opt := range strings.FieldsFunc(query, func(r rune) bool { return r == '&' || r == ';' }) {
+
+		key, value, _ := strings.Cut(opt, "=")
+		switch strings.ToLower(key) {
+		case "srvservicename":
+			if value == "" {
+				return "", &DSNError{Detail: "empty option " + key}
+			}
+		case "srvmaxhosts":
+			if n, err := strconv.Atoi(value); err != nil || n < 0 {
+				return "", &DSNError{Detail: "not correct option " + key, Err: err}
+			}
+		default:
+			kept = append(kept, opt)
+		}
+	}
+
+	if len(kept) == 0 {
+		return base, nil
+	}
+
+	return base + "?" + strings.Join(kept, "&"), nil
+}
@@ -7,6 +7,10 @@ var (
 	ErrEmptyValueDSN = errors.New("Empty value DSN")
 	// Empty value name
 	ErrEmptyValueName = errors.New("Empty value name")
+	// Empty id of document
+	ErrEmptyID = errors.New("Empty id")
+	// Empty email
+	ErrEmptyEmail = errors.New("Empty email")
 	// Empty value name DB
 	ErrEmptyValueNameDB = errors.New("Empty value name DB")
 	// Nil pointer collections
@@ -29,4 +33,128 @@ var (
 	ErrValueAge = errors.New("Error value age")
 	// Error update document
 	ErrUpdateDocument = errors.New("Error update document")
+	// Error value timeout
+	ErrValueTimeout = errors.New("Error value timeout")
+	// Error value pool size
+	ErrValuePoolSize = errors.New("Error value pool size")
+	// Implementation of MongoDBI is not connection, returned by New, e.g. Repository on memstore
+	ErrNotSupportedDB = errors.New("Not supported DB")
+	// Type is not struct
+	ErrNotStructType = errors.New("Type is not struct")
+	// Unknown field
+	ErrUnknownField = errors.New("Unknown field")
+	// Empty key field
+	ErrEmptyKeyField = errors.New("Empty key field")
+	// Error value pagination
+	ErrValuePagination = errors.New("Error value pagination")
+	// Not correct page token
+	ErrNotCorrectPageToken = errors.New("Not correct page token")
+	// Empty indexes
+	ErrEmptyIndexes = errors.New("Empty indexes")
+	// Empty keys of index
+	ErrEmptyIndexKeys = errors.New("Empty keys of index")
+	// Not correct index
+	ErrNotCorrectIndex = errors.New("Not correct index")
+	// Empty index name
+	ErrEmptyIndexName = errors.New("Empty index name")
+	// Index is not found
+	ErrIndexNotFound = errors.New("Index is not found")
+	// Not correct collection
+	ErrNotCorrectCollection = errors.New("Not correct collection")
+	// Not correct rule of validation
+	ErrNotCorrectRule = errors.New("Not correct rule of validation")
+	// Not supported type
+	ErrNotSupportedType = errors.New("Not supported type")
+	// Document failed validation of schema
+	ErrSchemaValidation = errors.New("Document failed validation of schema")
+	// Document failed validation
+	ErrValidation = errors.New("Document failed validation")
+	// Error value name
+	ErrValueName = errors.New("Error value name")
+	// Error value email
+	ErrValueEmail = errors.New("Error value email")
+	// Error value field
+	ErrValueField = errors.New("Error value field")
+	// Empty patch
+	ErrEmptyPatch = errors.New("Empty patch")
+	// Not correct patch
+	ErrNotCorrectPatch = errors.New("Not correct patch")
+	// Key of document is not equal key of operation
+	ErrKeyMismatch = errors.New("Key of document is not equal key")
+	// Empty bulk
+	ErrEmptyBulk = errors.New("Empty bulk")
+	// Some operations of bulk write failed
+	ErrBulkWrite = errors.New("Bulk write failed")
+	// Operation is not executed after failed operation of ordered bulk write
+	ErrNotExecuted = errors.New("Operation is not executed")
+	// Version of document is changed by other operation
+	ErrConcurrentModification = errors.New("Concurrent modification of document")
+	// Versioning is disabled
+	ErrNotVersioned = errors.New("Versioning is disabled")
+	// Soft delete is disabled
+	ErrNotSoftDelete = errors.New("Soft delete is disabled")
+	// Negative retention of deleted documents
+	ErrNegativeRetention = errors.New("Retention is negative")
+	// Empty filter of delete of many documents
+	ErrEmptyFilter = errors.New("Empty filter of delete")
+	// Query of delete has sorting, projection or pagination
+	ErrNotCorrectDeleteQuery = errors.New("Query of delete has sorting, projection or pagination")
+	// Negative time of commit or count of retries of transaction
+	ErrValueTxOptions = errors.New("Not correct settings of transaction")
+	// Nil function of transaction
+	ErrEmptyTxFunction = errors.New("Empty function of transaction")
+	// Negative size of buffer of channel
+	ErrNegativeBuffer = errors.New("Size of buffer is negative")
+	// Resume token of stream of changes is not correct
+	ErrNotCorrectResumeToken = errors.New("Not correct resume token")
+	// Outbox is disabled
+	ErrNotOutbox = errors.New("Outbox is disabled")
+	// Nil publisher of relay
+	ErrNilPublisher = errors.New("Publisher is nil")
+	// Negative settings of relay or outbox
+	ErrValueRelayOptions = errors.New("Not correct settings of relay")
+	// Stage of pipeline of aggregation is not correct
+	ErrNotCorrectPipeline = errors.New("Not correct pipeline")
+	// Operator of pipeline is not supported by implementation
+	ErrNotSupportedOperator = errors.New("Operator of pipeline is not supported")
+	// Results of aggregation is not pointer to slice
+	ErrNotCorrectResults = errors.New("Results must be pointer to slice")
+	// Boundaries of buckets are not ascending or less than two
+	ErrValueBoundaries = errors.New("Not correct boundaries of buckets")
+	// Negative size of batch or time of query of iteration
+	ErrValueIterOptions = errors.New("Not correct settings of iteration")
+	// Format of export or import is not supported
+	ErrNotCorrectFormat = errors.New("Not correct format of transfer")
+	// Negative size of batch, unknown policy of duplicates or not correct mapping of fields
+	ErrValueTransferOptions = errors.New("Not correct settings of transfer")
+	// Record of import can not be decoded to document
+	ErrNotCorrectRecord = errors.New("Not correct record of import")
 )
+
+// Error of DSN with detail. Matches ErrNotCorrectDSN.
+type DSNError struct {
+	// Description of the problem
+	Detail string
+	// Error of parser
+	Err error
+}
+
+// Text of error.
+func (e *DSNError) Error() string {
+
+	if e.Err != nil {
+		return ErrNotCorrectDSN.Error() + ": " + e.Detail + ": " + e.Err.Error()
+	}
+
+	return ErrNotCorrectDSN.Error() + ": " + e.Detail
+}
+
+// Wrapped errors.
+func (e *DSNError) Unwrap() []error {
+
+	if e.Err != nil {
+		return []error{ErrNotCorrectDSN, e.Err}
+	}
+
+	return []error{ErrNotCorrectDSN}
+}
@@ -1,5 +1,10 @@
 package mongodb
 
+import (
+	"context"
+	"time"
+)
+
 func IsExistsCollection(listNames []string, name string) bool {
 
 	for _, n := range listNames {
@@ -11,3 +16,36 @@ func IsExistsCollection(listNames []string, name string) bool {
 
 	return false
 }
+
+// Bounds the context by timeout, if it has no deadline. Returns context and cancel function.
+//
+// Params:
+//
+//	ctx - context
+//	timeout - fallback timeout
+func contextWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// Bounds the context by timeout of operation. Returns context and cancel function.
+//
+// Params:
+//
+//	ctx - context
+func (m *mongoDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+
+	timeout := m.opTimeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return contextWithTimeout(ctx, timeout)
+}
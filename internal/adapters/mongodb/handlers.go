@@ -6,13 +6,23 @@ import (
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Close db connect. Return error.
 func (d *mongoDB) Close() error {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	return d.CloseCtx(context.Background())
+}
+
+// Close db connect with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+func (d *mongoDB) CloseCtx(ctx context.Context) error {
+
+	ctx, cancel := d.withTimeout(ctx)
 	defer cancel()
 
 	if err := d.connect.Disconnect(ctx); err != nil {
@@ -29,6 +39,17 @@ func (d *mongoDB) Close() error {
 //	collections - list of collections names.
 func (m *mongoDB) CheckCreateDB(collections []string) error {
 
+	return m.CheckCreateDBCtx(context.Background(), collections)
+}
+
+// If DB is not exists - create by name with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	collections - list of collections names.
+func (m *mongoDB) CheckCreateDBCtx(ctx context.Context, collections []string) error {
+
 	// Check
 	if m.nameDB == "" {
 		return ErrEmptyValueNameDB
@@ -40,31 +61,31 @@ func (m *mongoDB) CheckCreateDB(collections []string) error {
 		return ErrEmptyCollectionsNames
 	}
 
-	// Get collections names
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	// Create collections
+	specs := make([]CollectionSpec, 0, len(collections))
+	for _, v := range collections {
+		specs = append(specs, CollectionSpec{Name: v})
+	}
 
-	names, err := m.db.ListCollectionNames(ctx, bson.M{})
-	if err != nil {
-		return fmt.Errorf("failed to list collection names: %v", err)
+	if err := m.CreateCollections(ctx, specs); err != nil {
+		return err
 	}
 
-	// Create collections
+	// Apply schemas
 	for _, v := range collections {
 
-		if IsExistsCollection(names, v) {
-			continue
+		if schema, ok := m.schemas[v]; ok {
+			if err := m.ApplySchema(ctx, v, schema, SchemaOptions{}); err != nil {
+				return err
+			}
 		}
+	}
 
-		collection := m.connect.Database(m.nameDB).Collection(v)
-
-		doc := bson.D{
-			{Key: "name", Value: "initial"},
-		}
+	// Create indexes
+	for _, v := range collections {
 
-		_, err := collection.InsertOne(context.Background(), doc)
-		if err != nil {
-			return fmt.Errorf("failed to create collection and insert initial document: %v", err)
+		if err := m.users().EnsureIndexes(ctx, v); err != nil {
+			return err
 		}
 	}
 
@@ -78,6 +99,17 @@ func (m *mongoDB) CheckCreateDB(collections []string) error {
 //	collectionName - collection name.
 func (m *mongoDB) DropCollection(collectionName string) error {
 
+	return m.DropCollectionCtx(context.Background(), collectionName)
+}
+
+// Drop collection by name with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - collection name.
+func (m *mongoDB) DropCollectionCtx(ctx context.Context, collectionName string) error {
+
 	// Check
 	if collectionName == "" {
 		return ErrEmptyValueName
@@ -87,19 +119,34 @@ func (m *mongoDB) DropCollection(collectionName string) error {
 	}
 
 	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
 	collection := m.db.Collection(collectionName)
 
-	err := collection.Drop(context.Background())
+	err := collection.Drop(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to drop collection: <%w>", err)
 	}
 
+	m.forgetIndexes(collectionName)
+
 	return nil
 }
 
 // Get names of collections. Returns names and error.
 func (m *mongoDB) GetNamesCollections() (names []string, err error) {
 
+	return m.GetNamesCollectionsCtx(context.Background())
+}
+
+// Get names of collections with context. Returns names and error.
+//
+// Params:
+//
+//	ctx - context
+func (m *mongoDB) GetNamesCollectionsCtx(ctx context.Context) (names []string, err error) {
+
 	// Check
 	if m.connect == nil {
 		return nil, ErrNilPtrDB
@@ -109,7 +156,7 @@ func (m *mongoDB) GetNamesCollections() (names []string, err error) {
 	}
 
 	// Logic
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
 	names, err = m.db.ListCollectionNames(ctx, bson.M{})
@@ -126,49 +173,28 @@ func (m *mongoDB) GetNamesCollections() (names []string, err error) {
 //
 //	collectionName - name of collection
 //	doc - document
-func (m *mongoDB) SendDocumentUser(collectionName string, doc DocUser) (id interface{}, err error) {
-
-	// Check
-	if m.db == nil {
-		return nil, ErrNilPtrDB
-	}
-	if m.connect == nil {
-		return nil, ErrNilPtrConnect
-	}
-	if collectionName == "" {
-		return nil, ErrEmptyCollectionsName
-	}
-	if doc.Age <= 0 && doc.Email == "" && doc.Name == "" {
-		return nil, ErrEmptyDocument
-	}
-	if doc.Age <= 0 {
-		return nil, ErrValueAge
-	}
-
-	// Сheck exists document
-	collection := m.db.Collection(collectionName)
+func (m *mongoDB) SendDocumentUser(collectionName string, doc DocUser) (id primitive.ObjectID, err error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
-
-	var existingDoc DocUser
-	filter := bson.M{"name": doc.Name, "age": doc.Age, "email": doc.Email}
-	err = collection.FindOne(ctx, filter).Decode(&existingDoc)
+	return m.SendDocumentUserCtx(context.Background(), collectionName, doc)
+}
 
-	if err == nil {
-		return nil, ErrDocumentExists
-	}
-	if err != mongo.ErrNoDocuments {
-		return nil, fmt.Errorf("Function FindOne, return error: <%w>", err)
-	}
+// Send the document user on DB with context. Returns id added document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	doc - document
+func (m *mongoDB) SendDocumentUserCtx(ctx context.Context, collectionName string, doc DocUser) (id primitive.ObjectID, err error) {
 
-	// Send
-	result, err := collection.InsertOne(ctx, doc)
+	inserted, err := m.users().Insert(ctx, collectionName, doc)
 	if err != nil {
-		return nil, fmt.Errorf("Function InsertOne, returned error: <%w>", err)
+		return primitive.NilObjectID, err
 	}
 
-	return result.InsertedID, nil
+	id, _ = inserted.(primitive.ObjectID)
+
+	return id, nil
 }
 
 // Update the document user on DB. Returns id added document and error.
@@ -180,46 +206,185 @@ func (m *mongoDB) SendDocumentUser(collectionName string, doc DocUser) (id inter
 //	doc - document
 func (m *mongoDB) UpdateDocumentUserByName(collectionName, name string, doc DocUser) (err error) {
 
+	return m.UpdateDocumentUserByNameCtx(context.Background(), collectionName, name, doc)
+}
+
+// Update the document user on DB with context. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document
+func (m *mongoDB) UpdateDocumentUserByNameCtx(ctx context.Context, collectionName, name string, doc DocUser) (err error) {
+
+	return m.users().UpdateByKey(ctx, collectionName, name, doc)
+}
+
+// Patch the document user by name. Only fields of patch are changed and checked.
+// Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	p - patch
+func (m *mongoDB) PatchDocumentUserByName(ctx context.Context, collectionName, name string, p *Patch) (DocUser, error) {
+
+	return m.users().PatchByKey(ctx, collectionName, name, p)
+}
+
+// Replace the document user by name or insert it, if name is not found.
+// Empty name of document is set by name. Returns result and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document
+func (m *mongoDB) UpsertDocumentUserByName(ctx context.Context, collectionName, name string, doc DocUser) (UpsertResult, error) {
+
+	return m.users().UpsertByKey(ctx, collectionName, name, doc)
+}
+
+// Send documents user by one bulk write, with outbox one by one with events. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (m *mongoDB) SendDocumentsUser(ctx context.Context, collectionName string, docs []DocUser, opts BulkOptions) (BulkResult, error) {
+
+	return m.users().BulkInsert(ctx, collectionName, docs, opts)
+}
+
+// Insert imported documents user by one bulk write, with outbox one by one with events. Fields, maintained
+// by adapter, are kept from documents, missing ones are set as on insert. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (m *mongoDB) InsertImportedDocumentsUser(ctx context.Context, collectionName string, docs []DocUser, opts BulkOptions) (BulkResult, error) {
+
+	return m.users().BulkInsertImported(ctx, collectionName, docs, opts)
+}
+
+// Replace the document user by id by imported document. Omitted fields are removed, fields, maintained
+// by adapter, are kept from document, missing ones are set as on insert. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+//	doc - document
+func (m *mongoDB) ReplaceImportedDocumentUser(ctx context.Context, collectionName string, id primitive.ObjectID, doc DocUser) error {
+
 	// Check
-	if m.db == nil {
-		return ErrNilPtrDB
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return err
 	}
-	if m.connect == nil {
-		return ErrNilPtrConnect
-	}
-	if collectionName == "" {
-		return ErrEmptyCollectionsName
-	}
-	if name == "" {
-		return ErrEmptyValueName
+	filter, err := userIDFilter(id)
+	if err != nil {
+		return err
 	}
-	if doc.Age <= 0 && doc.Email == "" && doc.Name == "" {
-		return ErrEmptyDocument
+
+	return users.ReplaceImported(ctx, collectionName, filter, doc)
+}
+
+// Update documents user by names by one bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	updates - updates
+//	opts - options
+func (m *mongoDB) UpdateDocumentsUserByName(ctx context.Context, collectionName string, updates []UserUpdate, opts BulkOptions) (BulkResult, error) {
+
+	list := make([]BulkUpdate[DocUser], 0, len(updates))
+	for _, u := range updates {
+		list = append(list, BulkUpdate[DocUser]{Key: u.Name, Doc: u.Doc})
 	}
-	if doc.Age <= 0 {
-		return ErrValueAge
+
+	return m.users().BulkUpdateByKey(ctx, collectionName, list, opts)
+}
+
+// Delete documents user by names by one bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	names - names of users
+//	opts - options
+func (m *mongoDB) DelDocumentsUserByName(ctx context.Context, collectionName string, names []string, opts BulkOptions) (BulkResult, error) {
+
+	keys := make([]interface{}, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, name)
 	}
 
-	// Logic
-	collection := m.db.Collection(collectionName)
+	return m.users().BulkDeleteByKey(ctx, collectionName, keys, opts)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// Update the document user by name, if version of document is equal doc.Version.
+// Requires WithVersioning. Returns updated document with new version and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document with expected version
+func (m *mongoDB) UpdateDocumentUserByNameVersioned(ctx context.Context, collectionName, name string, doc DocUser) (DocUser, error) {
 
-	filter := bson.M{"name": name}
+	return m.users().UpdateByKeyVersioned(ctx, collectionName, name, doc)
+}
 
-	update := bson.M{"$set": doc}
+// Find softly deleted documents user. Requires WithSoftDelete. Returns documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (m *mongoDB) FindDeletedDocumentsUser(ctx context.Context, collectionName string) ([]DocUser, error) {
 
-	result, err := collection.UpdateOne(ctx, filter, update)
-	if err != nil {
-		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
-	}
+	return m.users().FindDeleted(ctx, collectionName, nil)
+}
 
-	if result.MatchedCount == 0 {
-		return ErrUpdateDocument
-	}
+// Restore softly deleted document user by name. Requires WithSoftDelete. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+func (m *mongoDB) RestoreDocumentUserByName(ctx context.Context, collectionName, name string) error {
 
-	return nil
+	return m.users().RestoreByKey(ctx, collectionName, name)
+}
+
+// Remove softly deleted documents user, which are deleted before retention from now.
+// Requires WithSoftDelete. Returns count removed documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	retention - time of keeping of deleted documents, 0 - all deleted documents
+func (m *mongoDB) PurgeDeletedDocumentsUser(ctx context.Context, collectionName string, retention time.Duration) (int64, error) {
+
+	return m.users().Purge(ctx, collectionName, retention)
 }
 
 // Recieve document user by name. Returns document and error.
@@ -230,148 +395,353 @@ func (m *mongoDB) UpdateDocumentUserByName(collectionName, name string, doc DocU
 //	name - name
 func (m *mongoDB) RecvDocumentUserByName(collectionName string, name string) (doc DocUser, err error) {
 
+	return m.RecvDocumentUserByNameCtx(context.Background(), collectionName, name)
+}
+
+// Recieve document user by name with context. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name
+func (m *mongoDB) RecvDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (doc DocUser, err error) {
+
+	return m.users().FindOneByKey(ctx, collectionName, name)
+}
+
+// Delete document user by name. Returns document and error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	name - name
+func (m *mongoDB) DelDocumentUserByName(collectionName string, name string) (int64, error) {
+
+	return m.DelDocumentUserByNameCtx(context.Background(), collectionName, name)
+}
+
+// Delete document user by name with context. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name
+func (m *mongoDB) DelDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (int64, error) {
+
+	return m.users().DeleteByKey(ctx, collectionName, name)
+}
+
+// Delete all documents user by name. Returns count deleted documents
+// (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	opts - options
+func (m *mongoDB) DelAllDocumentsUserByName(ctx context.Context, collectionName, name string, opts DeleteOptions) (int64, error) {
+
+	return m.users().DeleteManyByKey(ctx, collectionName, name, opts)
+}
+
+// Delete all documents user by conditions of query. Query without conditions is refused
+// without opts.AllowEmptyFilter. Returns count deleted documents (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query with conditions only, nil - all documents
+//	opts - options
+func (m *mongoDB) DelDocumentsUserByQuery(ctx context.Context, collectionName string, q *UserQuery, opts DeleteOptions) (int64, error) {
+
 	// Check
-	if m.db == nil {
-		return DocUser{}, ErrNilPtrDB
+	spec, err := q.Spec()
+	if err != nil {
+		return 0, err
 	}
-	if m.connect == nil {
-		return DocUser{}, ErrNilPtrConnect
+	filter, err := spec.deleteFilter()
+	if err != nil {
+		return 0, err
 	}
-	if collectionName == "" {
-		return DocUser{}, ErrEmptyCollectionsName
+
+	return m.users().DeleteMany(ctx, collectionName, filter, opts)
+}
+
+// Change collection for document. Return error.
+//
+// Params:
+//
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	doc - document
+func (m *mongoDB) MoveDocumentUserTx(srcCollection, destCollection string, doc DocUser) error {
+
+	return m.MoveDocumentUserTxCtx(context.Background(), srcCollection, destCollection, doc)
+}
+
+// Change collection for document with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	doc - document
+func (m *mongoDB) MoveDocumentUserTxCtx(ctx context.Context, srcCollection, destCollection string, doc DocUser) error {
+
+	return m.users().MoveByKeyTx(ctx, srcCollection, destCollection, doc.Name)
+}
+
+// Recieve document user by id. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+func (m *mongoDB) RecvDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (DocUser, error) {
+
+	// Check
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return DocUser{}, err
 	}
-	if name == "" {
-		return DocUser{}, ErrEmptyValueName
+	filter, err := userIDFilter(id)
+	if err != nil {
+		return DocUser{}, err
 	}
 
-	// Logic
-	collection := m.db.Collection(collectionName)
+	return users.FindOne(ctx, collectionName, filter)
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+// Recieve first document user by email. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	email - email
+func (m *mongoDB) RecvDocumentUserByEmail(ctx context.Context, collectionName, email string) (DocUser, error) {
 
-	filter := bson.M{"name": name}
+	// Check
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return DocUser{}, err
+	}
+	filter, err := userEmailFilter(email)
+	if err != nil {
+		return DocUser{}, err
+	}
+
+	return users.FindOne(ctx, collectionName, filter)
+}
 
-	err = collection.FindOne(ctx, filter).Decode(&doc)
+// Update the document user by id. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+//	doc - document
+func (m *mongoDB) UpdateDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID, doc DocUser) error {
+
+	// Check
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return err
+	}
+	filter, err := userIDFilter(id)
 	if err != nil {
-		return DocUser{}, fmt.Errorf("Function FindOne return error: <%w>", err)
+		return err
 	}
 
-	return doc, nil
+	return users.Update(ctx, collectionName, filter, doc)
 }
 
-// Delete document user by name. Returns document and error.
+// Update the first document user by email. Returns error.
 //
 // Params:
 //
+//	ctx - context
 //	collectionName - name of collection
-//	name - name
-func (m *mongoDB) DelDocumentUserByName(collectionName string, name string) (int64, error) {
+//	email - email
+//	doc - document
+func (m *mongoDB) UpdateDocumentUserByEmail(ctx context.Context, collectionName, email string, doc DocUser) error {
 
 	// Check
-	if m.db == nil {
-		return 0, ErrNilPtrDB
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return err
 	}
-	if m.connect == nil {
-		return 0, ErrNilPtrConnect
+	filter, err := userEmailFilter(email)
+	if err != nil {
+		return err
 	}
-	if collectionName == "" {
-		return 0, ErrEmptyCollectionsName
+
+	return users.Update(ctx, collectionName, filter, doc)
+}
+
+// Delete document user by id. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+func (m *mongoDB) DelDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (int64, error) {
+
+	// Check
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return 0, err
 	}
-	if name == "" {
-		return 0, ErrEmptyValueName
+	filter, err := userIDFilter(id)
+	if err != nil {
+		return 0, err
 	}
 
-	// Logic
-	collection := m.db.Collection(collectionName)
+	return users.Delete(ctx, collectionName, filter)
+}
 
-	filter := bson.M{"name": name}
+// Delete the first document user by email. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	email - email
+func (m *mongoDB) DelDocumentUserByEmail(ctx context.Context, collectionName, email string) (int64, error) {
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	// Check
+	users := m.users()
+	if err := users.check(collectionName); err != nil {
+		return 0, err
+	}
+	filter, err := userEmailFilter(email)
+	if err != nil {
+		return 0, err
+	}
+
+	return users.Delete(ctx, collectionName, filter)
+}
 
-	result, err := collection.DeleteOne(ctx, filter)
+// Change collection for document by id. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	id - id of document
+func (m *mongoDB) MoveDocumentUserByIDTx(ctx context.Context, srcCollection, destCollection string, id primitive.ObjectID) error {
+
+	// Check
+	users := m.users()
+	if err := users.check(srcCollection); err != nil {
+		return err
+	}
+	if destCollection == "" {
+		return ErrEmptyCollectionsName
+	}
+	filter, err := userIDFilter(id)
 	if err != nil {
-		return 0, fmt.Errorf("failed to delete document: <%w>", err)
+		return err
 	}
 
-	return result.DeletedCount, nil
+	return users.MoveTx(ctx, srcCollection, destCollection, filter)
 }
 
-// Change collection for document. Return error.
+// Change collection for the first document by email. Return error.
 //
 // Params:
 //
+//	ctx - context
 //	srcCollection - source collection
 //	destCollection - destination collection
-//	doc - document
-func (m *mongoDB) MoveDocumentUserTx(srcCollection, destCollection string, doc DocUser) error {
+//	email - email
+func (m *mongoDB) MoveDocumentUserByEmailTx(ctx context.Context, srcCollection, destCollection, email string) error {
 
-	//
 	// Check
-	//
+	users := m.users()
+	if err := users.check(srcCollection); err != nil {
+		return err
+	}
+	if destCollection == "" {
+		return ErrEmptyCollectionsName
+	}
+	filter, err := userEmailFilter(email)
+	if err != nil {
+		return err
+	}
+
+	return users.MoveTx(ctx, srcCollection, destCollection, filter)
+}
+
+// Find documents user by query. Returns documents, token of next page and error.
+// Token is empty on the last page.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query, nil - all documents
+func (m *mongoDB) FindDocumentsUser(ctx context.Context, collectionName string, q *UserQuery) (docs []DocUser, next string, err error) {
 
+	// Check
 	if m.db == nil {
-		return ErrNilPtrDB
+		return nil, "", ErrNilPtrDB
 	}
 	if m.connect == nil {
-		return ErrNilPtrConnect
+		return nil, "", ErrNilPtrConnect
 	}
-	if srcCollection == "" || destCollection == "" {
-		return ErrEmptyCollectionsName
+	if collectionName == "" {
+		return nil, "", ErrEmptyCollectionsName
 	}
-	if doc.Name == "" {
-		return ErrEmptyValueName
+	spec, err := q.Spec()
+	if err != nil {
+		return nil, "", err
 	}
 
-	//
 	// Logic
-	//
+	collection := m.db.Collection(collectionName)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	ctx, cancel := m.withTimeout(ctx)
 	defer cancel()
 
-	session, err := m.connect.StartSession()
+	cursor, err := collection.Find(ctx, m.users().liveFilter(spec.filter()), spec.findOptions())
 	if err != nil {
-		return fmt.Errorf("Error stsrt session: <%v>", err)
+		return nil, "", fmt.Errorf("Function Find return error: <%w>", err)
 	}
-	defer session.EndSession(ctx)
+	defer cursor.Close(ctx)
 
-	sourceCollection := m.db.Collection(srcCollection)
-	destinationCollection := m.db.Collection(destCollection)
-	filter := bson.M{"name": doc.Name}
+	var last DocUser
+	docs = []DocUser{}
 
-	// Transaction
-	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
-		var result bson.M
+	for cursor.Next(ctx) {
 
-		// Recieve
-		err := sourceCollection.FindOne(sessCtx, filter).Decode(&result)
-		if err != nil {
-			if err == mongo.ErrNoDocuments {
-				return nil, fmt.Errorf("Document is not found: <%v>", err)
+		if spec.Limit > 0 && int64(len(docs)) == spec.Limit {
+			if next, err = spec.NextPageToken(last); err != nil {
+				return nil, "", err
 			}
-			return nil, fmt.Errorf("Fault recieve document: <%v>", err)
-		}
-
-		// Insert
-		_, err = destinationCollection.InsertOne(sessCtx, result)
-		if err != nil {
-			return nil, fmt.Errorf("Fault insert document: <%v>", err)
+			break
 		}
 
-		// Delete
-		_, err = sourceCollection.DeleteOne(sessCtx, filter)
-		if err != nil {
-			return nil, fmt.Errorf("Fault delete document: <%v>", err)
+		var doc DocUser
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, "", fmt.Errorf("Function Decode return error: <%w>", err)
 		}
 
-		return nil, nil
-	})
-
-	if err != nil {
-		return fmt.Errorf("Fault transaction: <%v>", err)
+		last = doc
+		docs = append(docs, spec.ApplyProjection(doc))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, "", fmt.Errorf("Function Next return error: <%w>", err)
 	}
 
-	return nil
+	return docs, next, nil
 }
@@ -1,10 +1,13 @@
 package mongodb
 
 import (
+	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 // Test CheckCreateDB
@@ -170,7 +173,7 @@ func TestSendDocumentUser(t *testing.T) {
 		}
 
 		_, err := db.SendDocumentUser(collection, doc)
-		require.Equalf(t, ErrValueAge, err, "Error is not equal")
+		require.Truef(t, errors.Is(err, ErrValueAge), "Error is not equal")
 	})
 
 	t.Run("Missing document", func(t *testing.T) {
@@ -289,7 +292,7 @@ func TestUpdateDocumentUser(t *testing.T) {
 		}
 
 		err := db.UpdateDocumentUserByName(collection, name, doc)
-		require.Equalf(t, ErrValueAge, err, "Error is not equal")
+		require.Truef(t, errors.Is(err, ErrValueAge), "Error is not equal")
 	})
 
 	t.Run("Correct", func(t *testing.T) {
@@ -299,7 +302,7 @@ func TestUpdateDocumentUser(t *testing.T) {
 		doc := DocUser{
 			Name:  "Aaa",
 			Age:   30,
-			Email: "Bbb@mail.com",
+			Email: "bbb@mail.com",
 		}
 		db.SendDocumentUser(collection, doc)
 		require.NoErrorf(t, err, "Unexpected error send")
@@ -309,7 +312,7 @@ func TestUpdateDocumentUser(t *testing.T) {
 		doc2 := DocUser{
 			Name:  "Aaa",
 			Age:   33,
-			Email: "Bbb@mail.com",
+			Email: "bbb@mail.com",
 		}
 		err := db.UpdateDocumentUserByName(collection, name, doc2)
 		require.NoErrorf(t, err, "Unexpected error update")
@@ -387,7 +390,7 @@ func TestRecvDocumentUserByName(t *testing.T) {
 		doc := DocUser{
 			Name:  "Aaa",
 			Age:   20,
-			Email: "AAA@mail.com",
+			Email: "aaa@mail.com",
 		}
 		_, err := db.SendDocumentUser(collection, doc)
 		require.NoErrorf(t, err, "Unexpected error send")
@@ -556,7 +559,7 @@ func TestMoveDocumentUser(t *testing.T) {
 		doc := DocUser{
 			Name:  "A",
 			Age:   30,
-			Email: "A@mail.mail",
+			Email: "a@mail.mail",
 		}
 
 		// Create
@@ -585,3 +588,56 @@ func TestMoveDocumentUser(t *testing.T) {
 	})
 
 }
+
+// Test RemoveInitialDocuments
+func TestRemoveInitialDocuments(t *testing.T) {
+
+	dsn := "mongodb://localhost:27017/myDatabase"
+
+	db, err := New(dsn)
+	require.NoErrorf(t, err, "Unexpected error New")
+	require.NotNil(t, db, "Pointer db is nil")
+
+	defer func() {
+		err = db.Close()
+		assert.NoErrorf(t, err, "Unexpected error Close")
+	}()
+
+	collections := []string{"info-1", "info-2"}
+
+	err = db.CheckCreateDB(collections)
+	require.NoErrorf(t, err, "Unexpected error CheckCreateDB")
+
+	defer func() {
+		err := db.DropCollection(collections[0])
+		require.NoErrorf(t, err, "Unexpected error DropCollection 0")
+
+		err = db.DropCollection(collections[1])
+		require.NoErrorf(t, err, "Unexpected error DropCollection 1")
+	}()
+
+	t.Run("Missing collections", func(t *testing.T) {
+
+		_, err := db.RemoveInitialDocuments(context.Background(), nil)
+		require.Equalf(t, ErrEmptyCollectionsNames, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		// Legacy placeholder
+		_, err := db.(*mongoDB).db.Collection(collections[0]).InsertOne(context.Background(), bson.M{"name": "initial"})
+		require.NoErrorf(t, err, "Unexpected error insert placeholder")
+
+		// User with the same name
+		_, err = db.SendDocumentUser(collections[0], DocUser{Name: "initial", Age: 20, Email: "initial@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error send")
+
+		cnt, err := db.RemoveInitialDocuments(context.Background(), collections)
+		require.NoErrorf(t, err, "Unexpected error RemoveInitialDocuments")
+		assert.Equalf(t, int64(1), cnt, "Value is not equal")
+
+		rxDoc, err := db.RecvDocumentUserByName(collections[0], "initial")
+		require.NoErrorf(t, err, "Unexpected error recieve")
+		assert.Equalf(t, 20, rxDoc.Age, "Age is not equal")
+	})
+}
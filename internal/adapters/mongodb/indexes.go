@@ -0,0 +1,208 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Declaration of index.
+type IndexSpec struct {
+	// Name of index. Empty - generated from keys
+	Name string
+	// Keys of index in order
+	Keys bson.D
+	// Unique values of keys
+	Unique bool
+	// Skip documents without keys
+	Sparse bool
+	// Index only documents, which match filter
+	PartialFilter interface{}
+	// TTL: documents expire after seconds from value of date key
+	ExpireAfterSeconds *int32
+	// Collation of strings
+	Collation *options.Collation
+}
+
+// Type of key: text index.
+const IndexText = "text"
+
+// Type of key: geospatial index on sphere.
+const Index2DSphere = "2dsphere"
+
+// Indexes of collections of users.
+var UserIndexes = UserIndexSpecs(false)
+
+// Indexes of collections of users. Returns declarations.
+//
+// With soft delete the field of deletion is the last key of unique index: live documents are indexed
+// with null, deleted ones with time of deletion, so a deleted user does not hold values of live one.
+// Partial filter {deletedAt: {$exists: false}} is not allowed by MongoDB.
+//
+// Params:
+//
+//	softDelete - soft delete is on
+func UserIndexSpecs(softDelete bool) []IndexSpec {
+
+	if softDelete {
+		return []IndexSpec{uniqueIndex(userRepositoryConfig.UniqueFields, "deletedAt")}
+	}
+
+	return []IndexSpec{uniqueIndex(userRepositoryConfig.UniqueFields, "")}
+}
+
+// Unique index by fields. Returns declaration.
+//
+// Params:
+//
+//	fields - names of fields
+//	deletedField - field of soft delete, "" - without soft delete
+func uniqueIndex(fields []string, deletedField string) IndexSpec {
+
+	keys := bson.D{}
+	for _, f := range fields {
+		keys = append(keys, bson.E{Key: f, Value: 1})
+	}
+	if deletedField != "" {
+		keys = append(keys, bson.E{Key: deletedField, Value: 1})
+	}
+
+	return IndexSpec{Keys: keys, Unique: true}
+}
+
+// Name of index. Returns given name or name generated from keys, as MongoDB does.
+func (s IndexSpec) IndexName() string {
+
+	if s.Name != "" {
+		return s.Name
+	}
+
+	parts := make([]string, 0, len(s.Keys))
+	for _, k := range s.Keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", k.Key, k.Value))
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// Model of index for driver. Returns model.
+func (s IndexSpec) model() mongo.IndexModel {
+
+	opts := options.Index().SetName(s.IndexName())
+	if s.Unique {
+		opts.SetUnique(true)
+	}
+	if s.Sparse {
+		opts.SetSparse(true)
+	}
+	if s.PartialFilter != nil {
+		opts.SetPartialFilterExpression(s.PartialFilter)
+	}
+	if s.ExpireAfterSeconds != nil {
+		opts.SetExpireAfterSeconds(*s.ExpireAfterSeconds)
+	}
+	if s.Collation != nil {
+		opts.SetCollation(s.Collation)
+	}
+
+	return mongo.IndexModel{Keys: s.Keys, Options: opts}
+}
+
+// Check declarations of indexes. Returns error.
+//
+// Params:
+//
+//	specs - declarations of indexes
+func checkIndexSpecs(specs []IndexSpec) error {
+
+	if len(specs) == 0 {
+		return ErrEmptyIndexes
+	}
+	for _, s := range specs {
+		if len(s.Keys) == 0 {
+			return ErrEmptyIndexKeys
+		}
+		if s.IndexName() == idIndexName {
+			return ErrNotCorrectIndex
+		}
+	}
+
+	return nil
+}
+
+// Create indexes of collection, if they are not exists. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	specs - declarations of indexes
+func (m *mongoDB) EnsureIndexes(ctx context.Context, collectionName string, specs []IndexSpec) error {
+
+	// Check
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return ErrEmptyCollectionsName
+	}
+	if err := checkIndexSpecs(specs); err != nil {
+		return err
+	}
+
+	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	models := make([]mongo.IndexModel, 0, len(specs))
+	for _, s := range specs {
+		models = append(models, s.model())
+	}
+
+	_, err := m.db.Collection(collectionName).Indexes().CreateMany(ctx, models)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("failed to create indexes: <%w>", ErrDocumentExists)
+		}
+		if isServerError(err, codeIndexOptionsConflict) || isServerError(err, codeIndexKeySpecsConflict) {
+			return fmt.Errorf("failed to create indexes: <%w>", ErrNotCorrectIndex)
+		}
+		return fmt.Errorf("failed to create indexes: <%w>", err)
+	}
+
+	for _, s := range specs {
+		m.indexed.Store(indexedKey(collectionName, s.IndexName()), true)
+	}
+
+	return nil
+}
+
+// Key of ensured index in cache. Returns key.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	name - name of index
+func indexedKey(collectionName, name string) string {
+
+	return collectionName + "\x00" + name
+}
+
+// Forget ensured indexes of collection.
+//
+// Params:
+//
+//	collectionName - name of collection
+func (m *mongoDB) forgetIndexes(collectionName string) {
+
+	m.indexed.Range(func(key, _ interface{}) bool {
+		if strings.HasPrefix(key.(string), collectionName+"\x00") {
+			m.indexed.Delete(key)
+		}
+		return true
+	})
+}
@@ -0,0 +1,133 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Test IndexSpec.
+func TestIndexSpec(t *testing.T) {
+
+	t.Run("Name", func(t *testing.T) {
+
+		spec := IndexSpec{Keys: bson.D{{Key: "name", Value: 1}, {Key: "age", Value: -1}}}
+		assert.Equalf(t, "name_1_age_-1", spec.IndexName(), "Name is not equal")
+
+		spec.Name = "custom"
+		assert.Equalf(t, "custom", spec.IndexName(), "Name is not equal")
+	})
+
+	t.Run("User indexes", func(t *testing.T) {
+
+		require.Lenf(t, UserIndexes, 1, "Count of indexes is not equal")
+		assert.Equalf(t, "name_1_age_1_email_1", UserIndexes[0].IndexName(), "Name is not equal")
+		assert.Truef(t, UserIndexes[0].Unique, "Index is not unique")
+	})
+
+	t.Run("Check", func(t *testing.T) {
+
+		require.Equalf(t, ErrEmptyIndexes, checkIndexSpecs(nil), "Error is not equal")
+		require.Equalf(t, ErrEmptyIndexKeys, checkIndexSpecs([]IndexSpec{{Name: "a"}}), "Error is not equal")
+		require.Equalf(t, ErrNotCorrectIndex, checkIndexSpecs([]IndexSpec{{Name: idIndexName, Keys: bson.D{{Key: "_id", Value: 1}}}}), "Error is not equal")
+		require.NoErrorf(t, checkIndexSpecs(UserIndexes), "Unexpected error")
+	})
+
+	t.Run("User indexes with soft delete", func(t *testing.T) {
+
+		specs := UserIndexSpecs(true)
+		require.Lenf(t, specs, 1, "Count of indexes is not equal")
+		assert.Equalf(t, "name_1_age_1_email_1_deletedAt_1", specs[0].IndexName(), "Name is not equal")
+		assert.Nilf(t, specs[0].PartialFilter, "Partial filter is set")
+
+		m := &mongoDB{softDelete: true}
+		assert.Equalf(t, specs, m.users().Indexes(), "Indexes is not equal")
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+
+		m := &mongoDB{}
+		m.indexed.Store(indexedKey("users", UserIndexes[0].IndexName()), true)
+		m.indexed.Store(indexedKey("users-2", UserIndexes[0].IndexName()), true)
+
+		m.forgetIndexes("users")
+
+		_, ok := m.indexed.Load(indexedKey("users", UserIndexes[0].IndexName()))
+		assert.Falsef(t, ok, "Index is not forgotten")
+		_, ok = m.indexed.Load(indexedKey("users-2", UserIndexes[0].IndexName()))
+		assert.Truef(t, ok, "Index is forgotten")
+	})
+}
+
+// Test DiffIndexes.
+func TestDiffIndexes(t *testing.T) {
+
+	byEmail := IndexSpec{Keys: bson.D{{Key: "email", Value: 1}}, Unique: true}
+	byName := IndexSpec{Keys: bson.D{{Key: "name", Value: 1}}}
+	idIndex := IndexSpec{Name: idIndexName, Keys: bson.D{{Key: "_id", Value: int32(1)}}}
+
+	existing := []IndexSpec{
+		idIndex,
+		{Name: "email_1", Keys: bson.D{{Key: "email", Value: int32(1)}}},
+		{Name: "old_1", Keys: bson.D{{Key: "old", Value: int32(1)}}},
+	}
+
+	report, create, drop := DiffIndexes(existing, []IndexSpec{byEmail, byName}, false)
+	assert.Equalf(t, []string{"name_1"}, report.Created, "Created is not equal")
+	assert.Equalf(t, []string{"email_1"}, report.Recreated, "Recreated is not equal")
+	assert.Equalf(t, []string{"old_1"}, report.Unknown, "Unknown is not equal")
+	assert.Emptyf(t, report.Dropped, "Dropped is not empty")
+	assert.Equalf(t, []IndexSpec{byEmail, byName}, create, "Create is not equal")
+	assert.Equalf(t, []string{"email_1"}, drop, "Drop is not equal")
+
+	report, _, drop = DiffIndexes(existing, []IndexSpec{byName}, true)
+	assert.Equalf(t, []string{"email_1", "old_1"}, report.Dropped, "Dropped is not equal")
+	assert.Equalf(t, []string{"email_1", "old_1"}, drop, "Drop is not equal")
+}
+
+// Test IndexSpec.Equal.
+func TestIndexSpecEqual(t *testing.T) {
+
+	ttl := int32(60)
+	ttl64 := int64(60)
+
+	t.Run("Listed index", func(t *testing.T) {
+
+		listed := listedIndex{
+			Name:               "createdAt_1",
+			Key:                bson.D{{Key: "createdAt", Value: float64(1)}},
+			PartialFilter:      bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: int32(18)}}}},
+			ExpireAfterSeconds: ttl64,
+			Collation: &struct {
+				Locale   string `bson:"locale"`
+				Strength int    `bson:"strength"`
+			}{Locale: "en", Strength: 3},
+		}
+		spec := IndexSpec{
+			Keys:               bson.D{{Key: "createdAt", Value: 1}},
+			PartialFilter:      bson.M{"age": bson.M{"$gt": 18}},
+			ExpireAfterSeconds: &ttl,
+			Collation:          &options.Collation{Locale: "en"},
+		}
+
+		assert.Truef(t, spec.Equal(listed.spec()), "Indexes are not equal")
+
+		spec.Collation.Locale = "fr"
+		assert.Falsef(t, spec.Equal(listed.spec()), "Indexes are equal")
+	})
+
+	t.Run("Text index", func(t *testing.T) {
+
+		listed := listedIndex{
+			Name:    "title_text_body_text",
+			Key:     bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+			Weights: bson.D{{Key: "body", Value: int32(1)}, {Key: "title", Value: int32(1)}},
+		}
+		spec := IndexSpec{Keys: bson.D{{Key: "title", Value: IndexText}, {Key: "body", Value: IndexText}}}
+
+		assert.Truef(t, spec.Equal(listed.spec()), "Indexes are not equal")
+	})
+}
@@ -0,0 +1,402 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Name of index by _id, which exists in each collection.
+const idIndexName = "_id_"
+
+// Code of server error: collection is not exists.
+const codeNamespaceNotFound = 26
+
+// Code of server error: index is not exists.
+const codeIndexNotFound = 27
+
+// Code of server error: index with the same name has other options.
+const codeIndexOptionsConflict = 85
+
+// Code of server error: index with the same name has other keys.
+const codeIndexKeySpecsConflict = 86
+
+// Options of synchronization of indexes.
+type IndexSyncOptions struct {
+	// Drop indexes, which are not declared
+	DropUnknown bool
+	// Only report changes, without applying
+	DryRun bool
+}
+
+// Report of synchronization of indexes. Contains names of indexes.
+type IndexSyncReport struct {
+	// Created indexes
+	Created []string
+	// Indexes with changed declaration, dropped and created again
+	Recreated []string
+	// Dropped indexes, which are not declared
+	Dropped []string
+	// Indexes, which match declaration
+	Unchanged []string
+	// Indexes, which are not declared and not dropped
+	Unknown []string
+}
+
+// Check of changes. Returns flag of changes.
+func (r IndexSyncReport) Changed() bool {
+
+	return len(r.Created) > 0 || len(r.Recreated) > 0 || len(r.Dropped) > 0
+}
+
+// Compare declarations of indexes. Returns flag of equality.
+//
+// Params:
+//
+//	o - other declaration
+func (s IndexSpec) Equal(o IndexSpec) bool {
+
+	if s.IndexName() != o.IndexName() || s.Unique != o.Unique || s.Sparse != o.Sparse {
+		return false
+	}
+	if !reflect.DeepEqual(normalizeKeys(s.Keys), normalizeKeys(o.Keys)) {
+		return false
+	}
+	if (s.ExpireAfterSeconds == nil) != (o.ExpireAfterSeconds == nil) {
+		return false
+	}
+	if s.ExpireAfterSeconds != nil && *s.ExpireAfterSeconds != *o.ExpireAfterSeconds {
+		return false
+	}
+	if !reflect.DeepEqual(normalizeDoc(s.PartialFilter), normalizeDoc(o.PartialFilter)) {
+		return false
+	}
+	if (s.Collation == nil) != (o.Collation == nil) {
+		return false
+	}
+	if s.Collation != nil {
+		// Server fills defaults of collation, so only declared values are compared
+		if s.Collation.Locale != o.Collation.Locale {
+			return false
+		}
+		if s.Collation.Strength != 0 && o.Collation.Strength != 0 && s.Collation.Strength != o.Collation.Strength {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Keys with comparable values, text keys are sorted. Returns keys.
+//
+// Params:
+//
+//	keys - keys of index
+func normalizeKeys(keys bson.D) []string {
+
+	out := []string{}
+	text := []string{}
+
+	for _, k := range keys {
+
+		v := fmt.Sprint(k.Value)
+		if v == IndexText {
+			text = append(text, k.Key)
+			continue
+		}
+		out = append(out, k.Key+":"+v)
+	}
+
+	sort.Strings(text)
+	for _, k := range text {
+		out = append(out, k+":"+IndexText)
+	}
+
+	return out
+}
+
+// Document with comparable values. Returns document or nil.
+//
+// Params:
+//
+//	doc - document
+func normalizeDoc(doc interface{}) interface{} {
+
+	if doc == nil {
+		return nil
+	}
+
+	b, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return doc
+	}
+
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return doc
+	}
+
+	return out
+}
+
+// Description of index, returned by listIndexes.
+type listedIndex struct {
+	Name               string      `bson:"name"`
+	Key                bson.D      `bson:"key"`
+	Unique             bool        `bson:"unique"`
+	Sparse             bool        `bson:"sparse"`
+	PartialFilter      bson.D      `bson:"partialFilterExpression"`
+	ExpireAfterSeconds interface{} `bson:"expireAfterSeconds"`
+	Weights            bson.D      `bson:"weights"`
+	Collation          *struct {
+		Locale   string `bson:"locale"`
+		Strength int    `bson:"strength"`
+	} `bson:"collation"`
+}
+
+// Declaration of listed index. Returns declaration.
+func (l listedIndex) spec() IndexSpec {
+
+	s := IndexSpec{
+		Name:   l.Name,
+		Unique: l.Unique,
+		Sparse: l.Sparse,
+	}
+
+	// Text index is listed with keys _fts, _ftsx and fields in weights
+	for _, k := range l.Key {
+		switch k.Key {
+		case "_fts":
+			for _, w := range l.Weights {
+				s.Keys = append(s.Keys, bson.E{Key: w.Key, Value: IndexText})
+			}
+		case "_ftsx":
+		default:
+			s.Keys = append(s.Keys, k)
+		}
+	}
+
+	if l.PartialFilter != nil {
+		s.PartialFilter = l.PartialFilter
+	}
+
+	switch v := l.ExpireAfterSeconds.(type) {
+	case int32:
+		s.ExpireAfterSeconds = &v
+	case int64:
+		ttl := int32(v)
+		s.ExpireAfterSeconds = &ttl
+	case float64:
+		ttl := int32(v)
+		s.ExpireAfterSeconds = &ttl
+	}
+
+	if l.Collation != nil {
+		s.Collation = &options.Collation{Locale: l.Collation.Locale, Strength: l.Collation.Strength}
+	}
+
+	return s
+}
+
+// Get indexes of collection. Returns declarations and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (m *mongoDB) ListIndexes(ctx context.Context, collectionName string) ([]IndexSpec, error) {
+
+	// Check
+	if m.db == nil {
+		return nil, ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return nil, ErrEmptyCollectionsName
+	}
+
+	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := m.db.Collection(collectionName).Indexes().List(ctx)
+	if err != nil {
+		if isServerError(err, codeNamespaceNotFound) {
+			return []IndexSpec{}, nil
+		}
+		return nil, fmt.Errorf("failed to list indexes: <%w>", err)
+	}
+	defer cursor.Close(ctx)
+
+	var listed []listedIndex
+	if err := cursor.All(ctx, &listed); err != nil {
+		return nil, fmt.Errorf("failed to decode indexes: <%w>", err)
+	}
+
+	specs := make([]IndexSpec, 0, len(listed))
+	for _, l := range listed {
+		specs = append(specs, l.spec())
+	}
+
+	return specs, nil
+}
+
+// Drop index of collection by name. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of index
+func (m *mongoDB) DropIndex(ctx context.Context, collectionName, name string) error {
+
+	// Check
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return ErrEmptyIndexName
+	}
+	if name == idIndexName {
+		return ErrNotCorrectIndex
+	}
+
+	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	_, err := m.db.Collection(collectionName).Indexes().DropOne(ctx, name)
+	if err != nil {
+		if isServerError(err, codeIndexNotFound) || isServerError(err, codeNamespaceNotFound) {
+			return fmt.Errorf("failed to drop index: <%w>", ErrIndexNotFound)
+		}
+		return fmt.Errorf("failed to drop index: <%w>", err)
+	}
+
+	m.indexed.Delete(indexedKey(collectionName, name))
+
+	return nil
+}
+
+// Synchronize indexes of collection with declarations. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	specs - declarations of indexes
+//	opts - options
+func (m *mongoDB) SyncIndexes(ctx context.Context, collectionName string, specs []IndexSpec, opts IndexSyncOptions) (IndexSyncReport, error) {
+
+	// Check
+	if m.db == nil {
+		return IndexSyncReport{}, ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return IndexSyncReport{}, ErrEmptyCollectionsName
+	}
+	if err := checkIndexSpecs(specs); err != nil {
+		return IndexSyncReport{}, err
+	}
+
+	// Logic
+	existing, err := m.ListIndexes(ctx, collectionName)
+	if err != nil {
+		return IndexSyncReport{}, err
+	}
+
+	report, create, drop := DiffIndexes(existing, specs, opts.DropUnknown)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	for _, name := range drop {
+		if err := m.DropIndex(ctx, collectionName, name); err != nil && !errors.Is(err, ErrIndexNotFound) {
+			return report, err
+		}
+	}
+	if len(create) > 0 {
+		if err := m.EnsureIndexes(ctx, collectionName, create); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// Compare existing indexes with declarations. Returns report, indexes to create and names of indexes to drop.
+//
+// Params:
+//
+//	existing - existing indexes
+//	specs - declarations of indexes
+//	dropUnknown - drop indexes, which are not declared
+func DiffIndexes(existing, specs []IndexSpec, dropUnknown bool) (report IndexSyncReport, create []IndexSpec, drop []string) {
+
+	current := map[string]IndexSpec{}
+	for _, e := range existing {
+		current[e.IndexName()] = e
+	}
+
+	declared := map[string]bool{}
+	for _, s := range specs {
+
+		name := s.IndexName()
+		declared[name] = true
+
+		e, ok := current[name]
+		switch {
+		case !ok:
+			report.Created = append(report.Created, name)
+			create = append(create, s)
+		case s.Equal(e):
+			report.Unchanged = append(report.Unchanged, name)
+		default:
+			report.Recreated = append(report.Recreated, name)
+			drop = append(drop, name)
+			create = append(create, s)
+		}
+	}
+
+	for _, e := range existing {
+
+		name := e.IndexName()
+		if declared[name] || name == idIndexName {
+			continue
+		}
+
+		if dropUnknown {
+			report.Dropped = append(report.Dropped, name)
+			drop = append(drop, name)
+		} else {
+			report.Unknown = append(report.Unknown, name)
+		}
+	}
+
+	return report, create, drop
+}
+
+// Check code of server error. Returns flag of match.
+//
+// Params:
+//
+//	err - error
+//	code - code of server error
+func isServerError(err error, code int) bool {
+
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		return se.HasErrorCode(code)
+	}
+
+	return false
+}
@@ -0,0 +1,328 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Settings of iteration over cursor.
+type IterOptions struct {
+	// Count of documents in one batch of cursor. 0 - default of server
+	BatchSize int32
+	// Maximum time of execution of query on server. 0 - without limit
+	MaxTime time.Duration
+	// Server does not close idle cursor after its timeout, only for documents of collection.
+	// Cursor is closed by iteration anyway
+	NoCursorTimeout bool
+}
+
+// Check of settings. Returns error.
+func (o IterOptions) Check() error {
+
+	if o.BatchSize < 0 || o.MaxTime < 0 {
+		return ErrValueIterOptions
+	}
+
+	return nil
+}
+
+// Apply settings to options of search.
+//
+// Params:
+//
+//	opts - options of search
+func (o IterOptions) applyFind(opts *options.FindOptions) {
+
+	if o.BatchSize > 0 {
+		opts.SetBatchSize(o.BatchSize)
+	}
+	if o.MaxTime > 0 {
+		opts.SetMaxTime(o.MaxTime)
+	}
+	if o.NoCursorTimeout {
+		opts.SetNoCursorTimeout(true)
+	}
+}
+
+// Options of aggregation by settings. Returns options.
+func (o IterOptions) aggregateOptions() *options.AggregateOptions {
+
+	opts := options.Aggregate()
+	if o.BatchSize > 0 {
+		opts.SetBatchSize(o.BatchSize)
+	}
+	if o.MaxTime > 0 {
+		opts.SetMaxTime(o.MaxTime)
+	}
+
+	return opts
+}
+
+// Sequence with one error. Returns sequence.
+//
+// Params:
+//
+//	err - error
+func iterError[T any](err error) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
+
+// Sequence of documents of cursor. Cursor is closed on end, break, error and cancel of context.
+// Opening and each batch are bounded by timeout of operation, the whole iteration is bounded by context only.
+// Returns sequence.
+//
+// Params:
+//
+//	ctx - context
+//	m - connection
+//	open - opening of cursor
+//	decode - decoding of current document of cursor
+func iterCursor[T any](ctx context.Context, m *mongoDB, open func(ctx context.Context) (*mongo.Cursor, error), decode func(cursor *mongo.Cursor) (T, error)) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+
+		var zero T
+
+		openCtx, cancel := m.withTimeout(ctx)
+		cursor, err := open(openCtx)
+		cancel()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer func() {
+			// Cursor is closed on server after cancel of context too
+			closeCtx, cancel := m.withTimeout(context.WithoutCancel(ctx))
+			defer cancel()
+			_ = cursor.Close(closeCtx)
+		}()
+
+		for {
+
+			// Documents of the current batch are not returned after cancel
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			// Next fetches the next batch, when the current batch is consumed
+			nextCtx, cancel := ctx, context.CancelFunc(func() {})
+			if cursor.RemainingBatchLength() == 0 {
+				nextCtx, cancel = m.withTimeout(ctx)
+			}
+			ok := cursor.Next(nextCtx)
+			cancel()
+
+			if !ok {
+				if err := cursor.Err(); err != nil {
+					if ctx.Err() != nil {
+						err = ctx.Err()
+					}
+					yield(zero, fmt.Errorf("Function Next return error: <%w>", err))
+				}
+				return
+			}
+
+			doc, err := decode(cursor)
+			if err != nil {
+				yield(zero, fmt.Errorf("Function Decode return error: <%w>", err))
+				return
+			}
+
+			if !yield(doc, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate documents by filter. Softly deleted documents are skipped. Each iteration executes query.
+// Returns sequence of documents, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter, nil - all documents
+//	opts - settings of iteration
+func (r *Repository[T]) Iter(ctx context.Context, collectionName string, filter interface{}, opts IterOptions) iter.Seq2[T, error] {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return iterError[T](err)
+	}
+	if err := opts.Check(); err != nil {
+		return iterError[T](err)
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	findOptions := options.Find()
+	opts.applyFind(findOptions)
+
+	open := func(ctx context.Context) (*mongo.Cursor, error) {
+		cursor, err := collection.Find(ctx, r.liveFilter(filter), findOptions)
+		if err != nil {
+			return nil, fmt.Errorf("Function Find return error: <%w>", err)
+		}
+		return cursor, nil
+	}
+
+	return iterCursor(ctx, r.m, open, func(cursor *mongo.Cursor) (T, error) {
+		var doc T
+		err := cursor.Decode(&doc)
+		return doc, err
+	})
+}
+
+// Iterate documents user by query with sorting, projection, skip and limit. Softly deleted documents are skipped.
+// Each iteration executes query. Returns sequence of documents, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query, nil - all documents
+//	opts - settings of iteration
+func (m *mongoDB) IterDocumentsUser(ctx context.Context, collectionName string, q *UserQuery, opts IterOptions) iter.Seq2[DocUser, error] {
+
+	// Check
+	if m.db == nil {
+		return iterError[DocUser](ErrNilPtrDB)
+	}
+	if m.connect == nil {
+		return iterError[DocUser](ErrNilPtrConnect)
+	}
+	if collectionName == "" {
+		return iterError[DocUser](ErrEmptyCollectionsName)
+	}
+	spec, err := q.Spec()
+	if err != nil {
+		return iterError[DocUser](err)
+	}
+	if err := opts.Check(); err != nil {
+		return iterError[DocUser](err)
+	}
+
+	// Logic
+	collection := m.db.Collection(collectionName)
+
+	findOptions := spec.findOptions()
+	if spec.Limit > 0 {
+		// Without next page
+		findOptions.SetLimit(spec.Limit)
+	}
+	opts.applyFind(findOptions)
+
+	filter := m.users().liveFilter(spec.filter())
+
+	open := func(ctx context.Context) (*mongo.Cursor, error) {
+		cursor, err := collection.Find(ctx, filter, findOptions)
+		if err != nil {
+			return nil, fmt.Errorf("Function Find return error: <%w>", err)
+		}
+		return cursor, nil
+	}
+
+	return iterCursor(ctx, m, open, func(cursor *mongo.Cursor) (DocUser, error) {
+		var doc DocUser
+		if err := cursor.Decode(&doc); err != nil {
+			return DocUser{}, err
+		}
+		return spec.ApplyProjection(doc), nil
+	})
+}
+
+// Iterate results of pipeline of aggregation on collection. Each iteration executes pipeline.
+// Returns sequence of documents, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+//	opts - settings of iteration
+func (m *mongoDB) IterAggregate(ctx context.Context, collectionName string, p *Pipeline, opts IterOptions) iter.Seq2[bson.Raw, error] {
+
+	// Check
+	if m.db == nil {
+		return iterError[bson.Raw](ErrNilPtrDB)
+	}
+	if m.connect == nil {
+		return iterError[bson.Raw](ErrNilPtrConnect)
+	}
+	if collectionName == "" {
+		return iterError[bson.Raw](ErrEmptyCollectionsName)
+	}
+	stages, err := p.Stages()
+	if err != nil {
+		return iterError[bson.Raw](err)
+	}
+	if err := opts.Check(); err != nil {
+		return iterError[bson.Raw](err)
+	}
+
+	// Logic
+	collection := m.db.Collection(collectionName)
+
+	open := func(ctx context.Context) (*mongo.Cursor, error) {
+		cursor, err := collection.Aggregate(ctx, stages, opts.aggregateOptions())
+		if err != nil {
+			return nil, fmt.Errorf("Function Aggregate return error: <%w>", err)
+		}
+		return cursor, nil
+	}
+
+	return iterCursor(ctx, m, open, func(cursor *mongo.Cursor) (bson.Raw, error) {
+		// Current document is reused by cursor
+		return append(bson.Raw(nil), cursor.Current...), nil
+	})
+}
+
+// Iterate results of pipeline of aggregation on collection, decoded to type R.
+// Returns sequence of results, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+//	opts - settings of iteration
+func IterAggregateAs[R any](ctx context.Context, db MongoDBI, collectionName string, p *Pipeline, opts IterOptions) iter.Seq2[R, error] {
+
+	if db == nil {
+		return iterError[R](ErrNilPtrDB)
+	}
+
+	return func(yield func(R, error) bool) {
+
+		for raw, err := range db.IterAggregate(ctx, collectionName, p, opts) {
+
+			var result R
+			if err == nil {
+				if err = bson.Unmarshal(raw, &result); err != nil {
+					err = fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+				}
+			}
+
+			if !yield(result, err) || err != nil {
+				return
+			}
+		}
+	}
+}
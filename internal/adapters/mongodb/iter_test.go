@@ -0,0 +1,76 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Test IterOptions.
+func TestIterOptions(t *testing.T) {
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		require.Equalf(t, ErrValueIterOptions, IterOptions{BatchSize: -1}.Check(), "Error is not equal")
+		require.Equalf(t, ErrValueIterOptions, IterOptions{MaxTime: -time.Second}.Check(), "Error is not equal")
+		require.NoErrorf(t, IterOptions{}.Check(), "Unexpected error")
+	})
+
+	t.Run("Options of search", func(t *testing.T) {
+
+		opts := options.Find()
+		IterOptions{}.applyFind(opts)
+		assert.Nilf(t, opts.BatchSize, "Batch size is set")
+		assert.Nilf(t, opts.MaxTime, "Max time is set")
+		assert.Nilf(t, opts.NoCursorTimeout, "No cursor timeout is set")
+
+		IterOptions{BatchSize: 50, MaxTime: time.Second, NoCursorTimeout: true}.applyFind(opts)
+		require.NotNilf(t, opts.BatchSize, "Batch size is not set")
+		assert.Equalf(t, int32(50), *opts.BatchSize, "Batch size is not equal")
+		require.NotNilf(t, opts.MaxTime, "Max time is not set")
+		assert.Equalf(t, time.Second, *opts.MaxTime, "Max time is not equal")
+		require.NotNilf(t, opts.NoCursorTimeout, "No cursor timeout is not set")
+		assert.Truef(t, *opts.NoCursorTimeout, "No cursor timeout is not equal")
+	})
+
+	t.Run("Options of aggregation", func(t *testing.T) {
+
+		opts := IterOptions{BatchSize: 10, MaxTime: time.Minute}.aggregateOptions()
+		require.NotNilf(t, opts.BatchSize, "Batch size is not set")
+		assert.Equalf(t, int32(10), *opts.BatchSize, "Batch size is not equal")
+		require.NotNilf(t, opts.MaxTime, "Max time is not set")
+		assert.Equalf(t, time.Minute, *opts.MaxTime, "Max time is not equal")
+	})
+}
+
+// Test iteration without connection.
+func TestIterChecks(t *testing.T) {
+
+	m := &mongoDB{}
+	ctx := context.Background()
+
+	count := 0
+	for doc, err := range m.IterDocumentsUser(ctx, "users", nil, IterOptions{}) {
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+		assert.Equalf(t, DocUser{}, doc, "Document is not empty")
+		count++
+	}
+	assert.Equalf(t, 1, count, "Count is not equal")
+
+	for _, err := range m.IterAggregate(ctx, "users", nil, IterOptions{}) {
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+	}
+
+	for _, err := range IterAggregateAs[bson.M](ctx, nil, "users", nil, IterOptions{}) {
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+	}
+
+	for _, err := range IterAggregateAs[bson.M](ctx, m, "users", nil, IterOptions{}) {
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+	}
+}
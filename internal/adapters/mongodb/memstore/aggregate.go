@@ -0,0 +1,1301 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Run pipeline of aggregation on collection and decode results to slice, as mongodb.Aggregate.
+// Only stages and operators of pipelines of adapter are supported: $match, $project, $group, $sort, $bucket,
+// accumulator $sum and expressions $toLower, $split, $arrayElemAt. Other ones return
+// mongodb.ErrNotSupportedOperator. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+//	results - pointer to slice of results
+func (s *memStore) Aggregate(ctx context.Context, collectionName string, p *mongodb.Pipeline, results interface{}) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	v := reflect.ValueOf(results)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return mongodb.ErrNotCorrectResults
+	}
+	pipeline, err := p.Stages()
+	if err != nil {
+		return err
+	}
+
+	stages := make([]bson.D, 0, len(pipeline))
+	for _, stage := range pipeline {
+		d, err := normalizeStage(stage)
+		if err != nil {
+			return err
+		}
+		stages = append(stages, d)
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Function Aggregate return error: <%w>", err)
+	}
+
+	docs, err := runPipeline(s.source(collectionName), stages)
+	if err != nil {
+		return fmt.Errorf("Function Aggregate return error: <%w>", err)
+	}
+
+	// Decode
+	data, err := bson.Marshal(bson.M{"results": docs})
+	if err != nil {
+		return fmt.Errorf("Function Marshal, returned error: <%w>", err)
+	}
+
+	v.Elem().Set(reflect.MakeSlice(v.Elem().Type(), 0, len(docs)))
+	if err := bson.Raw(data).Lookup("results").Unmarshal(results); err != nil {
+		return fmt.Errorf("Function All return error: <%w>", err)
+	}
+
+	return nil
+}
+
+// Count documents user by buckets of ages, as mongodb.UserStatsByAgeBucket. Returns buckets and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	boundaries - ascending boundaries of buckets, bucket is [boundaries[i], boundaries[i+1])
+func (s *memStore) UserStatsByAgeBucket(ctx context.Context, collectionName string, boundaries []int) ([]mongodb.AgeBucket, error) {
+
+	// Check
+	if collectionName == "" {
+		return nil, mongodb.ErrEmptyCollectionsName
+	}
+	if len(boundaries) < 2 {
+		return nil, mongodb.ErrValueBoundaries
+	}
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i] <= boundaries[i-1] {
+			return nil, mongodb.ErrValueBoundaries
+		}
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, fmt.Errorf("Function Aggregate return error: <%w>", err)
+	}
+
+	buckets := make([]mongodb.AgeBucket, 0, len(boundaries)-1)
+	for i := 0; i+1 < len(boundaries); i++ {
+		buckets = append(buckets, mongodb.AgeBucket{Min: boundaries[i], Max: boundaries[i+1]})
+	}
+
+	for _, r := range s.collections[collectionName] {
+		if s.isDeleted(r) {
+			continue
+		}
+		for i := range buckets {
+			if r.doc.Age >= buckets[i].Min && r.doc.Age < buckets[i].Max {
+				buckets[i].Count++
+				break
+			}
+		}
+	}
+
+	return buckets, nil
+}
+
+// Count documents user by domains of email, as mongodb.UserStatsByEmailDomain. Returns counts and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (s *memStore) UserStatsByEmailDomain(ctx context.Context, collectionName string) ([]mongodb.DomainCount, error) {
+
+	// Check
+	if collectionName == "" {
+		return nil, mongodb.ErrEmptyCollectionsName
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, fmt.Errorf("Function Aggregate return error: <%w>", err)
+	}
+
+	counts := map[string]int64{}
+	for _, r := range s.collections[collectionName] {
+		i := strings.LastIndex(r.doc.Email, "@")
+		if i < 0 || s.isDeleted(r) {
+			continue
+		}
+		counts[strings.ToLower(r.doc.Email[i+1:])]++
+	}
+
+	domains := make([]mongodb.DomainCount, 0, len(counts))
+	for domain, n := range counts {
+		domains = append(domains, mongodb.DomainCount{Domain: domain, Count: n})
+	}
+	sort.Slice(domains, func(i, j int) bool {
+		if domains[i].Count != domains[j].Count {
+			return domains[i].Count > domains[j].Count
+		}
+		return domains[i].Domain < domains[j].Domain
+	})
+
+	return domains, nil
+}
+
+// Stage with values, as they are decoded from BSON. Returns stage and error.
+//
+// Params:
+//
+//	stage - stage of pipeline
+func normalizeStage(stage bson.D) (bson.D, error) {
+
+	data, err := bson.Marshal(stage)
+	if err != nil {
+		return nil, fmt.Errorf("Function Marshal, returned error: <%w>", err)
+	}
+
+	var d bson.D
+	if err := bson.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+	}
+	if len(d) != 1 {
+		return nil, fmt.Errorf("%w: stage must have one operator", mongodb.ErrNotCorrectPipeline)
+	}
+
+	return d, nil
+}
+
+// Documents of collection, as they are stored. Lock must be held. Returns documents.
+//
+// Params:
+//
+//	collectionName - name of collection
+func (s *memStore) source(collectionName string) []bson.M {
+
+	docs := []bson.M{}
+
+	if collectionName == s.outboxName && s.outboxName != "" {
+		for _, ev := range s.outbox {
+			data, err := bson.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			doc := bson.M{}
+			if err := bson.Unmarshal(data, &doc); err == nil {
+				docs = append(docs, doc)
+			}
+		}
+		return docs
+	}
+
+	for _, r := range s.collections[collectionName] {
+		docs = append(docs, docFields(r.doc))
+	}
+
+	return docs
+}
+
+// Execute stages on documents. Returns documents and error.
+//
+// Params:
+//
+//	docs - input documents
+//	stages - stages of pipeline
+func runPipeline(docs []bson.M, stages []bson.D) ([]bson.M, error) {
+
+	var err error
+
+	for _, stage := range stages {
+
+		op, spec := stage[0].Key, stage[0].Value
+
+		switch op {
+		case mongodb.StageMatch:
+			docs, err = matchStage(docs, spec)
+		case mongodb.StageProject:
+			docs, err = projectStage(docs, spec)
+		case mongodb.StageGroup:
+			docs, err = groupStage(docs, spec)
+		case mongodb.StageSort:
+			docs, err = sortStage(docs, spec)
+		case mongodb.StageBucket:
+			docs, err = bucketStage(docs, spec)
+		default:
+			return nil, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// Stage $match. Returns documents and error.
+//
+// Params:
+//
+//	docs - input documents
+//	spec - filter
+func matchStage(docs []bson.M, spec interface{}) ([]bson.M, error) {
+
+	out := []bson.M{}
+	for _, doc := range docs {
+		ok, err := matchFilter(doc, spec)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, doc)
+		}
+	}
+
+	return out, nil
+}
+
+// Stage $project. Returns documents and error.
+//
+// Params:
+//
+//	docs - input documents
+//	spec - fields of projection
+func projectStage(docs []bson.M, spec interface{}) ([]bson.M, error) {
+
+	fields, ok := entries(spec)
+	if !ok || len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, mongodb.StageProject)
+	}
+
+	// Exclusion, if all fields are excluded
+	exclusion := true
+	keepID := true
+	for _, f := range fields {
+		if isFlag(f.Value) && !truthy(f.Value) {
+			if f.Key == "_id" {
+				keepID = false
+			}
+			continue
+		}
+		exclusion = false
+	}
+
+	out := make([]bson.M, 0, len(docs))
+	for _, doc := range docs {
+
+		if exclusion {
+			d := copyDoc(doc)
+			for _, f := range fields {
+				delete(d, f.Key)
+			}
+			out = append(out, d)
+			continue
+		}
+
+		d := bson.M{}
+		if id, ok := doc["_id"]; ok && keepID {
+			d["_id"] = id
+		}
+		for _, f := range fields {
+			if isFlag(f.Value) {
+				if truthy(f.Value) {
+					if v, ok := lookup(doc, f.Key); ok {
+						d[f.Key] = v
+					}
+				}
+				continue
+			}
+			v, err := evalExpr(doc, f.Value)
+			if err != nil {
+				return nil, err
+			}
+			d[f.Key] = v
+		}
+		out = append(out, d)
+	}
+
+	return out, nil
+}
+
+// Stage $group. Returns groups in order of the first documents and error.
+//
+// Params:
+//
+//	docs - input documents
+//	spec - id and accumulators
+func groupStage(docs []bson.M, spec interface{}) ([]bson.M, error) {
+
+	fields, ok := entries(spec)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, mongodb.StageGroup)
+	}
+
+	var id interface{}
+	hasID := false
+	accs := bson.D{}
+	for _, f := range fields {
+		if f.Key == "_id" {
+			id, hasID = f.Value, true
+			continue
+		}
+		accs = append(accs, f)
+	}
+	if !hasID {
+		return nil, fmt.Errorf("%w: %s without _id", mongodb.ErrNotCorrectPipeline, mongodb.StageGroup)
+	}
+
+	return accumulate(docs, accs, func(doc bson.M) (interface{}, bool, error) {
+		v, err := evalExpr(doc, id)
+		return v, true, err
+	}, nil)
+}
+
+// Stage $bucket. Returns buckets in order of boundaries, default bucket is the last, and error.
+//
+// Params:
+//
+//	docs - input documents
+//	spec - settings of bucket
+func bucketStage(docs []bson.M, spec interface{}) ([]bson.M, error) {
+
+	fields, ok := entries(spec)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, mongodb.StageBucket)
+	}
+
+	var groupBy, def interface{}
+	var boundaries []interface{}
+	hasDefault := false
+	output := bson.D{{Key: "count", Value: bson.D{{Key: "$sum", Value: int32(1)}}}}
+
+	for _, f := range fields {
+		switch f.Key {
+		case "groupBy":
+			groupBy = f.Value
+		case "boundaries":
+			boundaries, _ = asArray(f.Value)
+		case "default":
+			def, hasDefault = f.Value, true
+		case "output":
+			if output, ok = entries(f.Value); !ok {
+				return nil, fmt.Errorf("%w: output of %s", mongodb.ErrNotCorrectPipeline, mongodb.StageBucket)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %s.%s", mongodb.ErrNotSupportedOperator, mongodb.StageBucket, f.Key)
+		}
+	}
+
+	if groupBy == nil || len(boundaries) < 2 {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, mongodb.StageBucket)
+	}
+	for i := 1; i < len(boundaries); i++ {
+		if typeRank(boundaries[i]) != typeRank(boundaries[0]) || compareValues(boundaries[i-1], boundaries[i]) >= 0 {
+			return nil, fmt.Errorf("%w: boundaries of %s are not ascending", mongodb.ErrNotCorrectPipeline, mongodb.StageBucket)
+		}
+	}
+
+	// Keys of buckets in order of boundaries
+	order := make([]interface{}, 0, len(boundaries))
+	order = append(order, boundaries[:len(boundaries)-1]...)
+	if hasDefault {
+		order = append(order, def)
+	}
+
+	return accumulate(docs, output, func(doc bson.M) (interface{}, bool, error) {
+
+		v, err := evalExpr(doc, groupBy)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if typeRank(v) == typeRank(boundaries[0]) {
+			for i := 0; i+1 < len(boundaries); i++ {
+				if compareValues(v, boundaries[i]) >= 0 && compareValues(v, boundaries[i+1]) < 0 {
+					return boundaries[i], true, nil
+				}
+			}
+		}
+		if !hasDefault {
+			return nil, false, fmt.Errorf("%w: value out of boundaries of %s without default", mongodb.ErrNotCorrectPipeline, mongodb.StageBucket)
+		}
+
+		return def, true, nil
+	}, order)
+}
+
+// Group documents by key and calculate accumulators. Returns groups and error.
+//
+// Params:
+//
+//	docs - input documents
+//	accs - accumulators by names of output fields
+//	key - key of group of document, false - document is skipped
+//	order - order of keys of groups, nil - order of the first documents
+func accumulate(docs []bson.M, accs bson.D, key func(doc bson.M) (interface{}, bool, error), order []interface{}) ([]bson.M, error) {
+
+	type group struct {
+		id     interface{}
+		values [][]interface{}
+	}
+
+	for _, acc := range accs {
+		op, ok := entries(acc.Value)
+		if !ok || len(op) != 1 {
+			return nil, fmt.Errorf("%w: accumulator of field <%s>", mongodb.ErrNotCorrectPipeline, acc.Key)
+		}
+		if op[0].Key != "$sum" {
+			return nil, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, op[0].Key)
+		}
+	}
+
+	groups := map[string]*group{}
+	keys := []string{}
+
+	for _, doc := range docs {
+
+		id, ok, err := key(doc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		k := valueKey(id)
+		g, ok := groups[k]
+		if !ok {
+			g = &group{id: id, values: make([][]interface{}, len(accs))}
+			groups[k] = g
+			keys = append(keys, k)
+		}
+
+		for i, acc := range accs {
+			op, ok := entries(acc.Value)
+			if !ok || len(op) != 1 {
+				return nil, fmt.Errorf("%w: accumulator of field <%s>", mongodb.ErrNotCorrectPipeline, acc.Key)
+			}
+			v, err := evalExpr(doc, op[0].Value)
+			if err != nil {
+				return nil, err
+			}
+			g.values[i] = append(g.values[i], v)
+		}
+	}
+
+	if order != nil {
+		keys = keys[:0]
+		for _, id := range order {
+			if _, ok := groups[valueKey(id)]; ok {
+				keys = append(keys, valueKey(id))
+			}
+		}
+	}
+
+	out := make([]bson.M, 0, len(keys))
+	for _, k := range keys {
+
+		g := groups[k]
+		d := bson.M{"_id": g.id}
+
+		for i, acc := range accs {
+			op, _ := entries(acc.Value)
+			v, err := reduce(op[0].Key, g.values[i])
+			if err != nil {
+				return nil, err
+			}
+			d[acc.Key] = v
+		}
+		out = append(out, d)
+	}
+
+	return out, nil
+}
+
+// Value of accumulator by values of documents of group. Returns value and error.
+//
+// Params:
+//
+//	op - operator of accumulator
+//	values - values of expression
+func reduce(op string, values []interface{}) (interface{}, error) {
+
+	if op != "$sum" {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, op)
+	}
+
+	var sumInt int64
+	var sumFloat float64
+	isFloat, is64 := false, false
+
+	for _, v := range values {
+		switch x := v.(type) {
+		case int32:
+			sumInt += int64(x)
+		case int64:
+			sumInt += x
+			is64 = true
+		case float64:
+			sumFloat += x
+			isFloat = true
+		}
+	}
+
+	if isFloat {
+		return float64(sumInt) + sumFloat, nil
+	}
+	if is64 || sumInt > math.MaxInt32 || sumInt < math.MinInt32 {
+		return sumInt, nil
+	}
+
+	return int32(sumInt), nil
+}
+
+// Stage $sort. Returns documents and error.
+//
+// Params:
+//
+//	docs - input documents
+//	spec - fields of sorting
+func sortStage(docs []bson.M, spec interface{}) ([]bson.M, error) {
+
+	fields, ok := entries(spec)
+	if !ok || len(fields) == 0 {
+		return nil, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, mongodb.StageSort)
+	}
+
+	dirs := make([]int, len(fields))
+	for i, f := range fields {
+		n, ok := toInt(f.Value)
+		if !ok || (n != 1 && n != -1) {
+			return nil, fmt.Errorf("%w: direction of sort of field <%s>", mongodb.ErrNotCorrectPipeline, f.Key)
+		}
+		dirs[i] = n
+	}
+
+	out := make([]bson.M, len(docs))
+	copy(out, docs)
+
+	sort.SliceStable(out, func(i, j int) bool {
+		for k, f := range fields {
+			a, _ := lookup(out[i], f.Key)
+			b, _ := lookup(out[j], f.Key)
+			if c := compareValues(a, b) * dirs[k]; c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	return out, nil
+}
+
+// Check document by filter of query. Returns flag of match and error.
+//
+// Params:
+//
+//	doc - document
+//	filter - filter
+func matchFilter(doc bson.M, filter interface{}) (bool, error) {
+
+	conds, ok := entries(filter)
+	if !ok {
+		return false, fmt.Errorf("%w: filter of %s", mongodb.ErrNotCorrectPipeline, mongodb.StageMatch)
+	}
+
+	for _, c := range conds {
+
+		switch c.Key {
+		case "$and", "$or":
+
+			subs, ok := asArray(c.Value)
+			if !ok || len(subs) == 0 {
+				return false, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, c.Key)
+			}
+
+			some, all := false, true
+			for _, sub := range subs {
+				ok, err := matchFilter(doc, sub)
+				if err != nil {
+					return false, err
+				}
+				some = some || ok
+				all = all && ok
+			}
+
+			if (c.Key == "$and" && !all) || (c.Key == "$or" && !some) {
+				return false, nil
+			}
+
+		default:
+
+			if strings.HasPrefix(c.Key, "$") {
+				return false, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, c.Key)
+			}
+
+			v, exists := lookup(doc, c.Key)
+			ok, err := matchCondition(v, exists, c.Value)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// Check value of field by condition. Returns flag of match and error.
+//
+// Params:
+//
+//	v - value of field
+//	exists - field exists
+//	cond - value or document of operators
+func matchCondition(v interface{}, exists bool, cond interface{}) (bool, error) {
+
+	if re, ok := cond.(primitive.Regex); ok {
+		return matchRegex(v, re.Pattern, re.Options)
+	}
+
+	ops, ok := entries(cond)
+	if !ok || len(ops) == 0 || !strings.HasPrefix(ops[0].Key, "$") {
+		return matchValue(v, cond), nil
+	}
+
+	for _, op := range ops {
+
+		ok := false
+		var err error
+
+		switch op.Key {
+		case "$eq":
+			ok = matchValue(v, op.Value)
+		case "$ne":
+			ok = !matchValue(v, op.Value)
+		case "$gt", "$gte", "$lt", "$lte":
+			ok = matchCompare(v, op.Key, op.Value)
+		case "$in":
+			arr, isArr := asArray(op.Value)
+			if !isArr {
+				return false, fmt.Errorf("%w: %s needs array", mongodb.ErrNotCorrectPipeline, op.Key)
+			}
+			for _, e := range arr {
+				if matchValue(v, e) {
+					ok = true
+					break
+				}
+			}
+		case "$exists":
+			ok = exists == truthy(op.Value)
+		case "$regex":
+			re, isRegex := op.Value.(primitive.Regex)
+			if !isRegex {
+				return false, fmt.Errorf("%w: %s needs regular expression", mongodb.ErrNotCorrectPipeline, op.Key)
+			}
+			ok, err = matchRegex(v, re.Pattern, re.Options)
+		default:
+			return false, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, op.Key)
+		}
+
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// Check, that value of field is equal value. Array field matches, if one of elements is equal.
+// Missing field is equal null. Returns flag.
+//
+// Params:
+//
+//	v - value of field
+//	value - value of condition
+func matchValue(v, value interface{}) bool {
+
+	if compareValues(v, value) == 0 && typeRank(v) == typeRank(value) {
+		return true
+	}
+	if arr, ok := asArray(v); ok {
+		for _, e := range arr {
+			if compareValues(e, value) == 0 && typeRank(e) == typeRank(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Compare value of field with value of the same type. Array field matches, if one of elements matches.
+// Returns flag of match.
+//
+// Params:
+//
+//	v - value of field
+//	op - operator of comparison
+//	value - value of condition
+func matchCompare(v interface{}, op string, value interface{}) bool {
+
+	values := []interface{}{v}
+	if arr, ok := asArray(v); ok {
+		values = arr
+	}
+
+	for _, e := range values {
+
+		if typeRank(e) != typeRank(value) {
+			continue
+		}
+
+		c := compareValues(e, value)
+		if (op == "$gt" && c > 0) || (op == "$gte" && c >= 0) || (op == "$lt" && c < 0) || (op == "$lte" && c <= 0) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check string value of field by regular expression. Returns flag of match and error.
+//
+// Params:
+//
+//	v - value of field
+//	pattern - regular expression
+//	options - options of regular expression: i, m, s
+func matchRegex(v interface{}, pattern, options string) (bool, error) {
+
+	flags := ""
+	for _, o := range options {
+		if strings.ContainsRune("ims", o) && !strings.ContainsRune(flags, o) {
+			flags += string(o)
+		}
+	}
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", mongodb.ErrNotCorrectPipeline, err.Error())
+	}
+
+	values := []interface{}{v}
+	if arr, ok := asArray(v); ok {
+		values = arr
+	}
+	for _, e := range values {
+		if s, ok := e.(string); ok && re.MatchString(s) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Value of expression for document. Returns value and error.
+//
+// Params:
+//
+//	doc - document
+//	expr - expression: path of field with $, operator, document or literal
+func evalExpr(doc bson.M, expr interface{}) (interface{}, error) {
+
+	if s, ok := expr.(string); ok {
+		if strings.HasPrefix(s, "$$") {
+			return nil, fmt.Errorf("%w: variable %s", mongodb.ErrNotSupportedOperator, s)
+		}
+		if strings.HasPrefix(s, "$") {
+			v, _ := lookup(doc, s[1:])
+			return v, nil
+		}
+		return s, nil
+	}
+
+	if arr, ok := asArray(expr); ok {
+		out := make(bson.A, 0, len(arr))
+		for _, e := range arr {
+			v, err := evalExpr(doc, e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+
+	fields, ok := entries(expr)
+	if !ok {
+		return expr, nil
+	}
+
+	if len(fields) == 1 && strings.HasPrefix(fields[0].Key, "$") {
+		op := fields[0].Key
+
+		v, err := evalExpr(doc, fields[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		return evalOperator(op, v)
+	}
+
+	out := bson.M{}
+	for _, f := range fields {
+		v, err := evalExpr(doc, f.Value)
+		if err != nil {
+			return nil, err
+		}
+		out[f.Key] = v
+	}
+
+	return out, nil
+}
+
+// Value of operator of expression. Returns value and error.
+//
+// Params:
+//
+//	op - operator
+//	arg - value of argument
+func evalOperator(op string, arg interface{}) (interface{}, error) {
+
+	args, _ := asArray(arg)
+
+	switch op {
+	case "$toLower":
+
+		if len(args) == 1 {
+			arg = args[0]
+		}
+		s, ok := arg.(string)
+		if !ok && arg != nil {
+			s = fmt.Sprint(arg)
+		}
+		return strings.ToLower(s), nil
+
+	case "$split":
+
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: %s needs two arguments", mongodb.ErrNotCorrectPipeline, op)
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		s, ok1 := args[0].(string)
+		sep, ok2 := args[1].(string)
+		if !ok1 || !ok2 || sep == "" {
+			return nil, fmt.Errorf("%w: %s needs strings", mongodb.ErrNotCorrectPipeline, op)
+		}
+		out := bson.A{}
+		for _, part := range strings.Split(s, sep) {
+			out = append(out, part)
+		}
+		return out, nil
+
+	case "$arrayElemAt":
+
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: %s needs two arguments", mongodb.ErrNotCorrectPipeline, op)
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		arr, ok1 := asArray(args[0])
+		i, ok2 := toInt(args[1])
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("%w: %s needs array and index", mongodb.ErrNotCorrectPipeline, op)
+		}
+		if i < 0 {
+			i += len(arr)
+		}
+		if i < 0 || i >= len(arr) {
+			return nil, nil
+		}
+		return arr[i], nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", mongodb.ErrNotSupportedOperator, op)
+}
+
+// Value of field by path with dots. Returns value and flag of existence.
+//
+// Params:
+//
+//	doc - document
+//	path - path of field
+func lookup(doc bson.M, path string) (interface{}, bool) {
+
+	var cur interface{} = doc
+
+	for _, part := range strings.Split(path, ".") {
+
+		if arr, ok := asArray(cur); ok {
+			// Values of field of documents of array
+			values := bson.A{}
+			for _, e := range arr {
+				if d, ok := asDoc(e); ok {
+					if v, ok := d[part]; ok {
+						values = append(values, v)
+					}
+				}
+			}
+			cur = values
+			continue
+		}
+
+		d, ok := asDoc(cur)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = d[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// Deep copy of document. Returns document.
+//
+// Params:
+//
+//	doc - document
+func copyDoc(doc bson.M) bson.M {
+
+	out := make(bson.M, len(doc))
+	for k, v := range doc {
+		out[k] = copyValue(v)
+	}
+
+	return out
+}
+
+// Deep copy of value. Returns value.
+//
+// Params:
+//
+//	v - value
+func copyValue(v interface{}) interface{} {
+
+	if d, ok := asDoc(v); ok {
+		return copyDoc(d)
+	}
+	if arr, ok := asArray(v); ok {
+		out := make(bson.A, len(arr))
+		for i, e := range arr {
+			out[i] = copyValue(e)
+		}
+		return out
+	}
+
+	return v
+}
+
+// Fields of document in order: document D in own order, document M in order of names.
+// Returns fields and flag, that value is document.
+//
+// Params:
+//
+//	v - value
+func entries(v interface{}) (bson.D, bool) {
+
+	switch d := v.(type) {
+	case bson.D:
+		return d, true
+	case bson.M:
+		keys := make([]string, 0, len(d))
+		for k := range d {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make(bson.D, 0, len(d))
+		for _, k := range keys {
+			out = append(out, bson.E{Key: k, Value: d[k]})
+		}
+		return out, true
+	}
+
+	return nil, false
+}
+
+// Document as map. Returns document and flag, that value is document.
+//
+// Params:
+//
+//	v - value
+func asDoc(v interface{}) (bson.M, bool) {
+
+	switch d := v.(type) {
+	case bson.M:
+		return d, true
+	case bson.D:
+		return d.Map(), true
+	}
+
+	return nil, false
+}
+
+// Array as slice. Returns slice and flag, that value is array.
+//
+// Params:
+//
+//	v - value
+func asArray(v interface{}) ([]interface{}, bool) {
+
+	switch a := v.(type) {
+	case bson.A:
+		return a, true
+	case []interface{}:
+		return a, true
+	}
+
+	return nil, false
+}
+
+// Integer value of number. Returns value and flag, that value is integer.
+//
+// Params:
+//
+//	v - value
+func toInt(v interface{}) (int, bool) {
+
+	switch n := v.(type) {
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case int:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) {
+			return int(n), true
+		}
+	}
+
+	return 0, false
+}
+
+// Float value of number. Returns value.
+//
+// Params:
+//
+//	v - number
+func toFloat(v interface{}) float64 {
+
+	switch n := v.(type) {
+	case int32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int:
+		return float64(n)
+	case float64:
+		return n
+	}
+
+	return 0
+}
+
+// Check, that value of projection is flag of inclusion or exclusion. Returns flag.
+//
+// Params:
+//
+//	v - value
+func isFlag(v interface{}) bool {
+
+	switch v.(type) {
+	case bool, int32, int64, int, float64:
+		return true
+	}
+
+	return false
+}
+
+// Truth of value. Returns flag.
+//
+// Params:
+//
+//	v - value
+func truthy(v interface{}) bool {
+
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case int32, int64, int, float64:
+		return toFloat(x) != 0
+	}
+
+	return true
+}
+
+// Rank of type in order of comparison of MongoDB. Returns rank.
+//
+// Params:
+//
+//	v - value
+func typeRank(v interface{}) int {
+
+	switch v.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int32, int64, int, float64:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.M, bson.D:
+		return 4
+	case bson.A, []interface{}:
+		return 5
+	case primitive.Binary:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	}
+
+	return 12
+}
+
+// Compare values in order of MongoDB. Returns -1, 0 or 1.
+//
+// Params:
+//
+//	a - first value
+//	b - second value
+func compareValues(a, b interface{}) int {
+
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return compareInt(ra, rb)
+	}
+
+	switch ra {
+	case 2:
+		x, y := toFloat(a), toFloat(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	case 3:
+		return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+	case 5:
+		x, _ := asArray(a)
+		y, _ := asArray(b)
+		for i := 0; i < len(x) && i < len(y); i++ {
+			if c := compareValues(x[i], y[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(len(x), len(y))
+	case 7:
+		x, y := a.(primitive.ObjectID), b.(primitive.ObjectID)
+		return bytes.Compare(x[:], y[:])
+	case 8:
+		x, y := a.(bool), b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case 9:
+		return toTime(a).Compare(toTime(b))
+	}
+
+	return strings.Compare(valueKey(a), valueKey(b))
+}
+
+// Time of value of date. Returns time.
+//
+// Params:
+//
+//	v - value of date
+func toTime(v interface{}) time.Time {
+
+	switch t := v.(type) {
+	case primitive.DateTime:
+		return t.Time()
+	case time.Time:
+		return t
+	}
+
+	return time.Time{}
+}
+
+// Key of value, equal for equal values. Returns key.
+//
+// Params:
+//
+//	v - value
+func valueKey(v interface{}) string {
+
+	switch typeRank(v) {
+	case 1:
+		return "null"
+	case 2:
+		return fmt.Sprintf("n:%v", toFloat(v))
+	case 9:
+		return fmt.Sprintf("t:%d", toTime(v).UnixMilli())
+	}
+
+	if fields, ok := entries(v); ok {
+		parts := make([]string, 0, len(fields))
+		for _, f := range fields {
+			parts = append(parts, f.Key+"="+valueKey(f.Value))
+		}
+		return "{" + strings.Join(parts, ",") + "}"
+	}
+	if arr, ok := asArray(v); ok {
+		parts := make([]string, 0, len(arr))
+		for _, e := range arr {
+			parts = append(parts, valueKey(e))
+		}
+		return "[" + strings.Join(parts, ",") + "]"
+	}
+
+	return fmt.Sprintf("%T:%v", v, v)
+}
@@ -0,0 +1,664 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Check state of store and context. Returns error.
+//
+// Params:
+//
+//	ctx - context
+func (s *memStore) check(ctx context.Context) error {
+
+	if s.closed {
+		return mongo.ErrClientDisconnected
+	}
+	if ctx == nil {
+		return nil
+	}
+
+	return ctx.Err()
+}
+
+// Selector of documents user.
+type selector func(doc mongodb.DocUser) bool
+
+// Selector of documents by name. Returns selector.
+//
+// Params:
+//
+//	name - name
+func byName(name string) selector {
+
+	return func(doc mongodb.DocUser) bool { return doc.Name == name }
+}
+
+// Selector of document by id. Returns selector.
+//
+// Params:
+//
+//	id - id of document
+func byID(id primitive.ObjectID) selector {
+
+	return func(doc mongodb.DocUser) bool { return doc.ID == id }
+}
+
+// Selector of documents by email. Returns selector.
+//
+// Params:
+//
+//	email - normalized email
+func byEmail(email string) selector {
+
+	return func(doc mongodb.DocUser) bool { return doc.Email == email }
+}
+
+// Find index of first document user by selector. Softly deleted documents are skipped. Returns index or -1.
+//
+// Params:
+//
+//	records - documents of collection
+//	sel - selector
+func (s *memStore) indexWhere(records []record, sel selector) int {
+
+	for i, r := range records {
+
+		if sel(r.doc) && !s.isDeleted(r) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Find index of first document user by name. Softly deleted documents are skipped. Returns index or -1.
+//
+// Params:
+//
+//	records - documents of collection
+//	name - name
+func (s *memStore) indexByName(records []record, name string) int {
+
+	return s.indexWhere(records, byName(name))
+}
+
+// Record of inserted document. Missing id is generated. Returns record.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (s *memStore) newRecord(ctx context.Context, doc mongodb.DocUser) record {
+
+	id := doc.ID
+	if id.IsZero() {
+		id = primitive.NewObjectID()
+	}
+
+	doc = s.created(ctx, doc)
+	doc.ID = id
+
+	return record{id: id, doc: doc}
+}
+
+// Record of imported document. Missing id is generated, maintained fields are kept, missing ones are set
+// as on insert. Returns record.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (s *memStore) importedRecord(ctx context.Context, doc mongodb.DocUser) record {
+
+	r := s.newRecord(ctx, doc)
+
+	if s.versioning && doc.Version != 0 {
+		r.doc.Version = doc.Version
+	}
+	if s.softDelete {
+		r.doc.DeletedAt = doc.DeletedAt
+	}
+	if !doc.CreatedAt.IsZero() {
+		r.doc.CreatedAt = doc.CreatedAt
+	}
+	if !doc.UpdatedAt.IsZero() {
+		r.doc.UpdatedAt = doc.UpdatedAt
+	}
+	if doc.CreatedBy != "" {
+		r.doc.CreatedBy = doc.CreatedBy
+	}
+	if doc.UpdatedBy != "" {
+		r.doc.UpdatedBy = doc.UpdatedBy
+	}
+
+	return r
+}
+
+// Recieve first document user by selector. Lock must be held. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	sel - selector
+func (s *memStore) recv(ctx context.Context, collectionName string, sel selector) (mongodb.DocUser, error) {
+
+	if err := s.check(ctx); err != nil {
+		return mongodb.DocUser{}, fmt.Errorf("Function FindOne return error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexWhere(records, sel)
+	if i < 0 {
+		return mongodb.DocUser{}, fmt.Errorf("Function FindOne return error: <%w>", mongo.ErrNoDocuments)
+	}
+
+	return records[i].doc, nil
+}
+
+// Update first document user by selector with checked document. Lock must be held. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	sel - selector
+//	doc - normalized and checked document
+func (s *memStore) update(ctx context.Context, collectionName string, sel selector, doc mongodb.DocUser) error {
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexWhere(records, sel)
+	if i < 0 {
+		return mongodb.ErrUpdateDocument
+	}
+
+	updated := s.setFields(ctx, records[i].doc, doc)
+
+	if s.isDuplicate(collectionName, updated, i) {
+		return mongodb.ErrDocumentExists
+	}
+	s.emitUpdate(collectionName, records[i].doc, updated)
+	records[i].doc = updated
+
+	return nil
+}
+
+// Delete first document user by selector. Lock must be held. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	sel - selector
+func (s *memStore) del(ctx context.Context, collectionName string, sel selector) (int64, error) {
+
+	if err := s.check(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete document: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexWhere(records, sel)
+	if i < 0 {
+		return 0, nil
+	}
+
+	s.collections[collectionName] = s.deleteAt(ctx, collectionName, records, i)
+
+	return 1, nil
+}
+
+// Change collection for first document user by selector. Lock must be held. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	sel - selector
+func (s *memStore) move(ctx context.Context, srcCollection, destCollection string, sel selector) error {
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Fault transaction: <%v>", err)
+	}
+
+	src := s.collections[srcCollection]
+
+	i := s.indexWhere(src, sel)
+	if i < 0 {
+		return errNotFoundTx()
+	}
+	r := src[i]
+
+	doc := r.doc
+	doc.ID = r.id
+	if s.isDuplicate(destCollection, doc, -1) {
+		return fmt.Errorf("Fault transaction: <%v>", fmt.Errorf("Fault insert document: <%v>", mongodb.ErrDocumentExists))
+	}
+
+	r.doc.UpdatedAt = s.now()
+	r.doc.UpdatedBy = mongodb.ActorFromContext(ctx)
+
+	s.collections[destCollection] = append(s.collections[destCollection], r)
+	s.collections[srcCollection] = append(src[:i:i], src[i+1:]...)
+	s.emitInsert(destCollection, r.doc)
+	s.emitDelete(srcCollection, r.id)
+	s.appendOutbox(mongodb.EventUserMoved, destCollection, srcCollection, r.doc)
+
+	return nil
+}
+
+// Check, that document is softly deleted. Returns flag.
+//
+// Params:
+//
+//	r - document
+func (s *memStore) isDeleted(r record) bool {
+
+	return s.softDelete && !r.doc.DeletedAt.IsZero()
+}
+
+// Delete document softly or remove it. Returns documents of collection.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	records - documents of collection
+//	i - index of document
+func (s *memStore) deleteAt(ctx context.Context, collectionName string, records []record, i int) []record {
+
+	if s.softDelete {
+		doc := records[i].doc
+		doc.DeletedAt = s.now()
+		doc = s.updated(ctx, records[i].doc, doc)
+		s.emitUpdate(collectionName, records[i].doc, doc)
+		records[i].doc = doc
+		return records
+	}
+
+	s.emitDelete(collectionName, records[i].id)
+
+	return append(records[:i:i], records[i+1:]...)
+}
+
+// Delete all documents, which match predicate. Returns count deleted documents
+// (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	opts - options
+//	pred - predicate of document
+func (s *memStore) deleteMany(ctx context.Context, collectionName string, opts mongodb.DeleteOptions, pred func(r record) bool) (int64, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	n := int64(0)
+	for i := len(records) - 1; i >= 0; i-- {
+
+		if s.isDeleted(records[i]) || !pred(records[i]) {
+			continue
+		}
+		n++
+
+		if !opts.DryRun {
+			records = s.deleteAt(ctx, collectionName, records, i)
+		}
+	}
+
+	if n > 0 && !opts.DryRun {
+		s.collections[collectionName] = records
+	}
+
+	return n, nil
+}
+
+// Error of transaction, when document is not found.
+func errNotFoundTx() error {
+
+	return fmt.Errorf("Fault transaction: <%v>", fmt.Errorf("Document is not found: <%v>", mongo.ErrNoDocuments))
+}
+
+// Check violation of unique indexes by document. Returns flag of duplicate.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	doc - document
+//	skip - index of document to skip, -1 - none
+func (s *memStore) isDuplicate(collectionName string, doc mongodb.DocUser, skip int) bool {
+
+	// Indexes of users are created by the first write, as by mongodb
+	s.ensureIndexes(collectionName, mongodb.UserIndexSpecs(s.softDelete))
+
+	specs := s.indexes[collectionName]
+
+	for i, r := range s.collections[collectionName] {
+
+		if i == skip {
+			continue
+		}
+		if !doc.ID.IsZero() && r.id == doc.ID {
+			return true
+		}
+
+		for _, spec := range specs {
+			if spec.Unique && sameKeys(spec, r.doc, doc) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Compare keys of index in documents. Returns flag of equality.
+//
+// Params:
+//
+//	spec - declaration of index
+//	a - first document
+//	b - second document
+func sameKeys(spec mongodb.IndexSpec, a, b mongodb.DocUser) bool {
+
+	for _, k := range spec.Keys {
+
+		if fieldValue(a, k.Key) != fieldValue(b, k.Key) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Find index by name. Returns index in list or -1.
+//
+// Params:
+//
+//	specs - declarations of indexes
+//	name - name of index
+func indexOf(specs []mongodb.IndexSpec, name string) int {
+
+	for i, s := range specs {
+
+		if s.IndexName() == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Register indexes of collection, which are not exists. Creates collection.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	specs - declarations of indexes
+func (s *memStore) ensureIndexes(collectionName string, specs []mongodb.IndexSpec) {
+
+	if _, ok := s.collections[collectionName]; !ok {
+		s.collections[collectionName] = []record{}
+	}
+
+	for _, spec := range specs {
+		if indexOf(s.indexes[collectionName], spec.IndexName()) < 0 {
+			s.indexes[collectionName] = append(s.indexes[collectionName], spec)
+		}
+	}
+}
+
+// Value of document field by BSON name. Returns value or nil for unknown field.
+//
+// Params:
+//
+//	doc - document
+//	field - BSON name of field
+func fieldValue(doc mongodb.DocUser, field string) interface{} {
+
+	switch field {
+	case "name":
+		return doc.Name
+	case "age":
+		return doc.Age
+	case "email":
+		return doc.Email
+	case "version":
+		return doc.Version
+	case "deletedAt":
+		return doc.DeletedAt
+	}
+
+	return nil
+}
+
+// Set value of document field by BSON name.
+//
+// Params:
+//
+//	doc - document
+//	field - BSON name of field
+//	value - value, nil - zero value
+func setFieldValue(doc *mongodb.DocUser, field string, value interface{}) {
+
+	switch field {
+	case "name":
+		doc.Name, _ = value.(string)
+	case "age":
+		doc.Age, _ = value.(int)
+	case "email":
+		doc.Email, _ = value.(string)
+	case "version":
+		doc.Version, _ = value.(int64)
+	}
+}
+
+// Apply checked patch to document. Returns document.
+//
+// Params:
+//
+//	doc - document
+//	spec - specification of patch
+func applyPatch(doc mongodb.DocUser, spec mongodb.PatchSpec) mongodb.DocUser {
+
+	for _, f := range spec.Fields {
+
+		switch f.Op {
+		case mongodb.PatchSet:
+			setFieldValue(&doc, f.Field, f.Value)
+		case mongodb.PatchUnset:
+			setFieldValue(&doc, f.Field, nil)
+		case mongodb.PatchInc:
+			n := reflect.ValueOf(f.Value).Convert(reflect.TypeOf(int64(0))).Int()
+			switch v := fieldValue(doc, f.Field).(type) {
+			case int:
+				setFieldValue(&doc, f.Field, v+int(n))
+			case int64:
+				setFieldValue(&doc, f.Field, v+n)
+			}
+		case mongodb.PatchRename:
+			// Missing field is not renamed
+			if v := fieldValue(doc, f.Field); !reflect.ValueOf(v).IsZero() {
+				setFieldValue(&doc, f.Value.(string), v)
+				setFieldValue(&doc, f.Field, nil)
+			}
+		}
+	}
+
+	return doc
+}
+
+// Set fields of update to document. Fields with zero value are omitted, as by $set with omitempty.
+// Maintained fields are set by store. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+//	upd - update
+func (s *memStore) setFields(ctx context.Context, doc, upd mongodb.DocUser) mongodb.DocUser {
+
+	updated := doc
+	if upd.Name != "" {
+		updated.Name = upd.Name
+	}
+	if upd.Email != "" {
+		updated.Email = upd.Email
+	}
+	if upd.Version != 0 {
+		updated.Version = upd.Version
+	}
+	updated.Age = upd.Age
+
+	return s.updated(ctx, doc, updated)
+}
+
+// Current time of store with precision of BSON date. Returns time.
+func (s *memStore) now() time.Time {
+
+	clock := time.Now
+	if s.clock != nil {
+		clock = s.clock
+	}
+
+	return clock().UTC().Truncate(time.Millisecond)
+}
+
+// Names of fields, maintained by store. Returns names.
+func (s *memStore) managedFields() []string {
+
+	names := []string{"_id", "createdAt", "updatedAt", "createdBy", "updatedBy"}
+	if s.versioning {
+		names = append(names, "version")
+	}
+	if s.softDelete {
+		names = append(names, "deletedAt")
+	}
+
+	return names
+}
+
+// Set maintained fields of inserted document. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (s *memStore) created(ctx context.Context, doc mongodb.DocUser) mongodb.DocUser {
+
+	if s.versioning {
+		doc.Version = 1
+	}
+	if s.softDelete {
+		doc.DeletedAt = time.Time{}
+	}
+
+	doc.CreatedAt = s.now()
+	doc.UpdatedAt = doc.CreatedAt
+	doc.CreatedBy = mongodb.ActorFromContext(ctx)
+	doc.UpdatedBy = doc.CreatedBy
+
+	return doc
+}
+
+// Set maintained fields of changed document. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	old - document before change
+//	doc - changed document
+func (s *memStore) updated(ctx context.Context, old, doc mongodb.DocUser) mongodb.DocUser {
+
+	if s.versioning {
+		doc.Version = old.Version + 1
+	}
+
+	doc.ID = old.ID
+	doc.CreatedAt = old.CreatedAt
+	doc.CreatedBy = old.CreatedBy
+	doc.UpdatedAt = s.now()
+	doc.UpdatedBy = mongodb.ActorFromContext(ctx)
+
+	return doc
+}
+
+// Execute operations as bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	n - count of operations
+//	opts - options
+//	op - operation by index, returns id of inserted document and error
+func (s *memStore) bulk(ctx context.Context, collectionName string, n int, opts mongodb.BulkOptions,
+	op func(i int, result *mongodb.BulkResult) (primitive.ObjectID, error)) (mongodb.BulkResult, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.BulkResult{}, mongodb.ErrEmptyCollectionsName
+	}
+	if n == 0 {
+		return mongodb.BulkResult{}, mongodb.ErrEmptyBulk
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return mongodb.BulkResult{}, fmt.Errorf("Function BulkWrite, returned error: <%w>", err)
+	}
+
+	result := mongodb.BulkResult{Items: make([]mongodb.BulkItemResult, n)}
+	failed := 0
+
+	for i := range result.Items {
+
+		result.Items[i].Index = i
+
+		if opts.Ordered && failed > 0 {
+			result.Items[i].Err = mongodb.ErrNotExecuted
+			failed++
+			continue
+		}
+
+		id, err := op(i, &result)
+		if err != nil {
+			result.Items[i].Err = err
+			failed++
+			continue
+		}
+		result.Items[i].ID = id
+	}
+
+	if failed > 0 {
+		return result, fmt.Errorf("%w: %d of %d operations", mongodb.ErrBulkWrite, failed, n)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,1442 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Close store. Return error.
+func (s *memStore) Close() error {
+
+	return s.CloseCtx(context.Background())
+}
+
+// Close store with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+func (s *memStore) CloseCtx(ctx context.Context) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Function Disconnetc, return error <%w>", err)
+	}
+
+	s.closed = true
+
+	return nil
+}
+
+// If collections are not exists - create by name. Return error.
+//
+// Params:
+//
+//	collections - list of collections names.
+func (s *memStore) CheckCreateDB(collections []string) error {
+
+	return s.CheckCreateDBCtx(context.Background(), collections)
+}
+
+// If collections are not exists - create by name with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	collections - list of collections names.
+func (s *memStore) CheckCreateDBCtx(ctx context.Context, collections []string) error {
+
+	// Check
+	if s.nameDB == "" {
+		return mongodb.ErrEmptyValueNameDB
+	}
+	if collections == nil {
+		return mongodb.ErrNilPtrCollections
+	}
+	if len(collections) == 0 {
+		return mongodb.ErrEmptyCollectionsNames
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	for _, v := range collections {
+		s.ensureIndexes(v, mongodb.UserIndexSpecs(s.softDelete))
+	}
+
+	return nil
+}
+
+// Drop collection by name. Return error.
+//
+// Params:
+//
+//	collectionName - collection name.
+func (s *memStore) DropCollection(collectionName string) error {
+
+	return s.DropCollectionCtx(context.Background(), collectionName)
+}
+
+// Drop collection by name with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - collection name.
+func (s *memStore) DropCollectionCtx(ctx context.Context, collectionName string) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyValueName
+	}
+	if s.nameDB == "" {
+		return mongodb.ErrEmptyValueNameDB
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to drop collection: <%w>", err)
+	}
+
+	if _, ok := s.collections[collectionName]; ok {
+		s.emitDrop(collectionName)
+	}
+	if collectionName == s.outboxName {
+		s.outbox = nil
+	}
+	delete(s.collections, collectionName)
+	delete(s.indexes, collectionName)
+
+	return nil
+}
+
+// Get names of collections. Returns names and error.
+func (s *memStore) GetNamesCollections() (names []string, err error) {
+
+	return s.GetNamesCollectionsCtx(context.Background())
+}
+
+// Get names of collections with context. Returns names and error.
+//
+// Params:
+//
+//	ctx - context
+func (s *memStore) GetNamesCollectionsCtx(ctx context.Context) (names []string, err error) {
+
+	// Check
+	if s.nameDB == "" {
+		return nil, mongodb.ErrEmptyValueNameDB
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	names = make([]string, 0, len(s.collections))
+	for name := range s.collections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Send the document user. Returns id added document and error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	doc - document
+func (s *memStore) SendDocumentUser(collectionName string, doc mongodb.DocUser) (id primitive.ObjectID, err error) {
+
+	return s.SendDocumentUserCtx(context.Background(), collectionName, doc)
+}
+
+// Send the document user with context. Returns id added document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	doc - document
+func (s *memStore) SendDocumentUserCtx(ctx context.Context, collectionName string, doc mongodb.DocUser) (id primitive.ObjectID, err error) {
+
+	// Check
+	if collectionName == "" {
+		return primitive.NilObjectID, mongodb.ErrEmptyCollectionsName
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUser(doc); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return primitive.NilObjectID, fmt.Errorf("Function FindOne, return error: <%w>", err)
+	}
+
+	if s.isDuplicate(collectionName, doc, -1) {
+		return primitive.NilObjectID, mongodb.ErrDocumentExists
+	}
+
+	r := s.newRecord(ctx, doc)
+	s.collections[collectionName] = append(s.collections[collectionName], r)
+	s.emitInsert(collectionName, r.doc)
+	s.appendOutbox(mongodb.EventUserCreated, collectionName, "", r.doc)
+
+	return r.id, nil
+}
+
+// Update the document user. Returns error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document
+func (s *memStore) UpdateDocumentUserByName(collectionName, name string, doc mongodb.DocUser) (err error) {
+
+	return s.UpdateDocumentUserByNameCtx(context.Background(), collectionName, name, doc)
+}
+
+// Update the document user with context. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document
+func (s *memStore) UpdateDocumentUserByNameCtx(ctx context.Context, collectionName, name string, doc mongodb.DocUser) (err error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.ErrEmptyValueName
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUserUpdate(doc); err != nil {
+		return err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(ctx, collectionName, byName(name), doc)
+}
+
+// Patch the document user by name. Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	p - patch
+func (s *memStore) PatchDocumentUserByName(ctx context.Context, collectionName, name string, p *mongodb.Patch) (mongodb.DocUser, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyValueName
+	}
+	spec, err := p.Spec()
+	if err != nil {
+		return mongodb.DocUser{}, err
+	}
+	if spec, err = mongodb.PrepareDocUserPatch(spec); err != nil {
+		return mongodb.DocUser{}, err
+	}
+	for _, f := range spec.Fields {
+		to, _ := f.Value.(string)
+		if mongodb.IsExistsCollection(s.managedFields(), f.Field) || (f.Op == mongodb.PatchRename && mongodb.IsExistsCollection(s.managedFields(), to)) {
+			return mongodb.DocUser{}, fmt.Errorf("%w: field <%s> is maintained by repository", mongodb.ErrNotCorrectPatch, f.Field)
+		}
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return mongodb.DocUser{}, fmt.Errorf("Function FindOneAndUpdate, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexByName(records, name)
+	if i < 0 {
+		return mongodb.DocUser{}, mongodb.ErrUpdateDocument
+	}
+
+	updated := s.updated(ctx, records[i].doc, applyPatch(records[i].doc, spec))
+
+	if s.isDuplicate(collectionName, updated, i) {
+		return mongodb.DocUser{}, mongodb.ErrDocumentExists
+	}
+	s.emitUpdate(collectionName, records[i].doc, updated)
+	records[i].doc = updated
+
+	return updated, nil
+}
+
+// Replace the document user by name or insert it. Returns result and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document
+func (s *memStore) UpsertDocumentUserByName(ctx context.Context, collectionName, name string, doc mongodb.DocUser) (mongodb.UpsertResult, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.UpsertResult{}, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.UpsertResult{}, mongodb.ErrEmptyValueName
+	}
+	if doc.Name == "" {
+		doc.Name = name
+	}
+	if doc.Name != name {
+		return mongodb.UpsertResult{}, mongodb.ErrKeyMismatch
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUser(doc); err != nil {
+		return mongodb.UpsertResult{}, err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return mongodb.UpsertResult{}, fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexByName(records, name)
+	if s.isDuplicate(collectionName, doc, i) {
+		return mongodb.UpsertResult{}, mongodb.ErrDocumentExists
+	}
+
+	if i >= 0 {
+		updated := s.updated(ctx, records[i].doc, doc)
+		s.emitUpdate(collectionName, records[i].doc, updated)
+		records[i].doc = updated
+		return mongodb.UpsertResult{}, nil
+	}
+
+	r := s.newRecord(ctx, doc)
+	s.collections[collectionName] = append(records, r)
+	s.emitInsert(collectionName, r.doc)
+	s.appendOutbox(mongodb.EventUserCreated, collectionName, "", r.doc)
+
+	return mongodb.UpsertResult{Inserted: true, ID: r.id}, nil
+}
+
+// Send documents user as by one bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (s *memStore) SendDocumentsUser(ctx context.Context, collectionName string, docs []mongodb.DocUser, opts mongodb.BulkOptions) (mongodb.BulkResult, error) {
+
+	return s.bulk(ctx, collectionName, len(docs), opts, func(i int, result *mongodb.BulkResult) (primitive.ObjectID, error) {
+
+		doc := mongodb.NormalizeDocUser(docs[i])
+		if err := mongodb.ValidateDocUser(doc); err != nil {
+			return primitive.NilObjectID, err
+		}
+
+		if s.isDuplicate(collectionName, doc, -1) {
+			return primitive.NilObjectID, mongodb.ErrDocumentExists
+		}
+
+		r := s.newRecord(ctx, doc)
+		s.collections[collectionName] = append(s.collections[collectionName], r)
+		s.emitInsert(collectionName, r.doc)
+		s.appendOutbox(mongodb.EventUserCreated, collectionName, "", r.doc)
+		result.Inserted++
+
+		return r.id, nil
+	})
+}
+
+// Insert imported documents user as by one bulk write. Maintained fields are kept from documents, missing
+// ones are set as on insert. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	docs - documents
+//	opts - options
+func (s *memStore) InsertImportedDocumentsUser(ctx context.Context, collectionName string, docs []mongodb.DocUser, opts mongodb.BulkOptions) (mongodb.BulkResult, error) {
+
+	return s.bulk(ctx, collectionName, len(docs), opts, func(i int, result *mongodb.BulkResult) (primitive.ObjectID, error) {
+
+		doc := mongodb.NormalizeDocUser(docs[i])
+		if err := mongodb.ValidateDocUser(doc); err != nil {
+			return primitive.NilObjectID, err
+		}
+
+		if s.isDuplicate(collectionName, doc, -1) {
+			return primitive.NilObjectID, mongodb.ErrDocumentExists
+		}
+
+		r := s.importedRecord(ctx, doc)
+		s.collections[collectionName] = append(s.collections[collectionName], r)
+		s.emitInsert(collectionName, r.doc)
+		s.appendOutbox(mongodb.EventUserCreated, collectionName, "", r.doc)
+		result.Inserted++
+
+		return r.id, nil
+	})
+}
+
+// Replace the document user by id by imported document. Omitted fields are removed, maintained fields are
+// kept from document, missing ones are set as on insert. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+//	doc - document
+func (s *memStore) ReplaceImportedDocumentUser(ctx context.Context, collectionName string, id primitive.ObjectID, doc mongodb.DocUser) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if id.IsZero() {
+		return mongodb.ErrEmptyID
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUser(doc); err != nil {
+		return err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Function ReplaceOne, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := s.indexWhere(records, byID(id))
+	if i < 0 {
+		return mongodb.ErrUpdateDocument
+	}
+
+	doc.ID = id
+	replaced := s.importedRecord(ctx, doc).doc
+	if s.isDuplicate(collectionName, replaced, i) {
+		return mongodb.ErrDocumentExists
+	}
+	s.emitReplace(collectionName, replaced)
+	records[i].doc = replaced
+
+	return nil
+}
+
+// Update documents user by names as by one bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	updates - updates
+//	opts - options
+func (s *memStore) UpdateDocumentsUserByName(ctx context.Context, collectionName string, updates []mongodb.UserUpdate, opts mongodb.BulkOptions) (mongodb.BulkResult, error) {
+
+	return s.bulk(ctx, collectionName, len(updates), opts, func(i int, result *mongodb.BulkResult) (primitive.ObjectID, error) {
+
+		if updates[i].Name == "" {
+			return primitive.NilObjectID, mongodb.ErrEmptyValueName
+		}
+		doc := mongodb.NormalizeDocUser(updates[i].Doc)
+		if err := mongodb.ValidateDocUserUpdate(doc); err != nil {
+			return primitive.NilObjectID, err
+		}
+
+		records := s.collections[collectionName]
+
+		j := s.indexByName(records, updates[i].Name)
+		if j < 0 {
+			return primitive.NilObjectID, mongodb.ErrUpdateDocument
+		}
+		result.Matched++
+
+		updated := s.setFields(ctx, records[j].doc, doc)
+		if s.isDuplicate(collectionName, updated, j) {
+			return primitive.NilObjectID, mongodb.ErrDocumentExists
+		}
+		if updated != records[j].doc {
+			s.emitUpdate(collectionName, records[j].doc, updated)
+			records[j].doc = updated
+			result.Modified++
+		}
+
+		return primitive.NilObjectID, nil
+	})
+}
+
+// Delete documents user by names as by one bulk write. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	names - names of users
+//	opts - options
+func (s *memStore) DelDocumentsUserByName(ctx context.Context, collectionName string, names []string, opts mongodb.BulkOptions) (mongodb.BulkResult, error) {
+
+	return s.bulk(ctx, collectionName, len(names), opts, func(i int, result *mongodb.BulkResult) (primitive.ObjectID, error) {
+
+		if names[i] == "" {
+			return primitive.NilObjectID, mongodb.ErrEmptyValueName
+		}
+
+		records := s.collections[collectionName]
+
+		j := s.indexByName(records, names[i])
+		if j < 0 {
+			return primitive.NilObjectID, nil
+		}
+		s.collections[collectionName] = s.deleteAt(ctx, collectionName, records, j)
+		result.Deleted++
+
+		return primitive.NilObjectID, nil
+	})
+}
+
+// Update the document user by name, if version of document is equal doc.Version.
+// Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	doc - document with expected version
+func (s *memStore) UpdateDocumentUserByNameVersioned(ctx context.Context, collectionName, name string, doc mongodb.DocUser) (mongodb.DocUser, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyValueName
+	}
+	if !s.versioning {
+		return mongodb.DocUser{}, mongodb.ErrNotVersioned
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUserUpdate(doc); err != nil {
+		return mongodb.DocUser{}, err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return mongodb.DocUser{}, fmt.Errorf("Function FindOneAndUpdate, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	i := -1
+	for j, r := range records {
+		if r.doc.Name == name && r.doc.Version == doc.Version && !s.isDeleted(r) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		if s.indexByName(records, name) >= 0 {
+			return mongodb.DocUser{}, mongodb.ErrConcurrentModification
+		}
+		return mongodb.DocUser{}, mongodb.ErrUpdateDocument
+	}
+
+	updated := s.setFields(ctx, records[i].doc, doc)
+
+	if s.isDuplicate(collectionName, updated, i) {
+		return mongodb.DocUser{}, mongodb.ErrDocumentExists
+	}
+	s.emitUpdate(collectionName, records[i].doc, updated)
+	records[i].doc = updated
+
+	return updated, nil
+}
+
+// Find softly deleted documents user. Returns documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (s *memStore) FindDeletedDocumentsUser(ctx context.Context, collectionName string) ([]mongodb.DocUser, error) {
+
+	// Check
+	if collectionName == "" {
+		return nil, mongodb.ErrEmptyCollectionsName
+	}
+	if !s.softDelete {
+		return nil, mongodb.ErrNotSoftDelete
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, fmt.Errorf("Function Find return error: <%w>", err)
+	}
+
+	docs := []mongodb.DocUser{}
+	for _, r := range s.collections[collectionName] {
+		if s.isDeleted(r) {
+			docs = append(docs, r.doc)
+		}
+	}
+
+	return docs, nil
+}
+
+// Restore softly deleted document user by name. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+func (s *memStore) RestoreDocumentUserByName(ctx context.Context, collectionName, name string) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.ErrEmptyValueName
+	}
+	if !s.softDelete {
+		return mongodb.ErrNotSoftDelete
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	records := s.collections[collectionName]
+
+	for i, r := range records {
+		if r.doc.Name == name && s.isDeleted(r) {
+			doc := r.doc
+			doc.DeletedAt = time.Time{}
+			if s.isDuplicate(collectionName, doc, i) {
+				return mongodb.ErrDocumentExists
+			}
+			records[i].doc = s.updated(ctx, r.doc, doc)
+			s.emitUpdate(collectionName, r.doc, records[i].doc)
+			return nil
+		}
+	}
+
+	return mongodb.ErrUpdateDocument
+}
+
+// Remove softly deleted documents user, which are deleted before retention from now.
+// Returns count removed documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	retention - time of keeping of deleted documents, 0 - all deleted documents
+func (s *memStore) PurgeDeletedDocumentsUser(ctx context.Context, collectionName string, retention time.Duration) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	if !s.softDelete {
+		return 0, mongodb.ErrNotSoftDelete
+	}
+	if retention < 0 {
+		return 0, mongodb.ErrNegativeRetention
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+
+	border := s.now().Add(-retention)
+
+	kept := []record{}
+	for _, r := range s.collections[collectionName] {
+		if s.isDeleted(r) && !r.doc.DeletedAt.After(border) {
+			s.emitDelete(collectionName, r.id)
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	n := int64(len(s.collections[collectionName]) - len(kept))
+	if n > 0 {
+		s.collections[collectionName] = kept
+	}
+
+	return n, nil
+}
+
+// Recieve document user by name. Returns document and error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	name - name
+func (s *memStore) RecvDocumentUserByName(collectionName string, name string) (doc mongodb.DocUser, err error) {
+
+	return s.RecvDocumentUserByNameCtx(context.Background(), collectionName, name)
+}
+
+// Recieve document user by name with context. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name
+func (s *memStore) RecvDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (doc mongodb.DocUser, err error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyValueName
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recv(ctx, collectionName, byName(name))
+}
+
+// Delete document user by name. Returns count deleted documents and error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	name - name
+func (s *memStore) DelDocumentUserByName(collectionName string, name string) (int64, error) {
+
+	return s.DelDocumentUserByNameCtx(context.Background(), collectionName, name)
+}
+
+// Delete document user by name with context. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name
+func (s *memStore) DelDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return 0, mongodb.ErrEmptyValueName
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.del(ctx, collectionName, byName(name))
+}
+
+// Delete all documents user by name. Returns count deleted documents
+// (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of user
+//	opts - options
+func (s *memStore) DelAllDocumentsUserByName(ctx context.Context, collectionName, name string, opts mongodb.DeleteOptions) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return 0, mongodb.ErrEmptyValueName
+	}
+
+	// Logic
+	return s.deleteMany(ctx, collectionName, opts, func(r record) bool {
+		return r.doc.Name == name
+	})
+}
+
+// Delete all documents user by conditions of query. Returns count deleted documents
+// (would be deleted with opts.DryRun) and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query with conditions only, nil - all documents
+//	opts - options
+func (s *memStore) DelDocumentsUserByQuery(ctx context.Context, collectionName string, q *mongodb.UserQuery, opts mongodb.DeleteOptions) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	spec, err := q.Spec()
+	if err != nil {
+		return 0, err
+	}
+	if err := spec.CheckDelete(); err != nil {
+		return 0, err
+	}
+	if !spec.HasConditions() && !opts.AllowEmptyFilter {
+		return 0, mongodb.ErrEmptyFilter
+	}
+
+	// Logic
+	return s.deleteMany(ctx, collectionName, opts, func(r record) bool {
+		return match(spec, r)
+	})
+}
+
+// Change collection for document. Return error.
+//
+// Params:
+//
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	doc - document
+func (s *memStore) MoveDocumentUserTx(srcCollection, destCollection string, doc mongodb.DocUser) error {
+
+	return s.MoveDocumentUserTxCtx(context.Background(), srcCollection, destCollection, doc)
+}
+
+// Change collection for document with context. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	doc - document
+func (s *memStore) MoveDocumentUserTxCtx(ctx context.Context, srcCollection, destCollection string, doc mongodb.DocUser) error {
+
+	// Check
+	if srcCollection == "" || destCollection == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if doc.Name == "" {
+		return mongodb.ErrEmptyValueName
+	}
+
+	// Logic. Relocation is atomic under the lock
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.move(ctx, srcCollection, destCollection, byName(doc.Name))
+}
+
+// Recieve document user by id. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+func (s *memStore) RecvDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (mongodb.DocUser, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyCollectionsName
+	}
+	if id.IsZero() {
+		return mongodb.DocUser{}, mongodb.ErrEmptyID
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recv(ctx, collectionName, byID(id))
+}
+
+// Update the document user by id. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+//	doc - document
+func (s *memStore) UpdateDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID, doc mongodb.DocUser) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if id.IsZero() {
+		return mongodb.ErrEmptyID
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUserUpdate(doc); err != nil {
+		return err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(ctx, collectionName, byID(id), doc)
+}
+
+// Delete the document user by id. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	id - id of document
+func (s *memStore) DelDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	if id.IsZero() {
+		return 0, mongodb.ErrEmptyID
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.del(ctx, collectionName, byID(id))
+}
+
+// Change collection for the document by id. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	id - id of document
+func (s *memStore) MoveDocumentUserByIDTx(ctx context.Context, srcCollection, destCollection string, id primitive.ObjectID) error {
+
+	// Check
+	if srcCollection == "" || destCollection == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if id.IsZero() {
+		return mongodb.ErrEmptyID
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.move(ctx, srcCollection, destCollection, byID(id))
+}
+
+// Recieve first document user by email. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	email - email
+func (s *memStore) RecvDocumentUserByEmail(ctx context.Context, collectionName, email string) (mongodb.DocUser, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyCollectionsName
+	}
+	email = mongodb.NormalizeDocUser(mongodb.DocUser{Email: email}).Email
+	if email == "" {
+		return mongodb.DocUser{}, mongodb.ErrEmptyEmail
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recv(ctx, collectionName, byEmail(email))
+}
+
+// Update the first document user by email. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	email - email
+//	doc - document
+func (s *memStore) UpdateDocumentUserByEmail(ctx context.Context, collectionName, email string, doc mongodb.DocUser) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	email = mongodb.NormalizeDocUser(mongodb.DocUser{Email: email}).Email
+	if email == "" {
+		return mongodb.ErrEmptyEmail
+	}
+	doc = mongodb.NormalizeDocUser(doc)
+	if err := mongodb.ValidateDocUserUpdate(doc); err != nil {
+		return err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(ctx, collectionName, byEmail(email), doc)
+}
+
+// Delete the first document user by email. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	email - email
+func (s *memStore) DelDocumentUserByEmail(ctx context.Context, collectionName, email string) (int64, error) {
+
+	// Check
+	if collectionName == "" {
+		return 0, mongodb.ErrEmptyCollectionsName
+	}
+	email = mongodb.NormalizeDocUser(mongodb.DocUser{Email: email}).Email
+	if email == "" {
+		return 0, mongodb.ErrEmptyEmail
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.del(ctx, collectionName, byEmail(email))
+}
+
+// Change collection for the first document by email. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	email - email
+func (s *memStore) MoveDocumentUserByEmailTx(ctx context.Context, srcCollection, destCollection, email string) error {
+
+	// Check
+	if srcCollection == "" || destCollection == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	email = mongodb.NormalizeDocUser(mongodb.DocUser{Email: email}).Email
+	if email == "" {
+		return mongodb.ErrEmptyEmail
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.move(ctx, srcCollection, destCollection, byEmail(email))
+}
+
+// Find documents user by query. Returns documents, token of next page and error.
+// Token is empty on the last page.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query, nil - all documents
+func (s *memStore) FindDocumentsUser(ctx context.Context, collectionName string, q *mongodb.UserQuery) (docs []mongodb.DocUser, next string, err error) {
+
+	// Check
+	if collectionName == "" {
+		return nil, "", mongodb.ErrEmptyCollectionsName
+	}
+	spec, err := q.Spec()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, "", fmt.Errorf("Function Find return error: <%w>", err)
+	}
+
+	found := s.find(collectionName, spec)
+
+	if spec.Limit > 0 && int64(len(found)) > spec.Limit {
+		last := found[spec.Limit-1].doc
+		last.ID = found[spec.Limit-1].id
+		if next, err = spec.NextPageToken(last); err != nil {
+			return nil, "", err
+		}
+		found = found[:spec.Limit]
+	}
+
+	docs = make([]mongodb.DocUser, 0, len(found))
+	for _, r := range found {
+		docs = append(docs, spec.ApplyProjection(r.doc))
+	}
+
+	return docs, next, nil
+}
+
+// Create indexes of collection, if they are not exists. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	specs - declarations of indexes
+func (s *memStore) EnsureIndexes(ctx context.Context, collectionName string, specs []mongodb.IndexSpec) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if len(specs) == 0 {
+		return mongodb.ErrEmptyIndexes
+	}
+	for _, spec := range specs {
+		if len(spec.Keys) == 0 {
+			return mongodb.ErrEmptyIndexKeys
+		}
+		if spec.IndexName() == idIndexName {
+			return mongodb.ErrNotCorrectIndex
+		}
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to create indexes: <%w>", err)
+	}
+
+	for _, spec := range specs {
+
+		if i := indexOf(s.indexes[collectionName], spec.IndexName()); i >= 0 && !s.indexes[collectionName][i].Equal(spec) {
+			return fmt.Errorf("failed to create indexes: <%w>", mongodb.ErrNotCorrectIndex)
+		}
+		if !spec.Unique {
+			continue
+		}
+
+		records := s.collections[collectionName]
+		for i := range records {
+			for j := i + 1; j < len(records); j++ {
+				if sameKeys(spec, records[i].doc, records[j].doc) {
+					return fmt.Errorf("failed to create indexes: <%w>", mongodb.ErrDocumentExists)
+				}
+			}
+		}
+	}
+
+	s.ensureIndexes(collectionName, specs)
+
+	return nil
+}
+
+// Get indexes of collection. Returns declarations and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (s *memStore) ListIndexes(ctx context.Context, collectionName string) ([]mongodb.IndexSpec, error) {
+
+	// Check
+	if collectionName == "" {
+		return nil, mongodb.ErrEmptyCollectionsName
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return nil, fmt.Errorf("failed to list indexes: <%w>", err)
+	}
+
+	if _, ok := s.collections[collectionName]; !ok {
+		return []mongodb.IndexSpec{}, nil
+	}
+
+	specs := []mongodb.IndexSpec{idIndex}
+	specs = append(specs, s.indexes[collectionName]...)
+
+	return specs, nil
+}
+
+// Drop index of collection by name. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	name - name of index
+func (s *memStore) DropIndex(ctx context.Context, collectionName, name string) error {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.ErrEmptyCollectionsName
+	}
+	if name == "" {
+		return mongodb.ErrEmptyIndexName
+	}
+	if name == idIndexName {
+		return mongodb.ErrNotCorrectIndex
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to drop index: <%w>", err)
+	}
+
+	specs := s.indexes[collectionName]
+
+	i := indexOf(specs, name)
+	if i < 0 {
+		return fmt.Errorf("failed to drop index: <%w>", mongodb.ErrIndexNotFound)
+	}
+
+	s.indexes[collectionName] = append(specs[:i:i], specs[i+1:]...)
+
+	return nil
+}
+
+// Synchronize indexes of collection with declarations. Returns report and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	specs - declarations of indexes
+//	opts - options
+func (s *memStore) SyncIndexes(ctx context.Context, collectionName string, specs []mongodb.IndexSpec, opts mongodb.IndexSyncOptions) (mongodb.IndexSyncReport, error) {
+
+	// Check
+	if collectionName == "" {
+		return mongodb.IndexSyncReport{}, mongodb.ErrEmptyCollectionsName
+	}
+	if len(specs) == 0 {
+		return mongodb.IndexSyncReport{}, mongodb.ErrEmptyIndexes
+	}
+
+	// Logic
+	existing, err := s.ListIndexes(ctx, collectionName)
+	if err != nil {
+		return mongodb.IndexSyncReport{}, err
+	}
+
+	report, create, drop := mongodb.DiffIndexes(existing, specs, opts.DropUnknown)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	for _, name := range drop {
+		if err := s.DropIndex(ctx, collectionName, name); err != nil && !errors.Is(err, mongodb.ErrIndexNotFound) {
+			return report, err
+		}
+	}
+	if len(create) > 0 {
+		if err := s.EnsureIndexes(ctx, collectionName, create); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// Create collections, which are not exists. Existing collections are not changed. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	specs - declarations of collections
+func (s *memStore) CreateCollections(ctx context.Context, specs []mongodb.CollectionSpec) error {
+
+	// Check
+	if s.nameDB == "" {
+		return mongodb.ErrEmptyValueNameDB
+	}
+	if len(specs) == 0 {
+		return mongodb.ErrEmptyCollectionsNames
+	}
+	for _, spec := range specs {
+		if err := spec.Check(); err != nil {
+			return err
+		}
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	for _, spec := range specs {
+		if _, ok := s.collections[spec.Name]; !ok {
+			s.collections[spec.Name] = []record{}
+		}
+	}
+
+	return nil
+}
+
+// Migration: delete placeholder documents {name: "initial"}. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collections - list of collections names
+func (s *memStore) RemoveInitialDocuments(ctx context.Context, collections []string) (int64, error) {
+
+	// Check
+	if len(collections) == 0 {
+		return 0, mongodb.ErrEmptyCollectionsNames
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return 0, fmt.Errorf("failed to delete initial documents: <%w>", err)
+	}
+
+	var deleted int64
+	for _, c := range collections {
+
+		if c == "" {
+			return deleted, mongodb.ErrEmptyCollectionsName
+		}
+
+		kept := s.collections[c][:0]
+		for _, r := range s.collections[c] {
+			if r.doc == (mongodb.DocUser{Name: initialDocumentName}) {
+				s.emitDelete(c, r.id)
+				deleted++
+				continue
+			}
+			kept = append(kept, r)
+		}
+		if _, ok := s.collections[c]; ok {
+			s.collections[c] = kept
+		}
+	}
+
+	return deleted, nil
+}
+
+// Apply $jsonSchema, generated from struct, as validator of collection.
+// Store checks arguments and creates collection, documents are validated by Go rules only. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	v - struct with tags bson and validate
+//	opts - options
+func (s *memStore) ApplySchema(ctx context.Context, collectionName string, v interface{}, opts mongodb.SchemaOptions) error {
+
+	// Check
+	if err := mongodb.CheckSchema(collectionName, v, opts); err != nil {
+		return err
+	}
+
+	// Logic
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.check(ctx); err != nil {
+		return fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	if _, ok := s.collections[collectionName]; !ok {
+		s.collections[collectionName] = []record{}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Sequence with one error. Returns sequence.
+//
+// Params:
+//
+//	err - error
+func iterError[T any](err error) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+		var zero T
+		yield(zero, err)
+	}
+}
+
+// Sequence of values of snapshot. Iteration stops on break and cancel of context. Returns sequence.
+//
+// Params:
+//
+//	ctx - context
+//	snapshot - values, taken under the lock at the start of iteration
+func iterSnapshot[T any](ctx context.Context, snapshot func() ([]T, error)) iter.Seq2[T, error] {
+
+	return func(yield func(T, error) bool) {
+
+		var zero T
+
+		values, err := snapshot()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for _, v := range values {
+
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Iterate documents user by query, as mongodb.IterDocumentsUser. Documents are taken at the start of iteration,
+// size of batch and timeouts are ignored. Returns sequence of documents, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	q - query, nil - all documents
+//	opts - settings of iteration
+func (s *memStore) IterDocumentsUser(ctx context.Context, collectionName string, q *mongodb.UserQuery, opts mongodb.IterOptions) iter.Seq2[mongodb.DocUser, error] {
+
+	// Check
+	if collectionName == "" {
+		return iterError[mongodb.DocUser](mongodb.ErrEmptyCollectionsName)
+	}
+	spec, err := q.Spec()
+	if err != nil {
+		return iterError[mongodb.DocUser](err)
+	}
+	if err := opts.Check(); err != nil {
+		return iterError[mongodb.DocUser](err)
+	}
+
+	// Logic
+	return iterSnapshot(ctx, func() ([]mongodb.DocUser, error) {
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if err := s.check(ctx); err != nil {
+			return nil, fmt.Errorf("Function Find return error: <%w>", err)
+		}
+
+		found := s.find(collectionName, spec)
+		if spec.Limit > 0 && int64(len(found)) > spec.Limit {
+			found = found[:spec.Limit]
+		}
+
+		docs := make([]mongodb.DocUser, 0, len(found))
+		for _, r := range found {
+			docs = append(docs, spec.ApplyProjection(r.doc))
+		}
+
+		return docs, nil
+	})
+}
+
+// Iterate results of pipeline of aggregation, as mongodb.IterAggregate. Results are calculated at the start
+// of iteration. Returns sequence of documents, error ends sequence.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	p - pipeline, nil - all documents
+//	opts - settings of iteration
+func (s *memStore) IterAggregate(ctx context.Context, collectionName string, p *mongodb.Pipeline, opts mongodb.IterOptions) iter.Seq2[bson.Raw, error] {
+
+	// Check
+	if err := opts.Check(); err != nil {
+		return iterError[bson.Raw](err)
+	}
+
+	// Logic
+	return iterSnapshot(ctx, func() ([]bson.Raw, error) {
+
+		results := []bson.Raw{}
+		if err := s.Aggregate(ctx, collectionName, p, &results); err != nil {
+			return nil, err
+		}
+
+		return results, nil
+	})
+}
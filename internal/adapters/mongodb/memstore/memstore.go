@@ -0,0 +1,125 @@
+// Package memstore is in-memory implementation of mongodb.MongoDBI for unit tests.
+// It implements methods of interface only: mongodb.NewRepository and mongodb.NewCollectionCheckpoints
+// require connection of mongodb.New and return mongodb.ErrNotSupportedDB for memstore.
+package memstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stored document.
+type record struct {
+	id  primitive.ObjectID
+	doc mongodb.DocUser
+}
+
+// Name of index by _id, which exists in each collection.
+const idIndexName = "_id_"
+
+// Name of legacy placeholder document.
+const initialDocumentName = "initial"
+
+// Index by _id.
+var idIndex = mongodb.IndexSpec{Name: idIndexName, Keys: bson.D{{Key: "_id", Value: 1}}}
+
+// Presentation
+type memStore struct {
+	mu          sync.Mutex
+	txMu        sync.Mutex
+	nameDB      string
+	closed      bool
+	collections map[string][]record
+	indexes     map[string][]mongodb.IndexSpec
+	versioning  bool
+	softDelete  bool
+	clock       func() time.Time
+	// Events of changes, number of event is index + 1
+	history     []mongodb.ChangeEvent
+	subscribers map[*subscriber]struct{}
+	// Transaction is active, events are delayed
+	inTx    bool
+	pending []mongodb.ChangeEvent
+	// Name of outbox collection, "" - without outbox
+	outboxName string
+	outbox     []mongodb.OutboxEvent
+}
+
+// Option of constructor.
+type Option func(*memStore)
+
+// Maintain version of documents user, as mongodb.WithVersioning.
+func WithVersioning() Option {
+	return func(s *memStore) {
+		s.versioning = true
+	}
+}
+
+// Delete documents user softly, as mongodb.WithSoftDelete.
+func WithSoftDelete() Option {
+	return func(s *memStore) {
+		s.softDelete = true
+	}
+}
+
+// Set clock of fields of audit, as mongodb.WithClock.
+func WithClock(clock func() time.Time) Option {
+	return func(s *memStore) {
+		s.clock = clock
+	}
+}
+
+// Append events of documents user to outbox, as mongodb.WithOutbox.
+func WithOutbox(collectionName string) Option {
+	return func(s *memStore) {
+		s.outboxName = collectionName
+	}
+}
+
+// Check of implementation.
+var _ mongodb.MongoDBI = (*memStore)(nil)
+
+// Constructor.
+//
+// Params:
+//
+//	nameDB - name of DB
+//	opts - options
+func New(nameDB string, opts ...Option) (mongodb.MongoDBI, error) {
+
+	return NewCtx(context.Background(), nameDB, opts...)
+}
+
+// Constructor with context.
+//
+// Params:
+//
+//	ctx - context
+//	nameDB - name of DB
+//	opts - options
+func NewCtx(ctx context.Context, nameDB string, opts ...Option) (mongodb.MongoDBI, error) {
+
+	// Check
+	if nameDB == "" {
+		return nil, mongodb.ErrEmptyValueNameDB
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s := &memStore{
+		nameDB:      nameDB,
+		collections: make(map[string][]record),
+		indexes:     make(map[string][]mongodb.IndexSpec),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,113 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb/conformance"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Factory of memstore with options.
+func newFactory(opts ...Option) conformance.Factory {
+
+	return func(t *testing.T) mongodb.MongoDBI {
+
+		db, err := New("myDatabase", opts...)
+		require.NoErrorf(t, err, "Unexpected error New")
+
+		t.Cleanup(func() {
+			err := db.Close()
+			assert.NoErrorf(t, err, "Unexpected error Close")
+		})
+
+		return db
+	}
+}
+
+// Test conformance of memstore.
+func TestConformance(t *testing.T) {
+
+	conformance.Run(t, newFactory())
+}
+
+// Test conformance of memstore with versioning.
+func TestConformanceVersioning(t *testing.T) {
+
+	conformance.RunVersioning(t, newFactory(WithVersioning()))
+}
+
+// Test conformance of memstore with clock of audit.
+func TestConformanceAudit(t *testing.T) {
+
+	conformance.RunAudit(t, func(clock func() time.Time) conformance.Factory {
+		return newFactory(WithClock(clock))
+	})
+}
+
+// Test conformance of memstore with soft delete.
+func TestConformanceSoftDelete(t *testing.T) {
+
+	conformance.RunSoftDelete(t, func(clock func() time.Time) conformance.Factory {
+		return newFactory(WithSoftDelete(), WithClock(clock))
+	})
+}
+
+// Test conformance of memstore with outbox.
+func TestConformanceOutbox(t *testing.T) {
+
+	conformance.RunOutbox(t, newFactory(WithOutbox(conformance.OutboxCollection)))
+}
+
+// Test New.
+func TestNew(t *testing.T) {
+
+	t.Run("Missing name DB", func(t *testing.T) {
+
+		_, err := New("")
+		require.Equalf(t, mongodb.ErrEmptyValueNameDB, err, "Error is not equal")
+	})
+
+	t.Run("Closed", func(t *testing.T) {
+
+		db, err := New("myDatabase")
+		require.NoErrorf(t, err, "Unexpected error New")
+
+		err = db.Close()
+		require.NoErrorf(t, err, "Unexpected error Close")
+
+		_, err = db.GetNamesCollections()
+		require.Errorf(t, err, "Error is not exists")
+	})
+}
+
+// Test operators of pipeline, which are not supported by memstore.
+func TestAggregateNotSupported(t *testing.T) {
+
+	db, err := New("myDatabase")
+	require.NoErrorf(t, err, "Unexpected error New")
+	defer db.Close()
+
+	_, err = db.SendDocumentUser("users", mongodb.DocUser{Name: "Anna", Age: 25, Email: "anna@mail.com"})
+	require.NoErrorf(t, err, "Unexpected error send")
+
+	tests := map[string]*mongodb.Pipeline{
+		"Stage":       mongodb.NewPipeline().Unwind("tags", false),
+		"Accumulator": mongodb.NewPipeline().Group(nil, mongodb.Accumulators{"max": mongodb.Max(mongodb.Field("age"))}),
+		"Expression":  mongodb.NewPipeline().Project(bson.M{"upper": bson.M{"$toUpper": "$name"}}),
+		"Match":       mongodb.NewPipeline().Match(bson.M{"name": bson.M{"$nin": bson.A{"Boris"}}}),
+	}
+	for name, p := range tests {
+		t.Run(name, func(t *testing.T) {
+
+			var results []bson.M
+			err := db.Aggregate(context.Background(), "users", p, &results)
+			require.Truef(t, errors.Is(err, mongodb.ErrNotSupportedOperator), "Error is not equal")
+		})
+	}
+}
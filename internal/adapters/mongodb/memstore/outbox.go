@@ -0,0 +1,209 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Append event of document to outbox. Lock must be held.
+//
+// Params:
+//
+//	eventType - type of event
+//	collectionName - name of collection of document
+//	source - name of source collection of relocation, "" - for other events
+//	doc - document
+func (s *memStore) appendOutbox(eventType, collectionName, source string, doc mongodb.DocUser) {
+
+	if s.outboxName == "" {
+		return
+	}
+
+	payload, _ := bson.Marshal(doc)
+
+	ev := mongodb.OutboxEvent{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		Collection: collectionName,
+		Source:     source,
+		DocumentID: doc.ID,
+		Payload:    payload,
+		CreatedAt:  s.now(),
+	}
+	s.outbox = append(s.outbox, ev)
+
+	s.emit(mongodb.ChangeEvent{
+		Operation:    mongodb.OperationInsert,
+		Collection:   s.outboxName,
+		DocumentKey:  ev.ID,
+		FullDocument: rawDocument(ev),
+	})
+}
+
+// Check outbox. Lock must be held. Returns error.
+//
+// Params:
+//
+//	ctx - context
+func (s *memStore) checkOutbox(ctx context.Context) error {
+
+	if s.outboxName == "" {
+		return mongodb.ErrNotOutbox
+	}
+
+	return s.check(ctx)
+}
+
+// Claim undelivered events of outbox for delivery, as mongodb.ClaimOutboxEvents. Returns events and error.
+//
+// Params:
+//
+//	ctx - context
+//	limit - maximum count of events
+//	lease - time of lease of delivery
+func (s *memStore) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]mongodb.OutboxEvent, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check
+	if err := s.checkOutbox(ctx); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || lease <= 0 {
+		return nil, mongodb.ErrValueRelayOptions
+	}
+
+	// Logic
+	now := s.now()
+
+	events := []mongodb.OutboxEvent{}
+	for i := range s.outbox {
+
+		if len(events) == limit {
+			break
+		}
+
+		ev := &s.outbox[i]
+		if !ev.DeliveredAt.IsZero() || ev.LockedUntil.After(now) {
+			continue
+		}
+		ev.LockedUntil = now.Add(lease)
+		ev.Attempts++
+
+		events = append(events, *ev)
+	}
+
+	return events, nil
+}
+
+// Mark event of outbox as delivered. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+func (s *memStore) AckOutboxEvent(ctx context.Context, id primitive.ObjectID) error {
+
+	return s.updateOutboxEvent(ctx, id, func(ev *mongodb.OutboxEvent) {
+		ev.DeliveredAt = s.now()
+		ev.LockedUntil = time.Time{}
+		ev.LastError = ""
+	})
+}
+
+// Register error of delivery of event of outbox. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+//	cause - error of delivery
+//	retryAfter - delay of next delivery
+func (s *memStore) FailOutboxEvent(ctx context.Context, id primitive.ObjectID, cause error, retryAfter time.Duration) error {
+
+	// Check
+	if retryAfter < 0 {
+		return mongodb.ErrValueRelayOptions
+	}
+
+	return s.updateOutboxEvent(ctx, id, func(ev *mongodb.OutboxEvent) {
+		ev.LockedUntil = s.now().Add(retryAfter)
+		if cause != nil {
+			ev.LastError = cause.Error()
+		}
+	})
+}
+
+// Update undelivered event of outbox. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+//	set - change of event
+func (s *memStore) updateOutboxEvent(ctx context.Context, id primitive.ObjectID, set func(ev *mongodb.OutboxEvent)) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check
+	if err := s.checkOutbox(ctx); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return mongodb.ErrEmptyID
+	}
+
+	// Logic
+	for i := range s.outbox {
+		if s.outbox[i].ID == id && s.outbox[i].DeliveredAt.IsZero() {
+			set(&s.outbox[i])
+			return nil
+		}
+	}
+
+	return mongodb.ErrUpdateDocument
+}
+
+// Remove delivered events of outbox, which are delivered before retention from now.
+// Returns count removed events and error.
+//
+// Params:
+//
+//	ctx - context
+//	retention - time of keeping of delivered events, 0 - all delivered events
+func (s *memStore) PurgeOutboxEvents(ctx context.Context, retention time.Duration) (int64, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Check
+	if err := s.checkOutbox(ctx); err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+	if retention < 0 {
+		return 0, mongodb.ErrNegativeRetention
+	}
+
+	// Logic
+	border := s.now().Add(-retention)
+
+	kept := []mongodb.OutboxEvent{}
+	for _, ev := range s.outbox {
+		if !ev.DeliveredAt.IsZero() && !ev.DeliveredAt.After(border) {
+			continue
+		}
+		kept = append(kept, ev)
+	}
+
+	n := int64(len(s.outbox) - len(kept))
+	s.outbox = kept
+
+	return n, nil
+}
@@ -0,0 +1,162 @@
+package memstore
+
+import (
+	"bytes"
+	"cmp"
+	"sort"
+	"strings"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+)
+
+// Check document by specification of query. Returns flag of match.
+//
+// Params:
+//
+//	spec - specification of query
+//	r - document
+func match(spec mongodb.UserQuerySpec, r record) bool {
+
+	if spec.AgeMin != nil && r.doc.Age < *spec.AgeMin {
+		return false
+	}
+	if spec.AgeMax != nil && r.doc.Age > *spec.AgeMax {
+		return false
+	}
+	if spec.EmailDomain != "" && !strings.HasSuffix(strings.ToLower(r.doc.Email), "@"+strings.ToLower(spec.EmailDomain)) {
+		return false
+	}
+	if spec.NamePrefix != "" && !strings.HasPrefix(r.doc.Name, spec.NamePrefix) {
+		return false
+	}
+	if spec.Name != "" && r.doc.Name != spec.Name {
+		return false
+	}
+	if spec.Email != "" && r.doc.Email != spec.Email {
+		return false
+	}
+	if spec.After != nil && !afterToken(spec, r) {
+		return false
+	}
+
+	return true
+}
+
+// Check, that document is after token of page in order of sorting. Returns flag.
+//
+// Params:
+//
+//	spec - specification of query with token
+//	r - document
+func afterToken(spec mongodb.UserQuerySpec, r record) bool {
+
+	doc := r.doc
+	doc.ID = r.id
+
+	keys, err := spec.SortKeys(doc)
+	if err != nil {
+		return false
+	}
+
+	for i, sf := range spec.Sort {
+		c := compareValues(keys[i], spec.After.Keys[i])
+		if sf.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c > 0
+		}
+	}
+
+	return bytes.Compare(r.id[:], spec.After.ID[:]) > 0
+}
+
+// Documents, which match specification, sorted and skipped, without limit. Softly deleted documents
+// are skipped. Lock must be held. Returns documents.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	spec - specification of query
+func (s *memStore) find(collectionName string, spec mongodb.UserQuerySpec) []record {
+
+	found := []record{}
+	for _, r := range s.collections[collectionName] {
+		if match(spec, r) && !s.isDeleted(r) {
+			found = append(found, r)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return compare(spec.Sort, found[i], found[j]) < 0
+	})
+
+	if spec.Skip >= int64(len(found)) {
+		return nil
+	}
+
+	return found[spec.Skip:]
+}
+
+// Compare documents in order of sorting, id is the last field. Returns -1, 0 or 1.
+//
+// Params:
+//
+//	sort - fields of sorting
+//	a - first document
+//	b - second document
+func compare(sort []mongodb.SortField, a, b record) int {
+
+	for _, sf := range sort {
+
+		c := 0
+		switch sf.Field {
+		case "name":
+			c = strings.Compare(a.doc.Name, b.doc.Name)
+		case "age":
+			c = compareInt(a.doc.Age, b.doc.Age)
+		case "email":
+			c = strings.Compare(a.doc.Email, b.doc.Email)
+		case "_id":
+			c = bytes.Compare(a.id[:], b.id[:])
+		case "version":
+			c = cmp.Compare(a.doc.Version, b.doc.Version)
+		case "createdAt":
+			c = a.doc.CreatedAt.Compare(b.doc.CreatedAt)
+		case "updatedAt":
+			c = a.doc.UpdatedAt.Compare(b.doc.UpdatedAt)
+		case "createdBy":
+			c = strings.Compare(a.doc.CreatedBy, b.doc.CreatedBy)
+		case "updatedBy":
+			c = strings.Compare(a.doc.UpdatedBy, b.doc.UpdatedBy)
+		case "deletedAt":
+			c = a.doc.DeletedAt.Compare(b.doc.DeletedAt)
+		}
+
+		if sf.Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+
+	return bytes.Compare(a.id[:], b.id[:])
+}
+
+// Compare numbers. Returns -1, 0 or 1.
+//
+// Params:
+//
+//	a - first number
+//	b - second number
+func compareInt(a, b int) int {
+
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
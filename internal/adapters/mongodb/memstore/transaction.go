@@ -0,0 +1,140 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Execute function in transaction. Transactions are serialized, on error of function
+// collections and indexes are restored. Writes out of transaction are not isolated,
+// their events are delayed with events of transaction. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	fn - operations of transaction
+func (s *memStore) WithTransaction(ctx context.Context, fn func(tx mongodb.Tx) error) error {
+
+	// Check
+	if fn == nil {
+		return mongodb.ErrEmptyTxFunction
+	}
+
+	// Logic
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.Lock()
+	if err := s.check(ctx); err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	collections, indexes := s.snapshot()
+	outbox := slices.Clone(s.outbox)
+	s.inTx = true
+	s.mu.Unlock()
+
+	err := fn(&tx{s: s, ctx: ctx})
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := s.pending
+	s.inTx, s.pending = false, nil
+
+	if err != nil {
+		s.collections, s.indexes, s.outbox = collections, indexes, outbox
+		return err
+	}
+
+	for _, ev := range pending {
+		s.emit(ev)
+	}
+
+	return nil
+}
+
+// Copy of collections and indexes. Returns copies.
+func (s *memStore) snapshot() (map[string][]record, map[string][]mongodb.IndexSpec) {
+
+	collections := make(map[string][]record, len(s.collections))
+	for name, records := range s.collections {
+		collections[name] = slices.Clone(records)
+	}
+
+	indexes := make(map[string][]mongodb.IndexSpec, len(s.indexes))
+	for name, specs := range s.indexes {
+		indexes[name] = slices.Clone(specs)
+	}
+
+	return collections, indexes
+}
+
+// Operations of transaction.
+type tx struct {
+	s   *memStore
+	ctx context.Context
+}
+
+// Context of transaction.
+func (t *tx) Context() context.Context {
+
+	return t.ctx
+}
+
+// Send new document user. Returns id added document and error.
+func (t *tx) SendDocumentUser(collectionName string, doc mongodb.DocUser) (primitive.ObjectID, error) {
+
+	return t.s.SendDocumentUserCtx(t.ctx, collectionName, doc)
+}
+
+// Recieve document user by name. Returns document and error.
+func (t *tx) RecvDocumentUserByName(collectionName, name string) (mongodb.DocUser, error) {
+
+	return t.s.RecvDocumentUserByNameCtx(t.ctx, collectionName, name)
+}
+
+// Recieve document user by id. Returns document and error.
+func (t *tx) RecvDocumentUserByID(collectionName string, id primitive.ObjectID) (mongodb.DocUser, error) {
+
+	return t.s.RecvDocumentUserByID(t.ctx, collectionName, id)
+}
+
+// Update document user by name. Returns error.
+func (t *tx) UpdateDocumentUserByName(collectionName, name string, doc mongodb.DocUser) error {
+
+	return t.s.UpdateDocumentUserByNameCtx(t.ctx, collectionName, name, doc)
+}
+
+// Patch document user by name. Returns updated document and error.
+func (t *tx) PatchDocumentUserByName(collectionName, name string, p *mongodb.Patch) (mongodb.DocUser, error) {
+
+	return t.s.PatchDocumentUserByName(t.ctx, collectionName, name, p)
+}
+
+// Replace document user by name or insert it. Returns result and error.
+func (t *tx) UpsertDocumentUserByName(collectionName, name string, doc mongodb.DocUser) (mongodb.UpsertResult, error) {
+
+	return t.s.UpsertDocumentUserByName(t.ctx, collectionName, name, doc)
+}
+
+// Delete document user by name. Returns count deleted documents and error.
+func (t *tx) DelDocumentUserByName(collectionName, name string) (int64, error) {
+
+	return t.s.DelDocumentUserByNameCtx(t.ctx, collectionName, name)
+}
+
+// Relocate document by name. Returns error.
+func (t *tx) MoveDocumentUser(srcCollection, destCollection string, doc mongodb.DocUser) error {
+
+	return t.s.MoveDocumentUserTxCtx(t.ctx, srcCollection, destCollection, doc)
+}
+
+// Find documents user by query. Returns documents, token of next page and error.
+func (t *tx) FindDocumentsUser(collectionName string, q *mongodb.UserQuery) (docs []mongodb.DocUser, next string, err error) {
+
+	return t.s.FindDocumentsUser(t.ctx, collectionName, q)
+}
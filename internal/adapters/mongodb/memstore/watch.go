@@ -0,0 +1,404 @@
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Part001-R/MongoDB-v2/internal/adapters/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Subscriber of changes.
+type subscriber struct {
+	// Name of collection, "" - all collections
+	collection string
+	// Names of collections of users, full documents of which are decoded
+	users  []string
+	mu     sync.Mutex
+	queue  []mongodb.ChangeEvent
+	notify chan struct{}
+}
+
+// Check, that event is for subscriber. Returns flag.
+//
+// Params:
+//
+//	ev - event
+func (sub *subscriber) matches(ev mongodb.ChangeEvent) bool {
+
+	if ev.Operation == mongodb.OperationInvalidate {
+		return sub.collection == ev.Collection
+	}
+
+	return sub.collection == "" || sub.collection == ev.Collection
+}
+
+// Add event to queue of subscriber.
+//
+// Params:
+//
+//	ev - event
+func (sub *subscriber) push(ev mongodb.ChangeEvent) {
+
+	sub.mu.Lock()
+	sub.queue = append(sub.queue, ev)
+	sub.mu.Unlock()
+
+	select {
+	case sub.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Take first event from queue of subscriber. Document is kept for collections of users only. Returns event
+// and flag of existence.
+func (sub *subscriber) pop() (mongodb.ChangeEvent, bool) {
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	if len(sub.queue) == 0 {
+		return mongodb.ChangeEvent{}, false
+	}
+	ev := sub.queue[0]
+	sub.queue = sub.queue[1:]
+
+	if !slices.Contains(sub.users, ev.Collection) {
+		ev.Doc = nil
+	}
+
+	return ev, true
+}
+
+// Watch changes of collection or DB, as mongodb.Watch. Events of transaction are sent after commit.
+// Returns channel of events, channel of errors and error.
+//
+// Params:
+//
+//	ctx - context
+//	opts - settings
+func (s *memStore) Watch(ctx context.Context, opts mongodb.WatchOptions) (<-chan mongodb.ChangeEvent, <-chan error, error) {
+
+	// Check
+	if err := opts.Check(); err != nil {
+		return nil, nil, err
+	}
+
+	key := opts.Key(s.nameDB)
+
+	var token bson.Raw
+	if opts.Checkpoints != nil {
+		var err error
+		if token, err = opts.Checkpoints.Load(ctx, key); err != nil {
+			return nil, nil, fmt.Errorf("Function Load, returned error: <%w>", err)
+		}
+	}
+
+	// Subscribe. History after resume token is queued under the lock, so no event is lost
+	s.mu.Lock()
+
+	if err := s.check(ctx); err != nil {
+		s.mu.Unlock()
+		return nil, nil, fmt.Errorf("Function Watch, returned error: <%w>", err)
+	}
+
+	sub := &subscriber{collection: opts.Collection, users: opts.Users(s.outboxName), notify: make(chan struct{}, 1)}
+
+	if token != nil {
+		seq, err := parseToken(token)
+		if err != nil || seq > int64(len(s.history)) {
+			s.mu.Unlock()
+			return nil, nil, mongodb.ErrNotCorrectResumeToken
+		}
+		for _, ev := range s.history[seq:] {
+			if sub.matches(ev) {
+				sub.push(ev)
+			}
+		}
+	}
+
+	if s.subscribers == nil {
+		s.subscribers = map[*subscriber]struct{}{}
+	}
+	s.subscribers[sub] = struct{}{}
+
+	s.mu.Unlock()
+
+	// Send
+	events := make(chan mongodb.ChangeEvent, opts.BufferSize())
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+		defer s.unsubscribe(sub)
+
+		if err := s.deliver(ctx, sub, events, opts.Checkpoints, key); err != nil {
+			errs <- err
+		}
+	}()
+
+	return events, errs, nil
+}
+
+// Send events of subscriber to channel until cancel of context or invalidate. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	sub - subscriber
+//	events - channel of events
+//	checkpoints - store of resume tokens, nil - without store
+//	key - key of resume token
+func (s *memStore) deliver(ctx context.Context, sub *subscriber, events chan<- mongodb.ChangeEvent, checkpoints mongodb.CheckpointStore, key string) error {
+
+	for {
+
+		ev, ok := sub.pop()
+		if !ok {
+			select {
+			case <-sub.notify:
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if checkpoints != nil {
+			if err := checkpoints.Save(ctx, key, ev.Token); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("Function Save, returned error: <%w>", err)
+			}
+		}
+
+		if ev.Operation == mongodb.OperationInvalidate {
+			return nil
+		}
+	}
+}
+
+// Remove subscriber.
+//
+// Params:
+//
+//	sub - subscriber
+func (s *memStore) unsubscribe(sub *subscriber) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscribers, sub)
+}
+
+// Register event. In transaction event is delayed until commit. Lock must be held.
+//
+// Params:
+//
+//	ev - event
+func (s *memStore) emit(ev mongodb.ChangeEvent) {
+
+	if s.inTx {
+		s.pending = append(s.pending, ev)
+		return
+	}
+
+	ev.Token = newToken(int64(len(s.history)) + 1)
+	ev.Time = s.now()
+	s.history = append(s.history, ev)
+
+	for sub := range s.subscribers {
+		if sub.matches(ev) {
+			sub.push(ev)
+		}
+	}
+}
+
+// Register event of insert. Lock must be held.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	doc - inserted document
+func (s *memStore) emitInsert(collectionName string, doc mongodb.DocUser) {
+
+	s.emit(mongodb.ChangeEvent{
+		Operation:    mongodb.OperationInsert,
+		Collection:   collectionName,
+		DocumentKey:  doc.ID,
+		Doc:          &doc,
+		FullDocument: rawDocument(doc),
+	})
+}
+
+// Register event of update, if document is changed. Lock must be held.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	old - document before update
+//	doc - document after update
+func (s *memStore) emitUpdate(collectionName string, old, doc mongodb.DocUser) {
+
+	update := updateDescription(old, doc)
+	if len(update.UpdatedFields) == 0 && len(update.RemovedFields) == 0 {
+		return
+	}
+
+	s.emit(mongodb.ChangeEvent{
+		Operation:    mongodb.OperationUpdate,
+		Collection:   collectionName,
+		DocumentKey:  doc.ID,
+		Doc:          &doc,
+		FullDocument: rawDocument(doc),
+		Update:       &update,
+	})
+}
+
+// Register event of replace. Lock must be held.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	doc - document after replace
+func (s *memStore) emitReplace(collectionName string, doc mongodb.DocUser) {
+
+	s.emit(mongodb.ChangeEvent{
+		Operation:    mongodb.OperationReplace,
+		Collection:   collectionName,
+		DocumentKey:  doc.ID,
+		Doc:          &doc,
+		FullDocument: rawDocument(doc),
+	})
+}
+
+// Register event of delete. Lock must be held.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	id - id of deleted document
+func (s *memStore) emitDelete(collectionName string, id primitive.ObjectID) {
+
+	s.emit(mongodb.ChangeEvent{
+		Operation:   mongodb.OperationDelete,
+		Collection:  collectionName,
+		DocumentKey: id,
+	})
+}
+
+// Register events of drop of collection: drop and invalidate of streams of collection. Lock must be held.
+//
+// Params:
+//
+//	collectionName - name of collection
+func (s *memStore) emitDrop(collectionName string) {
+
+	s.emit(mongodb.ChangeEvent{Operation: mongodb.OperationDrop, Collection: collectionName})
+	s.emit(mongodb.ChangeEvent{Operation: mongodb.OperationInvalidate, Collection: collectionName})
+}
+
+// Changed fields of document, as in event of MongoDB. Returns description.
+//
+// Params:
+//
+//	old - document before update
+//	doc - document after update
+func updateDescription(old, doc mongodb.DocUser) mongodb.UpdateDescription {
+
+	before, after := docFields(old), docFields(doc)
+
+	update := mongodb.UpdateDescription{UpdatedFields: bson.M{}, RemovedFields: []string{}}
+	for k, v := range after {
+		if w, ok := before[k]; !ok || !reflect.DeepEqual(v, w) {
+			update.UpdatedFields[k] = v
+		}
+	}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			update.RemovedFields = append(update.RemovedFields, k)
+		}
+	}
+	sort.Strings(update.RemovedFields)
+
+	return update
+}
+
+// Fields of document, as they are stored. Returns fields.
+//
+// Params:
+//
+//	doc - document
+func docFields(doc mongodb.DocUser) bson.M {
+
+	fields := bson.M{}
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return fields
+	}
+	_ = bson.Unmarshal(data, &fields)
+
+	return fields
+}
+
+// Document, as it is stored. Returns document.
+//
+// Params:
+//
+//	doc - document
+func rawDocument(doc any) bson.Raw {
+
+	data, err := bson.Marshal(doc)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// Resume token by number of event. Returns token.
+//
+// Params:
+//
+//	seq - number of event
+func newToken(seq int64) bson.Raw {
+
+	token, _ := bson.Marshal(bson.D{{Key: "_data", Value: fmt.Sprintf("%016X", seq)}})
+
+	return token
+}
+
+// Number of event by resume token. Returns number and error.
+//
+// Params:
+//
+//	token - resume token
+func parseToken(token bson.Raw) (int64, error) {
+
+	if err := token.Validate(); err != nil {
+		return 0, err
+	}
+
+	data, ok := token.Lookup("_data").StringValueOK()
+	if !ok {
+		return 0, mongodb.ErrNotCorrectResumeToken
+	}
+
+	seq, err := strconv.ParseInt(data, 16, 64)
+	if err != nil || seq < 0 {
+		return 0, mongodb.ErrNotCorrectResumeToken
+	}
+
+	return seq, nil
+}
@@ -3,59 +3,202 @@ package mongodb
 import (
 	"context"
 	"fmt"
-	"strings"
+	"iter"
+	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// Timeout of operation, used when the context has no deadline.
+const defaultTimeout = 3 * time.Second
+
 // Presentation
 type mongoDB struct {
-	connect *mongo.Client
-	nameDB  string
-	db      *mongo.Database
+	connect   *mongo.Client
+	nameDB    string
+	db        *mongo.Database
+	opTimeout time.Duration
+	// Structs of schemas by names of collections
+	schemas map[string]interface{}
+	// Version of documents user is maintained
+	versioning bool
+	// Documents user are deleted softly
+	softDelete bool
+	// Clock of fields of audit, nil - time.Now
+	clock func() time.Time
+	// Settings of transactions
+	txOptions TxOptions
+	// Name of outbox collection, "" - without outbox
+	outbox string
+	// Ensured indexes by names of collections and indexes
+	indexed sync.Map
 }
 
 // Interface
 type MongoDBI interface {
 	// Close db connect.
 	Close() error
+	// Close db connect with context.
+	CloseCtx(ctx context.Context) error
 	// Check-create DB.
 	CheckCreateDB(collections []string) error
+	// Check-create DB with context.
+	CheckCreateDBCtx(ctx context.Context, collections []string) error
 	// Drop collection by name.
 	DropCollection(collectionName string) error
+	// Drop collection by name with context.
+	DropCollectionCtx(ctx context.Context, collectionName string) error
 	// Get names of collections.
 	GetNamesCollections() (names []string, err error)
+	// Get names of collections with context.
+	GetNamesCollectionsCtx(ctx context.Context) (names []string, err error)
 	// Send new document user
-	SendDocumentUser(collectionName string, doc DocUser) (id interface{}, err error)
+	SendDocumentUser(collectionName string, doc DocUser) (id primitive.ObjectID, err error)
+	// Send new document user with context
+	SendDocumentUserCtx(ctx context.Context, collectionName string, doc DocUser) (id primitive.ObjectID, err error)
 	// Update document user by name
 	UpdateDocumentUserByName(collectionName, name string, doc DocUser) (err error)
+	// Update document user by name with context
+	UpdateDocumentUserByNameCtx(ctx context.Context, collectionName, name string, doc DocUser) (err error)
 	// Recieve document user by name
 	RecvDocumentUserByName(collectionName string, name string) (doc DocUser, err error)
+	// Recieve document user by name with context
+	RecvDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (doc DocUser, err error)
 	// Delete document user by name
 	DelDocumentUserByName(collectionName string, name string) (int64, error)
+	// Delete document user by name with context
+	DelDocumentUserByNameCtx(ctx context.Context, collectionName string, name string) (int64, error)
 	// Relocate document
 	MoveDocumentUserTx(srcCollection, destCollection string, doc DocUser) error
+	// Relocate document with context
+	MoveDocumentUserTxCtx(ctx context.Context, srcCollection, destCollection string, doc DocUser) error
+	// Create collections by declarations
+	CreateCollections(ctx context.Context, specs []CollectionSpec) error
+	// Apply $jsonSchema, generated from struct, as validator of collection
+	ApplySchema(ctx context.Context, collectionName string, v interface{}, opts SchemaOptions) error
+	// Delete legacy placeholder documents
+	RemoveInitialDocuments(ctx context.Context, collections []string) (int64, error)
+	// Create indexes of collection
+	EnsureIndexes(ctx context.Context, collectionName string, specs []IndexSpec) error
+	// Get indexes of collection
+	ListIndexes(ctx context.Context, collectionName string) ([]IndexSpec, error)
+	// Drop index of collection by name
+	DropIndex(ctx context.Context, collectionName, name string) error
+	// Synchronize indexes of collection with declarations
+	SyncIndexes(ctx context.Context, collectionName string, specs []IndexSpec, opts IndexSyncOptions) (IndexSyncReport, error)
+	// Find documents user by query
+	FindDocumentsUser(ctx context.Context, collectionName string, q *UserQuery) (docs []DocUser, next string, err error)
+	// Patch document user by name, returns updated document
+	PatchDocumentUserByName(ctx context.Context, collectionName, name string, p *Patch) (DocUser, error)
+	// Replace document user by name or insert it
+	UpsertDocumentUserByName(ctx context.Context, collectionName, name string, doc DocUser) (UpsertResult, error)
+	// Send documents user by one bulk write, with outbox one by one with events
+	SendDocumentsUser(ctx context.Context, collectionName string, docs []DocUser, opts BulkOptions) (BulkResult, error)
+	// Insert imported documents user, fields maintained by adapter are kept
+	InsertImportedDocumentsUser(ctx context.Context, collectionName string, docs []DocUser, opts BulkOptions) (BulkResult, error)
+	// Replace document user by id by imported document, fields maintained by adapter are kept
+	ReplaceImportedDocumentUser(ctx context.Context, collectionName string, id primitive.ObjectID, doc DocUser) error
+	// Update documents user by names by one bulk write
+	UpdateDocumentsUserByName(ctx context.Context, collectionName string, updates []UserUpdate, opts BulkOptions) (BulkResult, error)
+	// Delete documents user by names by one bulk write
+	DelDocumentsUserByName(ctx context.Context, collectionName string, names []string, opts BulkOptions) (BulkResult, error)
+	// Update document user by name, if version is not changed
+	UpdateDocumentUserByNameVersioned(ctx context.Context, collectionName, name string, doc DocUser) (DocUser, error)
+	// Find softly deleted documents user
+	FindDeletedDocumentsUser(ctx context.Context, collectionName string) ([]DocUser, error)
+	// Restore softly deleted document user by name
+	RestoreDocumentUserByName(ctx context.Context, collectionName, name string) error
+	// Remove softly deleted documents user, which are deleted before retention
+	PurgeDeletedDocumentsUser(ctx context.Context, collectionName string, retention time.Duration) (int64, error)
+	// Delete all documents user by name
+	DelAllDocumentsUserByName(ctx context.Context, collectionName, name string, opts DeleteOptions) (int64, error)
+	// Delete all documents user by conditions of query
+	DelDocumentsUserByQuery(ctx context.Context, collectionName string, q *UserQuery, opts DeleteOptions) (int64, error)
+	// Recieve document user by id
+	RecvDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (DocUser, error)
+	// Recieve document user by email
+	RecvDocumentUserByEmail(ctx context.Context, collectionName, email string) (DocUser, error)
+	// Update document user by id
+	UpdateDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID, doc DocUser) error
+	// Update document user by email
+	UpdateDocumentUserByEmail(ctx context.Context, collectionName, email string, doc DocUser) error
+	// Delete document user by id
+	DelDocumentUserByID(ctx context.Context, collectionName string, id primitive.ObjectID) (int64, error)
+	// Delete document user by email
+	DelDocumentUserByEmail(ctx context.Context, collectionName, email string) (int64, error)
+	// Relocate document by id
+	MoveDocumentUserByIDTx(ctx context.Context, srcCollection, destCollection string, id primitive.ObjectID) error
+	// Relocate document by email
+	MoveDocumentUserByEmailTx(ctx context.Context, srcCollection, destCollection, email string) error
+	// Execute operations in transaction
+	WithTransaction(ctx context.Context, fn func(tx Tx) error) error
+	// Watch changes of collection or DB
+	Watch(ctx context.Context, opts WatchOptions) (<-chan ChangeEvent, <-chan error, error)
+	// Claim undelivered events of outbox for delivery
+	ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error)
+	// Mark event of outbox as delivered
+	AckOutboxEvent(ctx context.Context, id primitive.ObjectID) error
+	// Register error of delivery of event of outbox
+	FailOutboxEvent(ctx context.Context, id primitive.ObjectID, cause error, retryAfter time.Duration) error
+	// Remove delivered events of outbox, which are delivered before retention
+	PurgeOutboxEvents(ctx context.Context, retention time.Duration) (int64, error)
+	// Run pipeline of aggregation and decode results to slice
+	Aggregate(ctx context.Context, collectionName string, p *Pipeline, results interface{}) error
+	// Count documents user by buckets of ages
+	UserStatsByAgeBucket(ctx context.Context, collectionName string, boundaries []int) ([]AgeBucket, error)
+	// Count documents user by domains of email
+	UserStatsByEmailDomain(ctx context.Context, collectionName string) ([]DomainCount, error)
+	// Iterate documents user by query
+	IterDocumentsUser(ctx context.Context, collectionName string, q *UserQuery, opts IterOptions) iter.Seq2[DocUser, error]
+	// Iterate results of pipeline of aggregation
+	IterAggregate(ctx context.Context, collectionName string, p *Pipeline, opts IterOptions) iter.Seq2[bson.Raw, error]
 }
 
 // Constructor.
-func New(dsn string) (MongoDBI, error) {
+//
+// Params:
+//
+//	dsn - connection string
+//	opts - options
+func New(dsn string, opts ...Option) (MongoDBI, error) {
+
+	return NewCtx(context.Background(), dsn, opts...)
+}
+
+// Constructor with context.
+//
+// Params:
+//
+//	ctx - context
+//	dsn - connection string
+//	opts - options
+func NewCtx(ctx context.Context, dsn string, opts ...Option) (MongoDBI, error) {
 
 	// Check
 	if dsn == "" {
 		return nil, ErrEmptyValueDSN
 	}
-	parts := strings.Split(dsn, "/")
-	if len(parts) < 2 {
-		return nil, ErrNotCorrectDSN
+
+	cfg, err := newConfig(opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	// Connect
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancel()
+	nameDB, err := parseDSN(dsn, cfg.nameDB)
+	if err != nil {
+		return nil, err
+	}
 
+	// Connect
 	clientOptions := options.Client().ApplyURI(dsn)
+	cfg.apply(clientOptions)
+
+	ctx, cancel := contextWithTimeout(ctx, cfg.dialTimeout(clientOptions))
+	defer cancel()
 
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
@@ -65,16 +208,23 @@ func New(dsn string) (MongoDBI, error) {
 	// Check connect
 	err = client.Ping(ctx, nil)
 	if err != nil {
+		_ = client.Disconnect(context.Background())
 		return nil, fmt.Errorf("Failed to ping MongoDB: %v", err)
 	}
 
 	// Instance
-	nameDB := parts[len(parts)-1]
 	db := client.Database(nameDB)
 
 	return &mongoDB{
-		connect: client,
-		nameDB:  nameDB,
-		db:      db,
+		connect:    client,
+		nameDB:     nameDB,
+		db:         db,
+		opTimeout:  cfg.operationTimeout,
+		schemas:    cfg.schemas,
+		versioning: cfg.versioning,
+		softDelete: cfg.softDelete,
+		clock:      cfg.clock,
+		txOptions:  cfg.txOptions,
+		outbox:     cfg.outbox,
 	}, nil
 }
@@ -1,7 +1,10 @@
 package mongodb
 
 import (
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +19,20 @@ func TestNew(t *testing.T) {
 		require.Equalf(t, ErrEmptyValueDSN, err, "Error is not equal")
 	})
 
+	t.Run("Wrong DSN", func(t *testing.T) {
+		dsn := "mongodb://localhost:27017"
+
+		_, err := New(dsn)
+		require.Truef(t, errors.Is(err, ErrNotCorrectDSN), "Error is not equal")
+	})
+
+	t.Run("Wrong option", func(t *testing.T) {
+		dsn := "mongodb://localhost:27017/myDatabase"
+
+		_, err := New(dsn, WithOperationTimeout(0))
+		require.Equalf(t, ErrValueTimeout, err, "Error is not equal")
+	})
+
 	t.Run("Correct", func(t *testing.T) {
 
 		dsn := "mongodb://localhost:27017/myDatabase"
@@ -28,3 +45,66 @@ func TestNew(t *testing.T) {
 		require.NoErrorf(t, err, "Unexpected error Close")
 	})
 }
+
+// Test NewCtx.
+func TestNewCtx(t *testing.T) {
+
+	t.Run("Missing DSN", func(t *testing.T) {
+		dsn := ""
+
+		_, err := NewCtx(context.Background(), dsn)
+		require.Equalf(t, ErrEmptyValueDSN, err, "Error is not equal")
+	})
+
+	t.Run("Canceled context", func(t *testing.T) {
+
+		dsn := "mongodb://localhost:27017/myDatabase"
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := NewCtx(ctx, dsn)
+		require.Errorf(t, err, "Error is not exists")
+	})
+}
+
+// Test contextWithTimeout.
+func TestContextWithTimeout(t *testing.T) {
+
+	t.Run("Without deadline", func(t *testing.T) {
+
+		ctx, cancel := contextWithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		deadline, ok := ctx.Deadline()
+		require.Truef(t, ok, "Deadline is not exists")
+		require.WithinDurationf(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond, "Deadline is not equal")
+	})
+
+	t.Run("With deadline", func(t *testing.T) {
+
+		parent, parentCancel := context.WithTimeout(context.Background(), time.Minute)
+		defer parentCancel()
+
+		ctx, cancel := contextWithTimeout(parent, time.Second)
+		defer cancel()
+
+		want, _ := parent.Deadline()
+		deadline, ok := ctx.Deadline()
+		require.Truef(t, ok, "Deadline is not exists")
+		require.Equalf(t, want, deadline, "Deadline is not equal")
+	})
+}
+
+// Test withTimeout.
+func TestWithTimeout(t *testing.T) {
+
+	m := &mongoDB{opTimeout: time.Minute}
+
+	ctx, cancel := m.withTimeout(context.Background())
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	require.Truef(t, ok, "Deadline is not exists")
+	require.WithinDurationf(t, time.Now().Add(time.Minute), deadline, 100*time.Millisecond, "Deadline is not equal")
+}
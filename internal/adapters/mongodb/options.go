@@ -0,0 +1,225 @@
+package mongodb
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Settings of connection.
+type config struct {
+	connectTimeout   *time.Duration
+	operationTimeout time.Duration
+	maxPoolSize      *uint64
+	minPoolSize      *uint64
+	appName          string
+	readPreference   *readpref.ReadPref
+	readConcern      *readconcern.ReadConcern
+	writeConcern     *writeconcern.WriteConcern
+	nameDB           string
+	schemas          map[string]interface{}
+	versioning       bool
+	softDelete       bool
+	clock            func() time.Time
+	txOptions        TxOptions
+	outbox           string
+}
+
+// Option of constructor.
+type Option func(*config)
+
+// Set timeout of connect and ping, which overrides connectTimeoutMS from DSN.
+func WithConnectTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.connectTimeout = &timeout
+	}
+}
+
+// Set timeout of operation, used when the context has no deadline.
+func WithOperationTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.operationTimeout = timeout
+	}
+}
+
+// Set maximum size of connection pool.
+func WithMaxPoolSize(size uint64) Option {
+	return func(c *config) {
+		c.maxPoolSize = &size
+	}
+}
+
+// Set minimum size of connection pool.
+func WithMinPoolSize(size uint64) Option {
+	return func(c *config) {
+		c.minPoolSize = &size
+	}
+}
+
+// Set name of application.
+func WithAppName(name string) Option {
+	return func(c *config) {
+		c.appName = name
+	}
+}
+
+// Set read preference.
+func WithReadPreference(rp *readpref.ReadPref) Option {
+	return func(c *config) {
+		c.readPreference = rp
+	}
+}
+
+// Set read concern.
+func WithReadConcern(rc *readconcern.ReadConcern) Option {
+	return func(c *config) {
+		c.readConcern = rc
+	}
+}
+
+// Set write concern.
+func WithWriteConcern(wc *writeconcern.WriteConcern) Option {
+	return func(c *config) {
+		c.writeConcern = wc
+	}
+}
+
+// Set name of DB, which overrides the name from DSN.
+func WithDatabase(name string) Option {
+	return func(c *config) {
+		c.nameDB = name
+	}
+}
+
+// Register struct, which $jsonSchema is applied to collection by CheckCreateDB.
+func WithSchema(collectionName string, v interface{}) Option {
+	return func(c *config) {
+		if c.schemas == nil {
+			c.schemas = map[string]interface{}{}
+		}
+		c.schemas[collectionName] = v
+	}
+}
+
+// Maintain version of documents user, used by UpdateDocumentUserByNameVersioned.
+func WithVersioning() Option {
+	return func(c *config) {
+		c.versioning = true
+	}
+}
+
+// Delete documents user softly: delete sets deletedAt, reads exclude deleted documents.
+// Deleted documents are restored by RestoreDocumentUserByName and removed by PurgeDeletedDocumentsUser.
+func WithSoftDelete() Option {
+	return func(c *config) {
+		c.softDelete = true
+	}
+}
+
+// Set clock of fields of audit, e.g. fixed time in tests.
+func WithClock(clock func() time.Time) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// Set settings of transactions of WithTransaction and relocations of documents.
+func WithTxOptions(opts TxOptions) Option {
+	return func(c *config) {
+		c.txOptions = opts
+	}
+}
+
+// Append events of SendDocumentUser and relocations of documents user to outbox collection
+// in the same transaction. Events are delivered by Relay.
+func WithOutbox(collectionName string) Option {
+	return func(c *config) {
+		c.outbox = collectionName
+	}
+}
+
+// Build settings from options. Returns settings and error.
+//
+// Params:
+//
+//	opts - options
+func newConfig(opts ...Option) (config, error) {
+
+	cfg := config{
+		operationTimeout: defaultTimeout,
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	// Check
+	if (cfg.connectTimeout != nil && *cfg.connectTimeout <= 0) || cfg.operationTimeout <= 0 {
+		return config{}, ErrValueTimeout
+	}
+	if cfg.maxPoolSize != nil && cfg.minPoolSize != nil && *cfg.maxPoolSize != 0 && *cfg.minPoolSize > *cfg.maxPoolSize {
+		return config{}, ErrValuePoolSize
+	}
+	if err := cfg.txOptions.check(); err != nil {
+		return config{}, err
+	}
+	for name, v := range cfg.schemas {
+		if err := CheckSchema(name, v, SchemaOptions{}); err != nil {
+			return config{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Apply settings on client options.
+//
+// Params:
+//
+//	clientOptions - options of client
+func (c config) apply(clientOptions *options.ClientOptions) {
+
+	if c.connectTimeout != nil {
+		clientOptions.SetConnectTimeout(*c.connectTimeout)
+	}
+	if c.maxPoolSize != nil {
+		clientOptions.SetMaxPoolSize(*c.maxPoolSize)
+	}
+	if c.minPoolSize != nil {
+		clientOptions.SetMinPoolSize(*c.minPoolSize)
+	}
+	if c.appName != "" {
+		clientOptions.SetAppName(c.appName)
+	}
+	if c.readPreference != nil {
+		clientOptions.SetReadPreference(c.readPreference)
+	}
+	if c.readConcern != nil {
+		clientOptions.SetReadConcern(c.readConcern)
+	}
+	if c.writeConcern != nil {
+		clientOptions.SetWriteConcern(c.writeConcern)
+	}
+}
+
+// Timeout of connect and ping: WithConnectTimeout, connectTimeoutMS from DSN or default. Returns timeout.
+//
+// Params:
+//
+//	clientOptions - options of client with applied settings
+func (c config) dialTimeout(clientOptions *options.ClientOptions) time.Duration {
+
+	if c.connectTimeout != nil {
+		return *c.connectTimeout
+	}
+	if clientOptions.ConnectTimeout != nil && *clientOptions.ConnectTimeout > 0 {
+		return *clientOptions.ConnectTimeout
+	}
+
+	return defaultTimeout
+}
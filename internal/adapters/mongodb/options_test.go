@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Test newConfig.
+func TestNewConfig(t *testing.T) {
+
+	t.Run("Default", func(t *testing.T) {
+
+		cfg, err := newConfig()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		assert.Nilf(t, cfg.connectTimeout, "Connect timeout is not nil")
+		assert.Equalf(t, defaultTimeout, cfg.operationTimeout, "Operation timeout is not equal")
+		assert.Nilf(t, cfg.maxPoolSize, "Max pool size is not nil")
+		assert.Nilf(t, cfg.minPoolSize, "Min pool size is not nil")
+	})
+
+	t.Run("Wrong timeout", func(t *testing.T) {
+
+		_, err := newConfig(WithOperationTimeout(0))
+		require.Equalf(t, ErrValueTimeout, err, "Error is not equal")
+
+		_, err = newConfig(WithConnectTimeout(-time.Second))
+		require.Equalf(t, ErrValueTimeout, err, "Error is not equal")
+	})
+
+	t.Run("Wrong pool size", func(t *testing.T) {
+
+		_, err := newConfig(WithMaxPoolSize(5), WithMinPoolSize(10))
+		require.Equalf(t, ErrValuePoolSize, err, "Error is not equal")
+	})
+
+	t.Run("Wrong settings of transaction", func(t *testing.T) {
+
+		_, err := newConfig(WithTxOptions(TxOptions{MaxRetries: -1}))
+		require.Equalf(t, ErrValueTxOptions, err, "Error is not equal")
+
+		_, err = newConfig(WithTxOptions(TxOptions{MaxCommitTime: -time.Second}))
+		require.Equalf(t, ErrValueTxOptions, err, "Error is not equal")
+	})
+
+	t.Run("Wrong schema", func(t *testing.T) {
+
+		_, err := newConfig(WithSchema("users", "user"))
+		require.Equalf(t, ErrNotStructType, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		cfg, err := newConfig(
+			WithConnectTimeout(5*time.Second),
+			WithOperationTimeout(time.Second),
+			WithMaxPoolSize(20),
+			WithMinPoolSize(2),
+			WithAppName("app"),
+			WithReadPreference(readpref.SecondaryPreferred()),
+			WithWriteConcern(writeconcern.Majority()),
+			WithSchema("users", DocUser{}),
+			WithVersioning(),
+			WithSoftDelete(),
+			WithClock(func() time.Time { return time.Unix(100, 0) }),
+			WithTxOptions(TxOptions{MaxRetries: 5}),
+			WithOutbox("outbox"),
+		)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, map[string]interface{}{"users": DocUser{}}, cfg.schemas, "Schemas are not equal")
+		assert.Truef(t, cfg.versioning, "Versioning is disabled")
+		assert.Truef(t, cfg.softDelete, "Soft delete is disabled")
+		require.NotNilf(t, cfg.clock, "Clock is nil")
+		assert.Equalf(t, time.Unix(100, 0), cfg.clock(), "Clock is not equal")
+		assert.Equalf(t, TxOptions{MaxRetries: 5}, cfg.txOptions, "Settings of transaction are not equal")
+		assert.Equalf(t, "outbox", cfg.outbox, "Outbox is not equal")
+
+		clientOptions := options.Client()
+		cfg.apply(clientOptions)
+
+		assert.Equalf(t, 5*time.Second, *clientOptions.ConnectTimeout, "Connect timeout is not equal")
+		assert.Equalf(t, time.Second, cfg.operationTimeout, "Operation timeout is not equal")
+		assert.Equalf(t, uint64(20), *clientOptions.MaxPoolSize, "Max pool size is not equal")
+		assert.Equalf(t, uint64(2), *clientOptions.MinPoolSize, "Min pool size is not equal")
+		assert.Equalf(t, "app", *clientOptions.AppName, "App name is not equal")
+		assert.Equalf(t, readpref.SecondaryPreferred(), clientOptions.ReadPreference, "Read preference is not equal")
+		assert.Equalf(t, writeconcern.Majority(), clientOptions.WriteConcern, "Write concern is not equal")
+	})
+	t.Run("Connect timeout of DSN", func(t *testing.T) {
+
+		cfg, err := newConfig()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		clientOptions := options.Client().ApplyURI("mongodb://localhost:27017/?connectTimeoutMS=3000")
+		cfg.apply(clientOptions)
+
+		require.NotNilf(t, clientOptions.ConnectTimeout, "Connect timeout is not set")
+		assert.Equalf(t, 3*time.Second, *clientOptions.ConnectTimeout, "Connect timeout is not equal")
+		assert.Equalf(t, 3*time.Second, cfg.dialTimeout(clientOptions), "Timeout of connect is not equal")
+
+		assert.Equalf(t, defaultTimeout, cfg.dialTimeout(options.Client()), "Timeout of connect is not equal")
+
+		cfg, err = newConfig(WithConnectTimeout(5 * time.Second))
+		require.NoErrorf(t, err, "Unexpected error")
+		cfg.apply(clientOptions)
+
+		assert.Equalf(t, 5*time.Second, *clientOptions.ConnectTimeout, "Connect timeout is not equal")
+		assert.Equalf(t, 5*time.Second, cfg.dialTimeout(clientOptions), "Timeout of connect is not equal")
+	})
+}
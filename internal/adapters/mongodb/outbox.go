@@ -0,0 +1,315 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Types of events of documents user.
+const (
+	// Document user is inserted by SendDocumentUser
+	EventUserCreated = "user.created"
+	// Document user is relocated to other collection
+	EventUserMoved = "user.moved"
+)
+
+// Settings of outbox of repository.
+type OutboxConfig struct {
+	// Name of outbox collection
+	Collection string
+	// Type of event of insert
+	Created string
+	// Type of event of relocation
+	Moved string
+}
+
+// Event of outbox.
+type OutboxEvent struct {
+	// Id of event, events are delivered in order of ids
+	ID primitive.ObjectID `bson:"_id"`
+	// Type of event
+	Type string `bson:"type"`
+	// Name of collection of document
+	Collection string `bson:"collection"`
+	// Name of source collection of relocation
+	Source string `bson:"source,omitempty"`
+	// Id of document
+	DocumentID primitive.ObjectID `bson:"documentId"`
+	// Document after change
+	Payload bson.Raw `bson:"payload"`
+	// Time of creation of event
+	CreatedAt time.Time `bson:"createdAt"`
+	// Count of claims for delivery
+	Attempts int `bson:"attempts"`
+	// End of lease of delivery, after it event is claimed again
+	LockedUntil time.Time `bson:"lockedUntil,omitempty"`
+	// Time of delivery. Zero - event is not delivered
+	DeliveredAt time.Time `bson:"deliveredAt,omitempty"`
+	// Error of last delivery
+	LastError string `bson:"lastError,omitempty"`
+}
+
+// Decode payload of event. Returns error.
+//
+// Params:
+//
+//	v - pointer to document
+func (e OutboxEvent) Decode(v interface{}) error {
+
+	if err := bson.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+	}
+
+	return nil
+}
+
+// Document with _id, generated if it is missing. Returns document and error.
+//
+// Params:
+//
+//	doc - document
+func withID(doc interface{}) (bson.D, error) {
+
+	d, err := marshalDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range d {
+		if e.Key == "_id" {
+			return d, nil
+		}
+	}
+
+	return append(bson.D{{Key: "_id", Value: primitive.NewObjectID()}}, d...), nil
+}
+
+// Append event of document to outbox in transaction of context. Returns error.
+//
+// Params:
+//
+//	ctx - context with session
+//	eventType - type of event
+//	collectionName - name of collection of document
+//	source - name of source collection of relocation, "" - for other events
+//	doc - document with _id
+func (r *Repository[T]) appendOutbox(ctx context.Context, eventType, collectionName, source string, doc interface{}) error {
+
+	payload, err := bson.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("Function Marshal, returned error: <%w>", err)
+	}
+
+	ev := OutboxEvent{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		Collection: collectionName,
+		Source:     source,
+		Payload:    payload,
+		CreatedAt:  r.now(),
+	}
+	ev.DocumentID, _ = bson.Raw(payload).Lookup("_id").ObjectIDOK()
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if _, err := r.m.db.Collection(r.cfg.Outbox.Collection).InsertOne(ctx, ev); err != nil {
+		return fmt.Errorf("Function InsertOne, returned error: <%w>", err)
+	}
+
+	return nil
+}
+
+// Check connection and outbox. Returns error.
+func (m *mongoDB) checkOutbox() error {
+
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	if m.connect == nil {
+		return ErrNilPtrConnect
+	}
+	if m.outbox == "" {
+		return ErrNotOutbox
+	}
+
+	return nil
+}
+
+// Claim undelivered events of outbox for delivery, in order of ids. Claimed event is not claimed
+// again until end of lease. Returns events and error.
+//
+// Params:
+//
+//	ctx - context
+//	limit - maximum count of events
+//	lease - time of lease of delivery
+func (m *mongoDB) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+
+	// Check
+	if err := m.checkOutbox(); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || lease <= 0 {
+		return nil, ErrValueRelayOptions
+	}
+
+	// Logic
+	collection := m.db.Collection(m.outbox)
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	events := []OutboxEvent{}
+	for len(events) < limit {
+
+		now := m.now()
+		filter := bson.M{
+			"deliveredAt": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"lockedUntil": bson.M{"$exists": false}},
+				bson.M{"lockedUntil": bson.M{"$lte": now}},
+			},
+		}
+		update := bson.M{
+			"$set": bson.M{"lockedUntil": now.Add(lease)},
+			"$inc": bson.M{"attempts": 1},
+		}
+		opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "_id", Value: 1}}).SetReturnDocument(options.After)
+
+		// Each claim is atomic, so concurrent relays do not share events
+		var ev OutboxEvent
+		err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&ev)
+		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				break
+			}
+			return nil, fmt.Errorf("Function FindOneAndUpdate, returned error: <%w>", err)
+		}
+		events = append(events, ev)
+	}
+
+	return events, nil
+}
+
+// Mark event of outbox as delivered. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+func (m *mongoDB) AckOutboxEvent(ctx context.Context, id primitive.ObjectID) error {
+
+	// Check
+	if err := m.checkOutbox(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return ErrEmptyID
+	}
+
+	// Logic
+	update := bson.M{
+		"$set":   bson.M{"deliveredAt": m.now()},
+		"$unset": bson.M{"lockedUntil": "", "lastError": ""},
+	}
+
+	return m.updateOutboxEvent(ctx, id, update)
+}
+
+// Register error of delivery of event of outbox. Event is claimed again after delay. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+//	cause - error of delivery
+//	retryAfter - delay of next delivery
+func (m *mongoDB) FailOutboxEvent(ctx context.Context, id primitive.ObjectID, cause error, retryAfter time.Duration) error {
+
+	// Check
+	if err := m.checkOutbox(); err != nil {
+		return err
+	}
+	if id.IsZero() {
+		return ErrEmptyID
+	}
+	if retryAfter < 0 {
+		return ErrValueRelayOptions
+	}
+
+	// Logic
+	set := bson.M{"lockedUntil": m.now().Add(retryAfter)}
+	if cause != nil {
+		set["lastError"] = cause.Error()
+	}
+
+	return m.updateOutboxEvent(ctx, id, bson.M{"$set": set})
+}
+
+// Update undelivered event of outbox. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	id - id of event
+//	update - document of update
+func (m *mongoDB) updateOutboxEvent(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+
+	collection := m.db.Collection(m.outbox)
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{"_id": id, "deliveredAt": bson.M{"$exists": false}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUpdateDocument
+	}
+
+	return nil
+}
+
+// Remove delivered events of outbox, which are delivered before retention from now.
+// Returns count removed events and error.
+//
+// Params:
+//
+//	ctx - context
+//	retention - time of keeping of delivered events, 0 - all delivered events
+func (m *mongoDB) PurgeOutboxEvents(ctx context.Context, retention time.Duration) (int64, error) {
+
+	// Check
+	if err := m.checkOutbox(); err != nil {
+		return 0, err
+	}
+	if retention < 0 {
+		return 0, ErrNegativeRetention
+	}
+
+	// Logic
+	collection := m.db.Collection(m.outbox)
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	filter := bson.M{"deliveredAt": bson.M{"$lte": m.now().Add(-retention)}}
+
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+
+	return result.DeletedCount, nil
+}
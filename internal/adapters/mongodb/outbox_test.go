@@ -0,0 +1,172 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Test withID
+func TestWithID(t *testing.T) {
+
+	t.Run("Generated id", func(t *testing.T) {
+
+		d, err := withID(DocUser{Name: "Anna", Age: 25})
+		require.NoErrorf(t, err, "Unexpected error")
+		require.Equalf(t, "_id", d[0].Key, "Key is not equal")
+
+		id, ok := d[0].Value.(primitive.ObjectID)
+		require.Truef(t, ok, "Id is not ObjectID")
+		assert.Falsef(t, id.IsZero(), "Id is zero")
+		assert.Equalf(t, bson.D{{Key: "name", Value: "Anna"}, {Key: "age", Value: int32(25)}}, d[1:], "Document is not equal")
+	})
+
+	t.Run("Given id", func(t *testing.T) {
+
+		id := primitive.NewObjectID()
+
+		d, err := withID(DocUser{ID: id, Name: "Anna", Age: 25})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "Anna"}, {Key: "age", Value: int32(25)}}, d, "Document is not equal")
+	})
+}
+
+// Test OutboxEvent.Decode
+func TestOutboxEventDecode(t *testing.T) {
+
+	id := primitive.NewObjectID()
+	payload, err := bson.Marshal(DocUser{ID: id, Name: "Anna", Age: 25})
+	require.NoErrorf(t, err, "Unexpected error")
+
+	var doc DocUser
+	require.NoErrorf(t, OutboxEvent{Payload: payload}.Decode(&doc), "Unexpected error")
+	assert.Equalf(t, DocUser{ID: id, Name: "Anna", Age: 25}, doc, "Document is not equal")
+
+	require.Errorf(t, OutboxEvent{}.Decode(&doc), "Error is not exists")
+}
+
+// Test outbox without connection or settings
+func TestOutboxChecks(t *testing.T) {
+
+	ctx := context.Background()
+
+	_, err := (&mongoDB{}).ClaimOutboxEvents(ctx, 1, time.Minute)
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	// Client is not connected until first operation
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI("mongodb://localhost:27017"))
+	require.NoErrorf(t, err, "Unexpected error")
+	t.Cleanup(func() { _ = client.Disconnect(ctx) })
+
+	m := &mongoDB{db: client.Database("myDatabase"), connect: client}
+
+	_, err = m.ClaimOutboxEvents(ctx, 1, time.Minute)
+	require.Equalf(t, ErrNotOutbox, err, "Error is not equal")
+
+	m.outbox = "outbox"
+
+	_, err = m.ClaimOutboxEvents(ctx, 0, time.Minute)
+	require.Equalf(t, ErrValueRelayOptions, err, "Error is not equal")
+
+	err = m.AckOutboxEvent(ctx, primitive.NilObjectID)
+	require.Equalf(t, ErrEmptyID, err, "Error is not equal")
+
+	err = m.FailOutboxEvent(ctx, primitive.NewObjectID(), nil, -time.Second)
+	require.Equalf(t, ErrValueRelayOptions, err, "Error is not equal")
+
+	_, err = m.PurgeOutboxEvents(ctx, -time.Second)
+	require.Equalf(t, ErrNegativeRetention, err, "Error is not equal")
+}
+
+// Test NewRelay
+func TestNewRelay(t *testing.T) {
+
+	pub := PublisherFunc(func(ctx context.Context, ev OutboxEvent) error { return nil })
+
+	t.Run("Defaults", func(t *testing.T) {
+
+		r, err := NewRelay(&mongoDB{}, pub, RelayOptions{})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, RelayOptions{
+			Interval:   defaultRelayInterval,
+			BatchSize:  defaultRelayBatchSize,
+			Lease:      defaultRelayLease,
+			RetryAfter: defaultRelayRetryAfter,
+		}, r.opts, "Settings are not equal")
+	})
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		_, err := NewRelay(nil, pub, RelayOptions{})
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+		_, err = NewRelay(&mongoDB{}, nil, RelayOptions{})
+		require.Equalf(t, ErrNilPublisher, err, "Error is not equal")
+
+		for _, opts := range []RelayOptions{{Interval: -1}, {BatchSize: -1}, {Lease: -1}, {RetryAfter: -1}, {Retention: -1}} {
+			_, err = NewRelay(&mongoDB{}, pub, opts)
+			require.Equalf(t, ErrValueRelayOptions, err, "Error is not equal")
+		}
+	})
+}
+
+// Connection with stream of changes, which is closed with error.
+type brokenWatchDB struct {
+	MongoDBI
+	err error
+}
+
+// Claim events of outbox. Returns no events.
+func (db brokenWatchDB) ClaimOutboxEvents(ctx context.Context, limit int, lease time.Duration) ([]OutboxEvent, error) {
+
+	return nil, nil
+}
+
+// Watch changes. Returns closed stream with error.
+func (db brokenWatchDB) Watch(ctx context.Context, opts WatchOptions) (<-chan ChangeEvent, <-chan error, error) {
+
+	events := make(chan ChangeEvent)
+	errs := make(chan error, 1)
+	if db.err != nil {
+		errs <- db.err
+	}
+	close(events)
+	close(errs)
+
+	return events, errs, nil
+}
+
+// Test Relay.Run with closed stream of changes
+func TestRelayRunClosedWatch(t *testing.T) {
+
+	pub := PublisherFunc(func(ctx context.Context, ev OutboxEvent) error { return nil })
+	errStream := errors.New("stream")
+
+	t.Run("Error of stream", func(t *testing.T) {
+
+		r, err := NewRelay(brokenWatchDB{err: errStream}, pub, RelayOptions{Interval: time.Hour, WatchCollection: "outbox"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		err = r.Run(context.Background())
+		require.Truef(t, errors.Is(err, errStream), "Error is not equal")
+	})
+
+	t.Run("Without error", func(t *testing.T) {
+
+		r, err := NewRelay(brokenWatchDB{}, pub, RelayOptions{Interval: 10 * time.Millisecond, WatchCollection: "outbox"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		require.NoErrorf(t, r.Run(ctx), "Unexpected error")
+	})
+}
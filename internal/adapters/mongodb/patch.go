@@ -0,0 +1,354 @@
+package mongodb
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Operations of patch.
+const (
+	PatchSet    = "$set"
+	PatchUnset  = "$unset"
+	PatchInc    = "$inc"
+	PatchPush   = "$push"
+	PatchRename = "$rename"
+)
+
+// Order of operations in update.
+var patchOps = []string{PatchSet, PatchUnset, PatchInc, PatchPush, PatchRename}
+
+// Operation of patch with field.
+type PatchField struct {
+	// Operation, e.g. PatchSet
+	Op string
+	// BSON name of field
+	Field string
+	// Value of operation. New BSON name of field for PatchRename, nil for PatchUnset
+	Value interface{}
+}
+
+// Specification of patch.
+type PatchSpec struct {
+	// Operations in order of adding
+	Fields []PatchField
+}
+
+// Builder of patch. Fields, which are not added, are not changed.
+type Patch struct {
+	spec PatchSpec
+	err  error
+}
+
+// Constructor of patch.
+func NewPatch() *Patch {
+
+	return &Patch{}
+}
+
+// Add operation. Returns patch.
+//
+// Params:
+//
+//	op - operation
+//	field - BSON name of field
+//	value - value of operation
+func (p *Patch) add(op, field string, value interface{}) *Patch {
+
+	if (field == "" || field == "_id") && p.err == nil {
+		p.err = fmt.Errorf("%w: field <%s>", ErrNotCorrectPatch, field)
+	}
+
+	p.spec.Fields = append(p.spec.Fields, PatchField{Op: op, Field: field, Value: value})
+	return p
+}
+
+// Set value of field. Zero value is set too.
+func (p *Patch) Set(field string, value interface{}) *Patch {
+
+	if value == nil && p.err == nil {
+		p.err = fmt.Errorf("%w: nil value of field <%s>, use Unset", ErrNotCorrectPatch, field)
+	}
+
+	return p.add(PatchSet, field, value)
+}
+
+// Remove field.
+func (p *Patch) Unset(field string) *Patch {
+
+	return p.add(PatchUnset, field, nil)
+}
+
+// Increment numeric field by n.
+func (p *Patch) Inc(field string, n interface{}) *Patch {
+
+	if !isNumber(reflect.TypeOf(n)) && p.err == nil {
+		p.err = fmt.Errorf("%w: not numeric increment of field <%s>", ErrNotCorrectPatch, field)
+	}
+
+	return p.add(PatchInc, field, n)
+}
+
+// Append value to array field.
+func (p *Patch) Push(field string, value interface{}) *Patch {
+
+	return p.add(PatchPush, field, value)
+}
+
+// Rename field.
+func (p *Patch) Rename(field, to string) *Patch {
+
+	if (to == "" || to == "_id") && p.err == nil {
+		p.err = fmt.Errorf("%w: field <%s>", ErrNotCorrectPatch, to)
+	}
+
+	return p.add(PatchRename, field, to)
+}
+
+// Specification of patch. Returns specification and error.
+func (p *Patch) Spec() (PatchSpec, error) {
+
+	if p == nil || len(p.spec.Fields) == 0 {
+		return PatchSpec{}, ErrEmptyPatch
+	}
+	if p.err != nil {
+		return PatchSpec{}, p.err
+	}
+
+	// Each field is changed by one operation only
+	seen := map[string]bool{}
+	for _, f := range p.spec.Fields {
+
+		paths := []string{f.Field}
+		if f.Op == PatchRename {
+			paths = append(paths, f.Value.(string))
+		}
+
+		for _, path := range paths {
+			if seen[path] {
+				return PatchSpec{}, fmt.Errorf("%w: conflict of field <%s>", ErrNotCorrectPatch, path)
+			}
+			seen[path] = true
+		}
+	}
+
+	spec := PatchSpec{Fields: make([]PatchField, len(p.spec.Fields))}
+	copy(spec.Fields, p.spec.Fields)
+
+	return spec, nil
+}
+
+// Document of update. Returns document.
+func (s PatchSpec) Update() bson.D {
+
+	update := bson.D{}
+
+	for _, op := range patchOps {
+
+		fields := bson.D{}
+		for _, f := range s.Fields {
+			if f.Op != op {
+				continue
+			}
+			if op == PatchUnset {
+				fields = append(fields, bson.E{Key: f.Field, Value: ""})
+				continue
+			}
+			fields = append(fields, bson.E{Key: f.Field, Value: f.Value})
+		}
+
+		if len(fields) > 0 {
+			update = append(update, bson.E{Key: op, Value: fields})
+		}
+	}
+
+	return update
+}
+
+// Check patch for document with type T, normalize values of $set and check rules of touched fields.
+// Returns specification with converted values, errors of fields and error of patch.
+//
+// Params:
+//
+//	spec - specification
+//	normalize - normalization of document, nil - without normalization
+func preparePatch[T any](spec PatchSpec, normalize func(T) T) (PatchSpec, []FieldError, error) {
+
+	var doc T
+
+	t := reflect.TypeOf(doc)
+	if t == nil || t.Kind() != reflect.Struct {
+		return spec, nil, ErrNotStructType
+	}
+	rv := reflect.ValueOf(&doc).Elem()
+
+	// Fields and types
+	for _, f := range spec.Fields {
+
+		i, ok := bsonFieldIndex(t, f.Field)
+		if !ok {
+			return spec, nil, fmt.Errorf("%w: %s", ErrUnknownField, f.Field)
+		}
+		ft := t.Field(i).Type
+
+		switch f.Op {
+		case PatchSet:
+			v, ok := convertValue(ft, f.Value)
+			if !ok {
+				return spec, nil, fmt.Errorf("%w: value %T of field <%s>", ErrNotCorrectPatch, f.Value, f.Field)
+			}
+			rv.Field(i).Set(v)
+
+		case PatchInc:
+			vt := reflect.TypeOf(f.Value)
+			if !isNumber(ft) || !isNumber(vt) || (isInteger(ft) && !isInteger(vt)) {
+				return spec, nil, fmt.Errorf("%w: increment %T of field <%s>", ErrNotCorrectPatch, f.Value, f.Field)
+			}
+
+		case PatchPush:
+			if ft.Kind() != reflect.Slice {
+				return spec, nil, fmt.Errorf("%w: field <%s> is not array", ErrNotCorrectPatch, f.Field)
+			}
+			if _, ok := convertValue(ft.Elem(), f.Value); !ok {
+				return spec, nil, fmt.Errorf("%w: value %T of field <%s>", ErrNotCorrectPatch, f.Value, f.Field)
+			}
+
+		case PatchRename:
+			to := f.Value.(string)
+			j, ok := bsonFieldIndex(t, to)
+			if !ok {
+				return spec, nil, fmt.Errorf("%w: %s", ErrUnknownField, to)
+			}
+			if t.Field(j).Type != ft {
+				return spec, nil, fmt.Errorf("%w: types of fields <%s> and <%s>", ErrNotCorrectPatch, f.Field, to)
+			}
+
+		case PatchUnset:
+
+		default:
+			return spec, nil, fmt.Errorf("%w: operation <%s>", ErrNotCorrectPatch, f.Op)
+		}
+	}
+
+	// Normalization of set values
+	if normalize != nil {
+		doc = normalize(doc)
+		rv = reflect.ValueOf(&doc).Elem()
+	}
+
+	// Rules of touched fields
+	out := PatchSpec{Fields: make([]PatchField, 0, len(spec.Fields))}
+	var errs []FieldError
+
+	for _, f := range spec.Fields {
+
+		i, _ := bsonFieldIndex(t, f.Field)
+
+		rules, err := parseRules(t.Field(i).Tag.Get("validate"))
+		if err != nil {
+			return spec, nil, err
+		}
+
+		switch f.Op {
+		case PatchSet:
+			f.Value = rv.Field(i).Interface()
+			if detail := checkRules(rv.Field(i), rules); detail != "" {
+				errs = append(errs, FieldError{Field: f.Field, Detail: detail, Err: ErrValueField})
+			}
+		case PatchUnset, PatchRename:
+			if rules.required {
+				errs = append(errs, FieldError{Field: f.Field, Detail: "is required", Err: ErrValueField})
+			}
+		}
+
+		out.Fields = append(out.Fields, f)
+	}
+
+	return out, errs, nil
+}
+
+// Check patch of document user. Returns specification with normalized values and error.
+//
+// Params:
+//
+//	spec - specification
+func PrepareDocUserPatch(spec PatchSpec) (PatchSpec, error) {
+
+	out, errs, err := preparePatch(spec, NormalizeDocUser)
+	if err != nil {
+		return spec, err
+	}
+	if err := docUserValidationError(errs); err != nil {
+		return spec, err
+	}
+
+	return out, nil
+}
+
+// Convert value to type of field. Returns value and flag of success.
+//
+// Params:
+//
+//	t - type of field
+//	value - value
+func convertValue(t reflect.Type, value interface{}) (reflect.Value, bool) {
+
+	if value == nil {
+		return reflect.Value{}, false
+	}
+	v := reflect.ValueOf(value)
+
+	switch {
+	case v.Type().AssignableTo(t):
+		return v, true
+	case isNumber(t) && isNumber(v.Type()) && (!isInteger(t) || isInteger(v.Type())):
+		return v.Convert(t), true
+	case t.Kind() == reflect.String && v.Kind() == reflect.String:
+		return v.Convert(t), true
+	case t.Kind() == reflect.Pointer:
+		e, ok := convertValue(t.Elem(), value)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		p := reflect.New(t.Elem())
+		p.Elem().Set(e)
+		return p, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// Check numeric type. Returns flag.
+//
+// Params:
+//
+//	t - type
+func isNumber(t reflect.Type) bool {
+
+	if t == nil {
+		return false
+	}
+
+	return isInteger(t) || t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64
+}
+
+// Check integer type. Returns flag.
+//
+// Params:
+//
+//	t - type
+func isInteger(t reflect.Type) bool {
+
+	if t == nil {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
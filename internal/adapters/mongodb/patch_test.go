@@ -0,0 +1,124 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Test Patch
+func TestPatch(t *testing.T) {
+
+	t.Run("Empty", func(t *testing.T) {
+
+		_, err := NewPatch().Spec()
+		require.Equalf(t, ErrEmptyPatch, err, "Error is not equal")
+
+		var p *Patch
+		_, err = p.Spec()
+		require.Equalf(t, ErrEmptyPatch, err, "Error is not equal")
+	})
+
+	t.Run("Not correct", func(t *testing.T) {
+
+		tests := map[string]*Patch{
+			"Id":          NewPatch().Set("_id", 1),
+			"Nil value":   NewPatch().Set("email", nil),
+			"Increment":   NewPatch().Inc("age", "1"),
+			"Rename":      NewPatch().Rename("email", ""),
+			"Conflict":    NewPatch().Set("age", 1).Inc("age", 1),
+			"Rename into": NewPatch().Set("name", "A").Rename("email", "name"),
+		}
+
+		for name, p := range tests {
+			t.Run(name, func(t *testing.T) {
+
+				_, err := p.Spec()
+				require.Truef(t, errors.Is(err, ErrNotCorrectPatch), "Error is not equal")
+			})
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		spec, err := NewPatch().Inc("age", 1).Set("name", "A").Unset("email").Push("tags", "x").Rename("a", "b").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "name", Value: "A"}}},
+			{Key: "$unset", Value: bson.D{{Key: "email", Value: ""}}},
+			{Key: "$inc", Value: bson.D{{Key: "age", Value: 1}}},
+			{Key: "$push", Value: bson.D{{Key: "tags", Value: "x"}}},
+			{Key: "$rename", Value: bson.D{{Key: "a", Value: "b"}}},
+		}
+		assert.Equalf(t, want, spec.Update(), "Update is not equal")
+	})
+}
+
+// Test PrepareDocUserPatch
+func TestPrepareDocUserPatch(t *testing.T) {
+
+	prepare := func(t *testing.T, p *Patch) (PatchSpec, error) {
+
+		spec, err := p.Spec()
+		require.NoErrorf(t, err, "Unexpected error Spec")
+
+		return PrepareDocUserPatch(spec)
+	}
+
+	t.Run("Unknown field", func(t *testing.T) {
+
+		_, err := prepare(t, NewPatch().Unset("phone"))
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+		_, err = prepare(t, NewPatch().Rename("email", "phone"))
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Wrong types", func(t *testing.T) {
+
+		tests := map[string]*Patch{
+			"Set":       NewPatch().Set("age", "31"),
+			"Set float": NewPatch().Set("age", 1.5),
+			"Inc":       NewPatch().Inc("email", 1),
+			"Inc float": NewPatch().Inc("age", 0.5),
+			"Push":      NewPatch().Push("name", "A"),
+			"Rename":    NewPatch().Rename("age", "email"),
+		}
+
+		for name, p := range tests {
+			t.Run(name, func(t *testing.T) {
+
+				_, err := prepare(t, p)
+				require.Truef(t, errors.Is(err, ErrNotCorrectPatch), "Error is not equal")
+			})
+		}
+	})
+
+	t.Run("Touched fields", func(t *testing.T) {
+
+		_, err := prepare(t, NewPatch().Set("email", "bad").Unset("name"))
+
+		var verr *ValidationError
+		require.Truef(t, errors.As(err, &verr), "Error is not ValidationError")
+		require.Lenf(t, verr.Fields, 2, "Count of fields is not equal")
+		assert.Truef(t, errors.Is(err, ErrValueEmail), "Error is not equal")
+		assert.Truef(t, errors.Is(err, ErrValueName), "Error is not equal")
+		assert.Falsef(t, errors.Is(err, ErrValueAge), "Untouched field is checked")
+	})
+
+	t.Run("Normalization", func(t *testing.T) {
+
+		spec, err := prepare(t, NewPatch().Set("email", " A@MAIL.com ").Set("age", int64(40)))
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := PatchSpec{Fields: []PatchField{
+			{Op: PatchSet, Field: "email", Value: "a@mail.com"},
+			{Op: PatchSet, Field: "age", Value: 40},
+		}}
+		assert.Equalf(t, want, spec, "Specification is not equal")
+	})
+}
@@ -0,0 +1,367 @@
+package mongodb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Stages of aggregation.
+const (
+	StageMatch   = "$match"
+	StageGroup   = "$group"
+	StageSort    = "$sort"
+	StageProject = "$project"
+	StageLookup  = "$lookup"
+	StageUnwind  = "$unwind"
+	StageFacet   = "$facet"
+	StageBucket  = "$bucket"
+	StageSkip    = "$skip"
+	StageLimit   = "$limit"
+)
+
+// Accumulator of group or bucket.
+type Accumulator struct {
+	// Operator, e.g. $sum
+	Op string
+	// Expression of values
+	Expr interface{}
+}
+
+// Accumulators by names of output fields.
+type Accumulators map[string]Accumulator
+
+// Sum of values of expression.
+func Sum(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$sum", Expr: expr}
+}
+
+// Count of documents.
+func Count() Accumulator {
+
+	return Sum(1)
+}
+
+// Average of values of expression.
+func Avg(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$avg", Expr: expr}
+}
+
+// Minimum of values of expression.
+func Min(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$min", Expr: expr}
+}
+
+// Maximum of values of expression.
+func Max(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$max", Expr: expr}
+}
+
+// Value of expression of the first document.
+func First(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$first", Expr: expr}
+}
+
+// Value of expression of the last document.
+func Last(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$last", Expr: expr}
+}
+
+// Array of values of expression.
+func Push(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$push", Expr: expr}
+}
+
+// Array of unique values of expression.
+func AddToSet(expr interface{}) Accumulator {
+
+	return Accumulator{Op: "$addToSet", Expr: expr}
+}
+
+// Expression of value of field.
+func Field(name string) string {
+
+	return "$" + name
+}
+
+// Document of accumulators in order of names. Returns document and error.
+func (a Accumulators) doc() (bson.D, error) {
+
+	names := make([]string, 0, len(a))
+	for name := range a {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	doc := bson.D{}
+	for _, name := range names {
+		if name == "" || name == "_id" || strings.ContainsAny(name, ".$") {
+			return nil, fmt.Errorf("%w: output field <%s>", ErrNotCorrectPipeline, name)
+		}
+		if a[name].Op == "" {
+			return nil, fmt.Errorf("%w: empty accumulator of field <%s>", ErrNotCorrectPipeline, name)
+		}
+		doc = append(doc, bson.E{Key: name, Value: bson.D{{Key: a[name].Op, Value: a[name].Expr}}})
+	}
+
+	return doc, nil
+}
+
+// Builder of pipeline of aggregation. Stages are executed in order of adding.
+type Pipeline struct {
+	stages mongo.Pipeline
+	err    error
+}
+
+// Constructor of pipeline of aggregation.
+func NewPipeline() *Pipeline {
+
+	return &Pipeline{}
+}
+
+// Register the first error of building. Returns pipeline.
+//
+// Params:
+//
+//	format - format of detail
+//	args - arguments of detail
+func (p *Pipeline) fail(format string, args ...interface{}) *Pipeline {
+
+	if p.err == nil {
+		p.err = fmt.Errorf("%w: "+format, append([]interface{}{ErrNotCorrectPipeline}, args...)...)
+	}
+
+	return p
+}
+
+// Add stage. Returns pipeline.
+//
+// Params:
+//
+//	stage - operator of stage
+//	value - value of stage
+func (p *Pipeline) add(stage string, value interface{}) *Pipeline {
+
+	p.stages = append(p.stages, bson.D{{Key: stage, Value: value}})
+	return p
+}
+
+// Keep documents, which match filter.
+func (p *Pipeline) Match(filter interface{}) *Pipeline {
+
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	return p.add(StageMatch, filter)
+}
+
+// Keep documents user, which match conditions of query. Query must have no sorting, projection or pagination.
+func (p *Pipeline) MatchUsers(q *UserQuery) *Pipeline {
+
+	spec, err := q.Spec()
+	if err != nil {
+		if p.err == nil {
+			p.err = err
+		}
+		return p
+	}
+	if len(spec.Sort) > 0 || len(spec.Fields) > 0 || spec.Skip > 0 || spec.Limit > 0 || spec.After != nil {
+		return p.fail("query of match has sorting, projection or pagination")
+	}
+
+	return p.Match(spec.filter())
+}
+
+// Group documents by expression of id and calculate fields by accumulators. Nil id - one group of all documents.
+func (p *Pipeline) Group(id interface{}, fields Accumulators) *Pipeline {
+
+	doc, err := fields.doc()
+	if err != nil {
+		if p.err == nil {
+			p.err = err
+		}
+		return p
+	}
+
+	return p.add(StageGroup, append(bson.D{{Key: "_id", Value: id}}, doc...))
+}
+
+// Sort documents by fields.
+func (p *Pipeline) Sort(fields ...SortField) *Pipeline {
+
+	if len(fields) == 0 {
+		return p.fail("sort without fields")
+	}
+
+	keys := bson.D{}
+	for _, f := range fields {
+		if f.Field == "" {
+			return p.fail("empty field of sort")
+		}
+		dir := 1
+		if f.Desc {
+			dir = -1
+		}
+		keys = append(keys, bson.E{Key: f.Field, Value: dir})
+	}
+
+	return p.add(StageSort, keys)
+}
+
+// Reshape documents: 1 - include field, 0 - exclude field, expression - calculate field.
+func (p *Pipeline) Project(fields bson.M) *Pipeline {
+
+	if len(fields) == 0 {
+		return p.fail("projection without fields")
+	}
+
+	return p.add(StageProject, fields)
+}
+
+// Join documents of collection, which field foreignField is equal field localField, as array field.
+func (p *Pipeline) Lookup(from, localField, foreignField, as string) *Pipeline {
+
+	if from == "" || localField == "" || foreignField == "" || as == "" {
+		return p.fail("lookup from <%s> by <%s>=<%s> as <%s>", from, localField, foreignField, as)
+	}
+
+	return p.add(StageLookup, bson.D{
+		{Key: "from", Value: from},
+		{Key: "localField", Value: localField},
+		{Key: "foreignField", Value: foreignField},
+		{Key: "as", Value: as},
+	})
+}
+
+// Output document for each element of array field.
+// With preserveEmpty document with missing, null or empty array is kept.
+func (p *Pipeline) Unwind(field string, preserveEmpty bool) *Pipeline {
+
+	field = strings.TrimPrefix(field, "$")
+	if field == "" {
+		return p.fail("empty field of unwind")
+	}
+
+	return p.add(StageUnwind, bson.D{
+		{Key: "path", Value: Field(field)},
+		{Key: "preserveNullAndEmptyArrays", Value: preserveEmpty},
+	})
+}
+
+// Execute pipelines on the same documents. Output is one document with array field for each pipeline.
+func (p *Pipeline) Facet(facets map[string]*Pipeline) *Pipeline {
+
+	if len(facets) == 0 {
+		return p.fail("facet without pipelines")
+	}
+
+	names := make([]string, 0, len(facets))
+	for name := range facets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	doc := bson.D{}
+	for _, name := range names {
+
+		if name == "" || strings.ContainsAny(name, ".$") {
+			return p.fail("name of facet <%s>", name)
+		}
+
+		stages, err := facets[name].Stages()
+		if err != nil {
+			if p.err == nil {
+				p.err = fmt.Errorf("facet <%s>: %w", name, err)
+			}
+			return p
+		}
+		for _, stage := range stages {
+			if stage[0].Key == StageFacet {
+				return p.fail("facet <%s> contains facet", name)
+			}
+		}
+
+		doc = append(doc, bson.E{Key: name, Value: stages})
+	}
+
+	return p.add(StageFacet, doc)
+}
+
+// Group documents by ranges of value of expression. Boundaries are ascending, range is [boundaries[i], boundaries[i+1]).
+// Documents out of ranges are in group defaultID, nil - such documents are errors. Nil output - count of documents.
+func (p *Pipeline) Bucket(groupBy interface{}, boundaries []interface{}, defaultID interface{}, output Accumulators) *Pipeline {
+
+	if groupBy == nil {
+		return p.fail("empty expression of bucket")
+	}
+	if len(boundaries) < 2 {
+		return p.fail("bucket with less than two boundaries")
+	}
+
+	doc := bson.D{
+		{Key: "groupBy", Value: groupBy},
+		{Key: "boundaries", Value: boundaries},
+	}
+	if defaultID != nil {
+		doc = append(doc, bson.E{Key: "default", Value: defaultID})
+	}
+	if output != nil {
+		out, err := output.doc()
+		if err != nil {
+			if p.err == nil {
+				p.err = err
+			}
+			return p
+		}
+		doc = append(doc, bson.E{Key: "output", Value: out})
+	}
+
+	return p.add(StageBucket, doc)
+}
+
+// Skip count of documents.
+func (p *Pipeline) Skip(n int64) *Pipeline {
+
+	if n < 0 && p.err == nil {
+		p.err = ErrValuePagination
+	}
+
+	return p.add(StageSkip, n)
+}
+
+// Limit count of documents.
+func (p *Pipeline) Limit(n int64) *Pipeline {
+
+	if n <= 0 && p.err == nil {
+		p.err = ErrValuePagination
+	}
+
+	return p.add(StageLimit, n)
+}
+
+// Stages of pipeline. Nil pipeline has no stages. Returns stages and error of building.
+func (p *Pipeline) Stages() (mongo.Pipeline, error) {
+
+	if p == nil {
+		return mongo.Pipeline{}, nil
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	stages := make(mongo.Pipeline, len(p.stages))
+	copy(stages, p.stages)
+
+	return stages, nil
+}
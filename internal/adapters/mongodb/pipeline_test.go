@@ -0,0 +1,140 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Test Pipeline.
+func TestPipeline(t *testing.T) {
+
+	t.Run("Nil pipeline", func(t *testing.T) {
+
+		var p *Pipeline
+
+		stages, err := p.Stages()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Emptyf(t, stages, "Stages is not empty")
+	})
+
+	t.Run("Stages", func(t *testing.T) {
+
+		stages, err := NewPipeline().
+			Match(nil).
+			Group(Field("age"), Accumulators{"count": Count(), "names": Push(Field("name"))}).
+			Sort(SortField{Field: "count", Desc: true}, SortField{Field: "_id"}).
+			Project(bson.M{"_id": 0}).
+			Lookup("orders", "name", "user", "orders").
+			Unwind("$orders", true).
+			Skip(1).
+			Limit(10).
+			Stages()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{}}},
+			{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: "$age"},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				{Key: "names", Value: bson.D{{Key: "$push", Value: "$name"}}},
+			}}},
+			{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+			{{Key: "$project", Value: bson.M{"_id": 0}}},
+			{{Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "orders"},
+				{Key: "localField", Value: "name"},
+				{Key: "foreignField", Value: "user"},
+				{Key: "as", Value: "orders"},
+			}}},
+			{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$orders"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+			{{Key: "$skip", Value: int64(1)}},
+			{{Key: "$limit", Value: int64(10)}},
+		}
+		assert.Equalf(t, want, stages, "Stages is not equal")
+	})
+
+	t.Run("Match users", func(t *testing.T) {
+
+		stages, err := NewPipeline().MatchUsers(NewUserQuery().Name("Anna")).Stages()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, mongo.Pipeline{{{Key: "$match", Value: bson.M{"name": "Anna"}}}}, stages, "Stages is not equal")
+
+		_, err = NewPipeline().MatchUsers(NewUserQuery().SortBy("phone", false)).Stages()
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+		_, err = NewPipeline().MatchUsers(NewUserQuery().Limit(10)).Stages()
+		require.Truef(t, errors.Is(err, ErrNotCorrectPipeline), "Error is not equal")
+	})
+
+	t.Run("Facet", func(t *testing.T) {
+
+		stages, err := NewPipeline().Facet(map[string]*Pipeline{
+			"total": NewPipeline().Group(nil, Accumulators{"count": Count()}),
+			"first": NewPipeline().Limit(1),
+		}).Stages()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := mongo.Pipeline{{{Key: "$facet", Value: bson.D{
+			{Key: "first", Value: mongo.Pipeline{{{Key: "$limit", Value: int64(1)}}}},
+			{Key: "total", Value: mongo.Pipeline{{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: nil},
+				{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}}}},
+		}}}}
+		assert.Equalf(t, want, stages, "Stages is not equal")
+	})
+
+	t.Run("Bucket", func(t *testing.T) {
+
+		stages, err := NewPipeline().Bucket(Field("age"), []interface{}{0, 30}, "other", Accumulators{"avg": Avg(Field("age"))}).Stages()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := mongo.Pipeline{{{Key: "$bucket", Value: bson.D{
+			{Key: "groupBy", Value: "$age"},
+			{Key: "boundaries", Value: []interface{}{0, 30}},
+			{Key: "default", Value: "other"},
+			{Key: "output", Value: bson.D{{Key: "avg", Value: bson.D{{Key: "$avg", Value: "$age"}}}}},
+		}}}}
+		assert.Equalf(t, want, stages, "Stages is not equal")
+	})
+
+	t.Run("Not correct stages", func(t *testing.T) {
+
+		pipelines := map[string]*Pipeline{
+			"group output _id":    NewPipeline().Group(nil, Accumulators{"_id": Count()}),
+			"empty accumulator":   NewPipeline().Group(nil, Accumulators{"count": {}}),
+			"sort without fields": NewPipeline().Sort(),
+			"empty sort field":    NewPipeline().Sort(SortField{}),
+			"empty projection":    NewPipeline().Project(nil),
+			"lookup without from": NewPipeline().Lookup("", "name", "name", "as"),
+			"empty unwind":        NewPipeline().Unwind("$", false),
+			"empty facet":         NewPipeline().Facet(nil),
+			"nested facet":        NewPipeline().Facet(map[string]*Pipeline{"a": NewPipeline().Facet(map[string]*Pipeline{"b": nil})}),
+			"name of facet":       NewPipeline().Facet(map[string]*Pipeline{"a.b": nil}),
+			"bucket without expr": NewPipeline().Bucket(nil, []interface{}{0, 1}, nil, nil),
+			"one boundary":        NewPipeline().Bucket(Field("age"), []interface{}{0}, nil, nil),
+		}
+
+		for name, p := range pipelines {
+			_, err := p.Stages()
+			require.Truef(t, errors.Is(err, ErrNotCorrectPipeline), "Error is not equal: %s", name)
+		}
+
+		_, err := NewPipeline().Limit(0).Stages()
+		require.Equalf(t, ErrValuePagination, err, "Error is not equal")
+
+		_, err = NewPipeline().Skip(-1).Stages()
+		require.Equalf(t, ErrValuePagination, err, "Error is not equal")
+	})
+
+	t.Run("The first error", func(t *testing.T) {
+
+		_, err := NewPipeline().Sort().Limit(-1).Stages()
+		require.Truef(t, errors.Is(err, ErrNotCorrectPipeline), "Error is not equal")
+	})
+}
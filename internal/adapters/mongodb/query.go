@@ -0,0 +1,471 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Field of sorting.
+type SortField struct {
+	// BSON name of field
+	Field string
+	// Descending order
+	Desc bool
+}
+
+// Token of keyset pagination: values of sorting fields and id of the last document of page.
+type PageToken struct {
+	// Values of sorting fields in order of sorting, nil - field is missing or null
+	Keys []interface{} `bson:"keys"`
+	// Id of document
+	ID primitive.ObjectID `bson:"id"`
+}
+
+// Specification of query of users.
+type UserQuerySpec struct {
+	// Minimum age, inclusive
+	AgeMin *int
+	// Maximum age, inclusive
+	AgeMax *int
+	// Domain of email, case insensitive
+	EmailDomain string
+	// Prefix of name
+	NamePrefix string
+	// Name, exact match
+	Name string
+	// Email, exact match
+	Email string
+	// Fields of sorting. Id is always the last field of sorting
+	Sort []SortField
+	// Fields of projection. Empty - all fields
+	Fields []string
+	// Count of skipped documents
+	Skip int64
+	// Maximum count of documents. Zero - without limit
+	Limit int64
+	// Token of previous page
+	After *PageToken
+}
+
+// Builder of query of users.
+type UserQuery struct {
+	spec UserQuerySpec
+	err  error
+}
+
+// Constructor of query of users.
+func NewUserQuery() *UserQuery {
+
+	return &UserQuery{}
+}
+
+// Age in range [min, max].
+func (q *UserQuery) AgeBetween(min, max int) *UserQuery {
+
+	return q.AgeMin(min).AgeMax(max)
+}
+
+// Age is not less than min.
+func (q *UserQuery) AgeMin(min int) *UserQuery {
+
+	q.spec.AgeMin = &min
+	return q
+}
+
+// Age is not greater than max.
+func (q *UserQuery) AgeMax(max int) *UserQuery {
+
+	q.spec.AgeMax = &max
+	return q
+}
+
+// Email in domain.
+func (q *UserQuery) EmailDomain(domain string) *UserQuery {
+
+	q.spec.EmailDomain = strings.TrimPrefix(domain, "@")
+	return q
+}
+
+// Name starts with prefix.
+func (q *UserQuery) NamePrefix(prefix string) *UserQuery {
+
+	q.spec.NamePrefix = prefix
+	return q
+}
+
+// Name is equal name.
+func (q *UserQuery) Name(name string) *UserQuery {
+
+	q.spec.Name = name
+	return q
+}
+
+// Email is equal email.
+func (q *UserQuery) Email(email string) *UserQuery {
+
+	q.spec.Email = email
+	return q
+}
+
+// Sort by field.
+func (q *UserQuery) SortBy(field string, desc bool) *UserQuery {
+
+	if _, ok := bsonFieldIndex(reflect.TypeOf(DocUser{}), field); !ok && q.err == nil {
+		q.err = fmt.Errorf("%w: %s", ErrUnknownField, field)
+	}
+
+	q.spec.Sort = append(q.spec.Sort, SortField{Field: field, Desc: desc})
+	return q
+}
+
+// Return only fields.
+func (q *UserQuery) Project(fields ...string) *UserQuery {
+
+	for _, f := range fields {
+		if _, ok := bsonFieldIndex(reflect.TypeOf(DocUser{}), f); !ok && q.err == nil {
+			q.err = fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+	}
+
+	q.spec.Fields = append(q.spec.Fields, fields...)
+	return q
+}
+
+// Skip count of documents.
+func (q *UserQuery) Skip(n int64) *UserQuery {
+
+	if n < 0 && q.err == nil {
+		q.err = ErrValuePagination
+	}
+
+	q.spec.Skip = n
+	return q
+}
+
+// Limit count of documents.
+func (q *UserQuery) Limit(n int64) *UserQuery {
+
+	if n < 0 && q.err == nil {
+		q.err = ErrValuePagination
+	}
+
+	q.spec.Limit = n
+	return q
+}
+
+// Continue after page token.
+func (q *UserQuery) After(token string) *UserQuery {
+
+	if token == "" {
+		q.spec.After = nil
+		return q
+	}
+
+	t, err := DecodePageToken(token)
+	if err != nil && q.err == nil {
+		q.err = err
+	}
+
+	q.spec.After = &t
+	return q
+}
+
+// Specification of query. Returns specification and error of building.
+func (q *UserQuery) Spec() (UserQuerySpec, error) {
+
+	if q == nil {
+		return UserQuerySpec{}, nil
+	}
+	if q.err != nil {
+		return UserQuerySpec{}, q.err
+	}
+	if q.spec.After != nil && len(q.spec.After.Keys) != len(q.spec.Sort) {
+		// Token of query with other sorting
+		return UserQuerySpec{}, ErrNotCorrectPageToken
+	}
+
+	return q.spec, nil
+}
+
+// Encode page token. Returns token and error.
+//
+// Params:
+//
+//	t - token
+func EncodePageToken(t PageToken) (string, error) {
+
+	b, err := bson.Marshal(t)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token: <%w>", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Decode page token. Returns token and error.
+//
+// Params:
+//
+//	s - token
+func DecodePageToken(s string) (PageToken, error) {
+
+	var t PageToken
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return PageToken{}, ErrNotCorrectPageToken
+	}
+	if err := bson.Unmarshal(b, &t); err != nil {
+		return PageToken{}, ErrNotCorrectPageToken
+	}
+
+	return t, nil
+}
+
+// Filter of documents by specification. Returns filter.
+func (s UserQuerySpec) filter() bson.M {
+
+	filter := bson.M{}
+
+	age := bson.M{}
+	if s.AgeMin != nil {
+		age["$gte"] = *s.AgeMin
+	}
+	if s.AgeMax != nil {
+		age["$lte"] = *s.AgeMax
+	}
+	if len(age) > 0 {
+		filter["age"] = age
+	}
+	if s.EmailDomain != "" {
+		filter["email"] = primitive.Regex{Pattern: "@" + regexp.QuoteMeta(s.EmailDomain) + "$", Options: "i"}
+	}
+	if s.NamePrefix != "" {
+		filter["name"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(s.NamePrefix)}
+	}
+	if s.Name != "" {
+		if s.NamePrefix != "" {
+			filter["name"] = bson.M{"$eq": s.Name, "$regex": filter["name"]}
+		} else {
+			filter["name"] = s.Name
+		}
+	}
+	if s.Email != "" {
+		if s.EmailDomain != "" {
+			filter["email"] = bson.M{"$eq": s.Email, "$regex": filter["email"]}
+		} else {
+			filter["email"] = s.Email
+		}
+	}
+
+	if s.After == nil {
+		return filter
+	}
+
+	// Keyset: documents after the token in order of sorting. Missing and null values are the least,
+	// {field: null} matches both of them
+	or := bson.A{}
+
+	for i := 0; i <= len(s.Sort); i++ {
+
+		conds := bson.A{}
+		for j, sf := range s.Sort[:i] {
+			conds = append(conds, bson.M{sf.Field: s.After.Keys[j]})
+		}
+
+		if i == len(s.Sort) {
+			if s.sortedByID() {
+				// Id is unique, documents with equal keys do not exist
+				break
+			}
+			conds = append(conds, bson.M{"_id": bson.M{"$gt": s.After.ID}})
+		} else {
+			cond, ok := keysetAfter(s.Sort[i], s.After.Keys[i])
+			if !ok {
+				continue
+			}
+			conds = append(conds, cond)
+		}
+
+		if len(conds) == 1 {
+			or = append(or, conds[0])
+		} else {
+			or = append(or, bson.M{"$and": conds})
+		}
+	}
+
+	if len(or) == 0 {
+		// Token of the last document in order of sorting
+		return bson.M{"$and": bson.A{filter, bson.M{"_id": bson.M{"$in": bson.A{}}}}}
+	}
+
+	return bson.M{"$and": bson.A{filter, bson.M{"$or": or}}}
+}
+
+// Condition of values of sorting field after value of token. Returns condition and false,
+// if values after token do not exist.
+//
+// Params:
+//
+//	sf - field of sorting
+//	key - value of token, nil - missing or null
+func keysetAfter(sf SortField, key interface{}) (bson.M, bool) {
+
+	switch {
+	case !sf.Desc && key == nil:
+		return bson.M{sf.Field: bson.M{"$ne": nil}}, true
+	case !sf.Desc:
+		return bson.M{sf.Field: bson.M{"$gt": key}}, true
+	case key == nil:
+		return nil, false
+	}
+
+	return bson.M{"$or": bson.A{bson.M{sf.Field: bson.M{"$lt": key}}, bson.M{sf.Field: nil}}}, true
+}
+
+// Check, that id is field of sorting. Returns flag.
+func (s UserQuerySpec) sortedByID() bool {
+
+	for _, sf := range s.Sort {
+		if sf.Field == "_id" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Values of sorting fields of document as they are stored, nil - field is missing or null.
+// Returns values and error.
+//
+// Params:
+//
+//	doc - document
+func (s UserQuerySpec) SortKeys(doc DocUser) ([]interface{}, error) {
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Function Marshal, returned error: <%w>", err)
+	}
+
+	keys := make([]interface{}, 0, len(s.Sort))
+	for _, sf := range s.Sort {
+
+		v, err := bson.Raw(raw).LookupErr(sf.Field)
+		if err != nil || v.Type == bsontype.Null {
+			keys = append(keys, nil)
+			continue
+		}
+
+		var key interface{}
+		if err := v.Unmarshal(&key); err != nil {
+			return nil, fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Token of the page after document. Token keeps only values of sorting fields and id.
+// Returns token and error.
+//
+// Params:
+//
+//	doc - the last document of page
+func (s UserQuerySpec) NextPageToken(doc DocUser) (string, error) {
+
+	keys, err := s.SortKeys(doc)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodePageToken(PageToken{Keys: keys, ID: doc.ID})
+}
+
+// Options of search by specification. Returns options.
+func (s UserQuerySpec) findOptions() *options.FindOptions {
+
+	opts := options.Find()
+
+	sort := bson.D{}
+	for _, sf := range s.Sort {
+		dir := 1
+		if sf.Desc {
+			dir = -1
+		}
+		sort = append(sort, bson.E{Key: sf.Field, Value: dir})
+	}
+	if !s.sortedByID() {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+	opts.SetSort(sort)
+
+	if len(s.Fields) > 0 {
+		projection := bson.M{}
+		for _, f := range s.Fields {
+			projection[f] = 1
+		}
+		for _, f := range sortFieldNames(s.Sort) {
+			projection[f] = 1
+		}
+		opts.SetProjection(projection)
+	}
+
+	if s.Skip > 0 {
+		opts.SetSkip(s.Skip)
+	}
+	if s.Limit > 0 {
+		// One more document shows, that the next page exists
+		opts.SetLimit(s.Limit + 1)
+	}
+
+	return opts
+}
+
+// Clear fields of document, which are not in projection. Id is always kept. Returns document.
+//
+// Params:
+//
+//	doc - document
+func (s UserQuerySpec) ApplyProjection(doc DocUser) DocUser {
+
+	if len(s.Fields) == 0 {
+		return doc
+	}
+
+	out := DocUser{ID: doc.ID}
+	src := reflect.ValueOf(doc)
+	dst := reflect.ValueOf(&out).Elem()
+
+	for _, f := range s.Fields {
+		if i, ok := bsonFieldIndex(src.Type(), f); ok {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+
+	return out
+}
+
+// Names of sorting fields. Returns names.
+//
+// Params:
+//
+//	sort - fields of sorting
+func sortFieldNames(sort []SortField) []string {
+
+	names := make([]string, 0, len(sort))
+	for _, sf := range sort {
+		names = append(names, sf.Field)
+	}
+
+	return names
+}
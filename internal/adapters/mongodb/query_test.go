@@ -0,0 +1,179 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test UserQuery.
+func TestUserQuery(t *testing.T) {
+
+	t.Run("Nil query", func(t *testing.T) {
+
+		var q *UserQuery
+
+		spec, err := q.Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.M{}, spec.filter(), "Filter is not equal")
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+
+		_, err := NewUserQuery().Project("name", "phone").Spec()
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Wrong pagination", func(t *testing.T) {
+
+		_, err := NewUserQuery().Limit(-1).Spec()
+		require.Equalf(t, ErrValuePagination, err, "Error is not equal")
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+
+		spec, err := NewUserQuery().AgeBetween(18, 30).EmailDomain("@mail.com").NamePrefix("A.").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{
+			"age":   bson.M{"$gte": 18, "$lte": 30},
+			"email": primitive.Regex{Pattern: `@mail\.com$`, Options: "i"},
+			"name":  primitive.Regex{Pattern: `^A\.`},
+		}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+	})
+
+	t.Run("Exact filter", func(t *testing.T) {
+
+		spec, err := NewUserQuery().Name("Anna").Email("anna@mail.com").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{"name": "Anna", "email": "anna@mail.com"}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+
+		spec, err = NewUserQuery().NamePrefix("A").Name("Anna").Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want = bson.M{"name": bson.M{"$eq": "Anna", "$regex": primitive.Regex{Pattern: `^A`}}}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+	})
+
+	t.Run("Keyset filter", func(t *testing.T) {
+
+		id := primitive.NewObjectID()
+		token, err := EncodePageToken(PageToken{Keys: []interface{}{int32(20)}, ID: id})
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		spec, err := NewUserQuery().SortBy("age", true).After(token).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{"$and": bson.A{
+			bson.M{},
+			bson.M{"$or": bson.A{
+				bson.M{"$or": bson.A{bson.M{"age": bson.M{"$lt": int32(20)}}, bson.M{"age": nil}}},
+				bson.M{"$and": bson.A{bson.M{"age": int32(20)}, bson.M{"_id": bson.M{"$gt": id}}}},
+			}},
+		}}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+	})
+
+	t.Run("Keyset filter with missing value", func(t *testing.T) {
+
+		id := primitive.NewObjectID()
+		token, err := EncodePageToken(PageToken{Keys: []interface{}{nil}, ID: id})
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		spec, err := NewUserQuery().SortBy("email", false).After(token).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{"$and": bson.A{
+			bson.M{},
+			bson.M{"$or": bson.A{
+				bson.M{"email": bson.M{"$ne": nil}},
+				bson.M{"$and": bson.A{bson.M{"email": nil}, bson.M{"_id": bson.M{"$gt": id}}}},
+			}},
+		}}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+
+		// Nothing is less than missing value
+		spec, err = NewUserQuery().SortBy("email", true).After(token).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want = bson.M{"$and": bson.A{
+			bson.M{},
+			bson.M{"$or": bson.A{
+				bson.M{"$and": bson.A{bson.M{"email": nil}, bson.M{"_id": bson.M{"$gt": id}}}},
+			}},
+		}}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+	})
+
+	t.Run("Keyset filter by id", func(t *testing.T) {
+
+		id := primitive.NewObjectID()
+		token, err := EncodePageToken(PageToken{Keys: []interface{}{id}, ID: id})
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		spec, err := NewUserQuery().SortBy("_id", false).After(token).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{"$and": bson.A{bson.M{}, bson.M{"$or": bson.A{bson.M{"_id": bson.M{"$gt": id}}}}}}
+		assert.Equalf(t, want, spec.filter(), "Filter is not equal")
+		assert.Equalf(t, bson.D{{Key: "_id", Value: 1}}, spec.findOptions().Sort, "Sort is not equal")
+	})
+
+	t.Run("Token of other sorting", func(t *testing.T) {
+
+		token, err := EncodePageToken(PageToken{Keys: []interface{}{"A"}, ID: primitive.NewObjectID()})
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		_, err = NewUserQuery().SortBy("name", false).SortBy("age", false).After(token).Spec()
+		require.Equalf(t, ErrNotCorrectPageToken, err, "Error is not equal")
+	})
+
+	t.Run("Options", func(t *testing.T) {
+
+		spec, err := NewUserQuery().SortBy("name", false).Project("email").Skip(5).Limit(10).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		opts := spec.findOptions()
+		assert.Equalf(t, bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}, opts.Sort, "Sort is not equal")
+		assert.Equalf(t, bson.M{"email": 1, "name": 1}, opts.Projection, "Projection is not equal")
+		assert.Equalf(t, int64(5), *opts.Skip, "Skip is not equal")
+		assert.Equalf(t, int64(11), *opts.Limit, "Limit is not equal")
+
+		doc := spec.ApplyProjection(DocUser{Name: "A", Age: 20, Email: "a@mail.com"})
+		assert.Equalf(t, DocUser{Email: "a@mail.com"}, doc, "Document is not equal")
+	})
+
+	t.Run("Page token", func(t *testing.T) {
+
+		want := PageToken{Keys: []interface{}{"A", int32(20), nil}, ID: primitive.NewObjectID()}
+
+		s, err := EncodePageToken(want)
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		got, err := DecodePageToken(s)
+		require.NoErrorf(t, err, "Unexpected error decode")
+		assert.Equalf(t, want, got, "Token is not equal")
+
+		// Token has only values of sorting fields
+		spec, err := NewUserQuery().SortBy("age", false).SortBy("email", false).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		doc := DocUser{ID: want.ID, Name: "A", Age: 20}
+		s, err = spec.NextPageToken(doc)
+		require.NoErrorf(t, err, "Unexpected error encode")
+
+		got, err = DecodePageToken(s)
+		require.NoErrorf(t, err, "Unexpected error decode")
+		assert.Equalf(t, PageToken{Keys: []interface{}{int32(20), nil}, ID: want.ID}, got, "Token is not equal")
+
+		_, err = DecodePageToken("!")
+		require.Equalf(t, ErrNotCorrectPageToken, err, "Error is not equal")
+	})
+}
@@ -0,0 +1,180 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Defaults of relay.
+const (
+	defaultRelayInterval   = time.Second
+	defaultRelayBatchSize  = 100
+	defaultRelayLease      = 30 * time.Second
+	defaultRelayRetryAfter = 5 * time.Second
+)
+
+// Receiver of events of outbox. Event can be published more than once, so receiver
+// must deduplicate events by id.
+type Publisher interface {
+	// Publish event
+	Publish(ctx context.Context, ev OutboxEvent) error
+}
+
+// Function as Publisher.
+type PublisherFunc func(ctx context.Context, ev OutboxEvent) error
+
+// Publish event. Returns error.
+func (f PublisherFunc) Publish(ctx context.Context, ev OutboxEvent) error {
+
+	return f(ctx, ev)
+}
+
+// Settings of relay.
+type RelayOptions struct {
+	// Interval of polling of outbox. 0 - defaultRelayInterval
+	Interval time.Duration
+	// Count of events, claimed at once. 0 - defaultRelayBatchSize
+	BatchSize int
+	// Time of lease of claimed event, after it undelivered event is claimed again. 0 - defaultRelayLease
+	Lease time.Duration
+	// Delay of next delivery after error of publisher. 0 - defaultRelayRetryAfter
+	RetryAfter time.Duration
+	// Time of keeping of delivered events. 0 - delivered events are kept
+	Retention time.Duration
+	// Name of outbox collection, which changes wake relay before end of interval. Empty - only polling
+	WatchCollection string
+}
+
+// Worker, which delivers events of outbox to publisher with at-least-once semantics.
+type Relay struct {
+	db   MongoDBI
+	pub  Publisher
+	opts RelayOptions
+}
+
+// Constructor of relay.
+//
+// Params:
+//
+//	db - connection with outbox
+//	pub - publisher
+//	opts - settings
+func NewRelay(db MongoDBI, pub Publisher, opts RelayOptions) (*Relay, error) {
+
+	// Check
+	if db == nil {
+		return nil, ErrNilPtrDB
+	}
+	if pub == nil {
+		return nil, ErrNilPublisher
+	}
+	if opts.Interval < 0 || opts.BatchSize < 0 || opts.Lease < 0 || opts.RetryAfter < 0 || opts.Retention < 0 {
+		return nil, ErrValueRelayOptions
+	}
+
+	// Defaults
+	if opts.Interval == 0 {
+		opts.Interval = defaultRelayInterval
+	}
+	if opts.BatchSize == 0 {
+		opts.BatchSize = defaultRelayBatchSize
+	}
+	if opts.Lease == 0 {
+		opts.Lease = defaultRelayLease
+	}
+	if opts.RetryAfter == 0 {
+		opts.RetryAfter = defaultRelayRetryAfter
+	}
+
+	return &Relay{db: db, pub: pub, opts: opts}, nil
+}
+
+// Deliver events of outbox until cancel of context. Stream of WatchCollection, closed without error,
+// e.g. on drop of collection, is replaced by polling. Returns nil on cancel, error of outbox or of stream.
+//
+// Params:
+//
+//	ctx - context
+func (r *Relay) Run(ctx context.Context) error {
+
+	var wake <-chan ChangeEvent
+	var wakeErrs <-chan error
+	if r.opts.WatchCollection != "" {
+		events, errs, err := r.db.Watch(ctx, WatchOptions{Collection: r.opts.WatchCollection, Buffer: 1})
+		if err != nil {
+			return err
+		}
+		wake, wakeErrs = events, errs
+	}
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+
+		if _, err := r.RelayOnce(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case _, ok := <-wake:
+			if !ok {
+				// Error is sent before closing of channel of errors
+				if err := <-wakeErrs; err != nil && ctx.Err() == nil {
+					return fmt.Errorf("Function Watch, returned error: <%w>", err)
+				}
+				// Stream is closed, polling continues
+				wake = nil
+			}
+		}
+	}
+}
+
+// Deliver one batch of events of outbox and remove expired delivered events.
+// Error of publisher is registered in event and does not stop delivery.
+// Returns count delivered events and error.
+//
+// Params:
+//
+//	ctx - context
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+
+	events, err := r.db.ClaimOutboxEvents(ctx, r.opts.BatchSize, r.opts.Lease)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, ev := range events {
+
+		// Event, which lease is expired, can be completed by other relay
+		if err := r.pub.Publish(ctx, ev); err != nil {
+			err = r.db.FailOutboxEvent(ctx, ev.ID, err, r.opts.RetryAfter)
+			if err != nil && err != ErrUpdateDocument {
+				return delivered, fmt.Errorf("Function FailOutboxEvent, returned error: <%w>", err)
+			}
+			continue
+		}
+
+		err := r.db.AckOutboxEvent(ctx, ev.ID)
+		if err != nil && err != ErrUpdateDocument {
+			return delivered, fmt.Errorf("Function AckOutboxEvent, returned error: <%w>", err)
+		}
+		delivered++
+	}
+
+	if r.opts.Retention > 0 {
+		if _, err := r.db.PurgeOutboxEvents(ctx, r.opts.Retention); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
@@ -0,0 +1,1048 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Settings of repository.
+type RepositoryConfig[T any] struct {
+	// Name of field, which identifies document in methods ...ByKey
+	KeyField string
+	// Names of fields, which identify duplicate on insert
+	UniqueFields []string
+	// Normalization of document before check and write
+	Normalize func(doc T) T
+	// Check of document before insert
+	Validate func(doc T) error
+	// Check of document before update. Nil - Validate is used
+	ValidateUpdate func(doc T) error
+	// Name of integer field of version, maintained by repository: 1 on insert, incremented on update.
+	// Empty - without versioning
+	VersionField string
+	// Fields of audit, maintained by repository
+	Audit AuditFields
+	// Name of field of time of soft delete, maintained by repository. Delete sets field,
+	// reads and updates skip deleted documents. Empty - documents are deleted permanently
+	DeletedField string
+	// Check of patch, returns specification with normalized values.
+	// Nil - rules of tags validate and Normalize are used
+	PreparePatch func(spec PatchSpec) (PatchSpec, error)
+	// Outbox of events of insert and relocation. Empty name of collection - without outbox
+	Outbox OutboxConfig
+}
+
+// Result of upsert.
+type UpsertResult struct {
+	// Document is inserted, false - existing document is replaced
+	Inserted bool
+	// Id of inserted document, NilObjectID for replaced document
+	ID primitive.ObjectID
+}
+
+// Repository of documents with type T.
+type Repository[T any] struct {
+	m   *mongoDB
+	cfg RepositoryConfig[T]
+}
+
+// Constructor of repository. Repository executes operations of driver directly, so it requires connection,
+// returned by New. Other implementations of MongoDBI, e.g. memstore, return ErrNotSupportedDB:
+// behaviour of repository is checked against server only.
+//
+// Params:
+//
+//	db - connection, returned by New
+//	cfg - settings
+func NewRepository[T any](db MongoDBI, cfg RepositoryConfig[T]) (*Repository[T], error) {
+
+	// Check
+	m, ok := db.(*mongoDB)
+	if !ok || m == nil {
+		return nil, ErrNotSupportedDB
+	}
+
+	var zero T
+	if reflect.TypeOf(zero) == nil || reflect.TypeOf(zero).Kind() != reflect.Struct {
+		return nil, ErrNotStructType
+	}
+	for _, f := range append([]string{cfg.KeyField, cfg.VersionField, cfg.DeletedField}, cfg.UniqueFields...) {
+		if f == "" {
+			continue
+		}
+		if _, ok := bsonFieldIndex(reflect.TypeOf(zero), f); !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, f)
+		}
+	}
+	if cfg.VersionField != "" {
+		i, _ := bsonFieldIndex(reflect.TypeOf(zero), cfg.VersionField)
+		if !isInteger(reflect.TypeOf(zero).Field(i).Type) {
+			return nil, fmt.Errorf("%w: version field %s", ErrNotSupportedType, cfg.VersionField)
+		}
+	}
+	if err := cfg.Audit.check(reflect.TypeOf(zero)); err != nil {
+		return nil, err
+	}
+	if cfg.DeletedField != "" {
+		i, _ := bsonFieldIndex(reflect.TypeOf(zero), cfg.DeletedField)
+		if reflect.TypeOf(zero).Field(i).Type != reflect.TypeOf(time.Time{}) {
+			return nil, fmt.Errorf("%w: deleted field %s", ErrNotSupportedType, cfg.DeletedField)
+		}
+	}
+
+	return &Repository[T]{m: m, cfg: cfg}, nil
+}
+
+// Check connection and name of collection. Returns error.
+//
+// Params:
+//
+//	collectionName - name of collection
+func (r *Repository[T]) check(collectionName string) error {
+
+	if r.m.db == nil {
+		return ErrNilPtrDB
+	}
+	if r.m.connect == nil {
+		return ErrNilPtrConnect
+	}
+	if collectionName == "" {
+		return ErrEmptyCollectionsName
+	}
+
+	return nil
+}
+
+// Normalize document by settings. Returns document.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) normalize(doc T) T {
+
+	if r.cfg.Normalize == nil {
+		return doc
+	}
+
+	return r.cfg.Normalize(doc)
+}
+
+// Check document for insert by settings. Returns error.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) validate(doc T) error {
+
+	if r.cfg.Validate == nil {
+		return nil
+	}
+
+	return r.cfg.Validate(doc)
+}
+
+// Check document for update by settings. Returns error.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) validateUpdate(doc T) error {
+
+	if r.cfg.ValidateUpdate == nil {
+		return r.validate(doc)
+	}
+
+	return r.cfg.ValidateUpdate(doc)
+}
+
+// Check patch by settings. Returns specification and error.
+//
+// Params:
+//
+//	spec - specification
+func (r *Repository[T]) preparePatch(spec PatchSpec) (PatchSpec, error) {
+
+	if r.cfg.PreparePatch != nil {
+		return r.cfg.PreparePatch(spec)
+	}
+
+	out, errs, err := preparePatch(spec, r.cfg.Normalize)
+	if err != nil {
+		return spec, err
+	}
+	if len(errs) > 0 {
+		return spec, &ValidationError{Fields: errs}
+	}
+
+	return out, nil
+}
+
+// Build filter by key. Returns filter and error.
+//
+// Params:
+//
+//	key - value of key field
+func (r *Repository[T]) keyFilter(key interface{}) (bson.M, error) {
+
+	if r.cfg.KeyField == "" {
+		return nil, ErrEmptyKeyField
+	}
+	if key == nil || reflect.ValueOf(key).IsZero() {
+		return nil, ErrEmptyValueName
+	}
+
+	return bson.M{r.cfg.KeyField: key}, nil
+}
+
+// Indexes of repository. Returns declarations.
+func (r *Repository[T]) Indexes() []IndexSpec {
+
+	if len(r.cfg.UniqueFields) == 0 {
+		return nil
+	}
+
+	return []IndexSpec{uniqueIndex(r.cfg.UniqueFields, r.cfg.DeletedField)}
+}
+
+// Create indexes of repository in collection. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (r *Repository[T]) EnsureIndexes(ctx context.Context, collectionName string) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	specs := r.Indexes()
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// Logic
+	// Creation of indexes is not allowed in transaction
+	return r.m.EnsureIndexes(withoutSession(ctx), collectionName, specs)
+}
+
+// Create indexes of repository once per collection before the first write, so unique fields are checked
+// in collections, which are not created by CheckCreateDB. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+func (r *Repository[T]) ensureIndexes(ctx context.Context, collectionName string) error {
+
+	specs := []IndexSpec{}
+	for _, s := range r.Indexes() {
+		if _, ok := r.m.indexed.Load(indexedKey(collectionName, s.IndexName())); !ok {
+			specs = append(specs, s)
+		}
+	}
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// Creation of indexes is not allowed in transaction
+	return r.m.EnsureIndexes(withoutSession(ctx), collectionName, specs)
+}
+
+// Insert the document. Returns id added document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	doc - document
+func (r *Repository[T]) Insert(ctx context.Context, collectionName string, doc T) (id interface{}, err error) {
+
+	return r.insert(ctx, collectionName, doc, r.insertDoc)
+}
+
+// Insert the document, prepared by function. With outbox, event is appended in the same transaction.
+// Returns id added document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	doc - document
+//	prepare - preparation of checked document, sets maintained fields
+func (r *Repository[T]) insert(ctx context.Context, collectionName string, doc T, prepare func(ctx context.Context, doc T) T) (id interface{}, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return nil, err
+	}
+	doc = r.normalize(doc)
+	if err := r.validate(doc); err != nil {
+		return nil, err
+	}
+	doc = prepare(ctx, doc)
+
+	if r.cfg.Outbox.Collection == "" {
+		return r.insertOne(ctx, collectionName, doc)
+	}
+
+	// Event is appended to outbox in the same transaction
+	d, err := withID(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.m.inTransaction(ctx, func(sessCtx context.Context) error {
+
+		if id, err = r.insertOne(sessCtx, collectionName, d); err != nil {
+			return err
+		}
+
+		return r.appendOutbox(sessCtx, r.cfg.Outbox.Created, collectionName, "", d)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+// Insert the checked document. Returns id added document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	doc - document
+func (r *Repository[T]) insertOne(ctx context.Context, collectionName string, doc interface{}) (interface{}, error) {
+
+	// Unique index rejects duplicate atomically
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if err := r.ensureIndexes(ctx, collectionName); err != nil {
+		return nil, err
+	}
+
+	// Send
+	result, err := collection.InsertOne(ctx, doc)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDocumentExists
+		}
+		if isServerError(err, codeDocumentValidationFailure) {
+			return nil, schemaValidationError(err)
+		}
+		return nil, fmt.Errorf("Function InsertOne, returned error: <%w>", err)
+	}
+
+	return result.InsertedID, nil
+}
+
+// Find first document by filter. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+func (r *Repository[T]) FindOne(ctx context.Context, collectionName string, filter interface{}) (doc T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return doc, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	err = collection.FindOne(ctx, r.liveFilter(filter)).Decode(&doc)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("Function FindOne return error: <%w>", err)
+	}
+
+	return doc, nil
+}
+
+// Find document by key. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+func (r *Repository[T]) FindOneByKey(ctx context.Context, collectionName string, key interface{}) (doc T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return doc, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return doc, err
+	}
+
+	return r.FindOne(ctx, collectionName, filter)
+}
+
+// Find documents by filter. Returns documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+func (r *Repository[T]) Find(ctx context.Context, collectionName string, filter interface{}) (docs []T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return nil, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, r.liveFilter(filter))
+	if err != nil {
+		return nil, fmt.Errorf("Function Find return error: <%w>", err)
+	}
+
+	docs = []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("Function All return error: <%w>", err)
+	}
+
+	return docs, nil
+}
+
+// Update first document by filter. Fields of document are set by $set. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	doc - document
+func (r *Repository[T]) Update(ctx context.Context, collectionName string, filter interface{}, doc T) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	doc = r.normalize(doc)
+	if err := r.validateUpdate(doc); err != nil {
+		return err
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if err := r.ensureIndexes(ctx, collectionName); err != nil {
+		return err
+	}
+
+	update, err := r.updateDoc(ctx, doc)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(ctx, r.liveFilter(filter), update)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDocumentExists
+		}
+		if isServerError(err, codeDocumentValidationFailure) {
+			return schemaValidationError(err)
+		}
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUpdateDocument
+	}
+
+	return nil
+}
+
+// Update document by key. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+//	doc - document
+func (r *Repository[T]) UpdateByKey(ctx context.Context, collectionName string, key interface{}, doc T) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return err
+	}
+
+	return r.Update(ctx, collectionName, filter, doc)
+}
+
+// Patch first document by filter. Only fields of patch are changed and checked.
+// Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	p - patch
+func (r *Repository[T]) Patch(ctx context.Context, collectionName string, filter interface{}, p *Patch) (doc T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return doc, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	spec, err := p.Spec()
+	if err != nil {
+		return doc, err
+	}
+	if spec, err = r.preparePatch(spec); err != nil {
+		return doc, err
+	}
+	update, err := r.patchDoc(ctx, spec)
+	if err != nil {
+		return doc, err
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, r.liveFilter(filter), update, opts).Decode(&doc)
+	if err != nil {
+		var zero T
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return zero, ErrUpdateDocument
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return zero, ErrDocumentExists
+		}
+		if isServerError(err, codeDocumentValidationFailure) {
+			return zero, schemaValidationError(err)
+		}
+		return zero, fmt.Errorf("Function FindOneAndUpdate, returned error: <%w>", err)
+	}
+
+	return doc, nil
+}
+
+// Patch document by key. Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+//	p - patch
+func (r *Repository[T]) PatchByKey(ctx context.Context, collectionName string, key interface{}, p *Patch) (doc T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return doc, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return doc, err
+	}
+
+	return r.Patch(ctx, collectionName, filter, p)
+}
+
+// Replace first document by filter or insert document, if nothing matched.
+// Document is checked as on insert. With outbox, event of insert is appended in the same transaction.
+// Returns result and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	doc - document
+func (r *Repository[T]) Upsert(ctx context.Context, collectionName string, filter interface{}, doc T) (UpsertResult, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return UpsertResult{}, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	doc = r.normalize(doc)
+	if err := r.validate(doc); err != nil {
+		return UpsertResult{}, err
+	}
+
+	if r.cfg.Outbox.Collection == "" {
+		result, _, err := r.upsertOne(ctx, collectionName, filter, doc)
+		return result, err
+	}
+
+	// Event is appended to outbox in the same transaction
+	var result UpsertResult
+
+	err := r.m.inTransaction(ctx, func(sessCtx context.Context) error {
+
+		var id interface{}
+		var err error
+		if result, id, err = r.upsertOne(sessCtx, collectionName, filter, doc); err != nil || id == nil {
+			return err
+		}
+
+		// Inserted document is read back, as server builds it from filter and update
+		var inserted bson.D
+		if err := r.m.db.Collection(collectionName).FindOne(sessCtx, bson.M{"_id": id}).Decode(&inserted); err != nil {
+			return fmt.Errorf("Function FindOne, returned error: <%w>", err)
+		}
+
+		return r.appendOutbox(sessCtx, r.cfg.Outbox.Created, collectionName, "", inserted)
+	})
+	if err != nil {
+		return UpsertResult{}, err
+	}
+
+	return result, nil
+}
+
+// Replace first document by filter or insert the checked document. Returns result, id of inserted document
+// and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	doc - document
+func (r *Repository[T]) upsertOne(ctx context.Context, collectionName string, filter interface{}, doc T) (UpsertResult, interface{}, error) {
+
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if err := r.ensureIndexes(ctx, collectionName); err != nil {
+		return UpsertResult{}, nil, err
+	}
+
+	update, err := r.replaceDoc(ctx, doc)
+	if err != nil {
+		return UpsertResult{}, nil, err
+	}
+
+	result, err := collection.UpdateOne(ctx, r.liveFilter(filter), update, options.Update().SetUpsert(true))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return UpsertResult{}, nil, ErrDocumentExists
+		}
+		if isServerError(err, codeDocumentValidationFailure) {
+			return UpsertResult{}, nil, schemaValidationError(err)
+		}
+		return UpsertResult{}, nil, fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	if result.UpsertedID != nil {
+		id, _ := result.UpsertedID.(primitive.ObjectID)
+		return UpsertResult{Inserted: true, ID: id}, result.UpsertedID, nil
+	}
+
+	return UpsertResult{}, nil, nil
+}
+
+// Replace first document by filter by imported document. Omitted fields of document are removed, maintained
+// fields are kept from document, missing ones are set as on insert. Id of existing document is kept.
+// Document is checked as on insert. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	doc - document
+func (r *Repository[T]) ReplaceImported(ctx context.Context, collectionName string, filter interface{}, doc T) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	doc = r.normalize(doc)
+	if err := r.validate(doc); err != nil {
+		return err
+	}
+
+	d, err := marshalDoc(r.importDoc(ctx, doc))
+	if err != nil {
+		return err
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if err := r.ensureIndexes(ctx, collectionName); err != nil {
+		return err
+	}
+
+	result, err := collection.ReplaceOne(ctx, r.liveFilter(filter), withoutKeys(d, "_id"))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDocumentExists
+		}
+		if isServerError(err, codeDocumentValidationFailure) {
+			return schemaValidationError(err)
+		}
+		return fmt.Errorf("Function ReplaceOne, returned error: <%w>", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUpdateDocument
+	}
+
+	return nil
+}
+
+// Replace document by key or insert document, if key is not found.
+// Empty key field of document is set by key. Returns result and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+//	doc - document
+func (r *Repository[T]) UpsertByKey(ctx context.Context, collectionName string, key interface{}, doc T) (UpsertResult, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return UpsertResult{}, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return UpsertResult{}, err
+	}
+	if doc, err = r.withKey(doc, key); err != nil {
+		return UpsertResult{}, err
+	}
+
+	return r.Upsert(ctx, collectionName, filter, doc)
+}
+
+// Set empty key field of document by key. Returns document and error.
+//
+// Params:
+//
+//	doc - document
+//	key - value of key field
+func (r *Repository[T]) withKey(doc T, key interface{}) (T, error) {
+
+	v := reflect.ValueOf(&doc).Elem()
+
+	i, _ := bsonFieldIndex(v.Type(), r.cfg.KeyField)
+	field := v.Field(i)
+
+	k, ok := convertValue(field.Type(), key)
+	if !ok {
+		return doc, fmt.Errorf("%w: type %T", ErrKeyMismatch, key)
+	}
+
+	if field.IsZero() {
+		field.Set(k)
+		return doc, nil
+	}
+	if !reflect.DeepEqual(field.Interface(), k.Interface()) {
+		return doc, ErrKeyMismatch
+	}
+
+	return doc, nil
+}
+
+// Delete first document by filter. With DeletedField document is deleted softly.
+// Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+func (r *Repository[T]) Delete(ctx context.Context, collectionName string, filter interface{}) (int64, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return 0, err
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	if r.cfg.DeletedField != "" {
+		result, err := collection.UpdateOne(ctx, r.liveFilter(filter), r.deleteDoc(ctx))
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete document: <%w>", err)
+		}
+
+		return result.ModifiedCount, nil
+	}
+
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete document: <%w>", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// Delete document by key. Returns count deleted documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+func (r *Repository[T]) DeleteByKey(ctx context.Context, collectionName string, key interface{}) (int64, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return 0, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return r.Delete(ctx, collectionName, filter)
+}
+
+// Change collection for first document by filter in transaction. Return error.
+// With context of WithTransaction, relocation is part of that transaction.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	filter - filter
+func (r *Repository[T]) MoveTx(ctx context.Context, srcCollection, destCollection string, filter interface{}) error {
+
+	//
+	// Check
+	//
+
+	if err := r.check(srcCollection); err != nil {
+		return err
+	}
+	if destCollection == "" {
+		return ErrEmptyCollectionsName
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	//
+	// Logic
+	//
+
+	// Operations of transaction of caller
+	if mongo.SessionFromContext(ctx) != nil {
+		return r.move(ctx, srcCollection, destCollection, filter)
+	}
+
+	err := r.m.transaction(ctx, func(sessCtx context.Context) error {
+		return r.move(sessCtx, srcCollection, destCollection, filter)
+	})
+	if err != nil {
+		return fmt.Errorf("Fault transaction: <%w>", err)
+	}
+
+	return nil
+}
+
+// Change collection for first document by filter in session of context. Return error.
+//
+// Params:
+//
+//	ctx - context with session
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	filter - filter
+func (r *Repository[T]) move(ctx context.Context, srcCollection, destCollection string, filter interface{}) error {
+
+	sourceCollection := r.m.db.Collection(srcCollection)
+	destinationCollection := r.m.db.Collection(destCollection)
+
+	var result bson.M
+
+	// Recieve
+	err := sourceCollection.FindOne(ctx, r.liveFilter(filter)).Decode(&result)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return fmt.Errorf("Document is not found: <%w>", err)
+		}
+		return fmt.Errorf("Fault recieve document: <%w>", err)
+	}
+
+	// Insert
+	if err := r.ensureIndexes(ctx, destCollection); err != nil {
+		return fmt.Errorf("Fault insert document: <%w>", err)
+	}
+	r.stampMoved(ctx, result)
+	_, err = destinationCollection.InsertOne(ctx, result)
+	if err != nil {
+		return fmt.Errorf("Fault insert document: <%w>", err)
+	}
+
+	// Delete
+	_, err = sourceCollection.DeleteOne(ctx, bson.M{"_id": result["_id"]})
+	if err != nil {
+		return fmt.Errorf("Fault delete document: <%w>", err)
+	}
+
+	// Event
+	if r.cfg.Outbox.Collection != "" {
+		if err := r.appendOutbox(ctx, r.cfg.Outbox.Moved, destCollection, srcCollection, result); err != nil {
+			return fmt.Errorf("Fault append event: <%w>", err)
+		}
+	}
+
+	return nil
+}
+
+// Change collection for document by key in transaction. Return error.
+//
+// Params:
+//
+//	ctx - context
+//	srcCollection - source collection
+//	destCollection - destination collection
+//	key - value of key field
+func (r *Repository[T]) MoveByKeyTx(ctx context.Context, srcCollection, destCollection string, key interface{}) error {
+
+	// Check
+	if err := r.check(srcCollection); err != nil {
+		return err
+	}
+	if destCollection == "" {
+		return ErrEmptyCollectionsName
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return err
+	}
+
+	return r.MoveTx(ctx, srcCollection, destCollection, filter)
+}
+
+// Find index of struct field by BSON name. Returns index and flag of existence.
+//
+// Params:
+//
+//	t - type of struct
+//	name - BSON name of field
+func bsonFieldIndex(t reflect.Type, name string) (int, bool) {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		if bsonFieldName(t.Field(i)) == name {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
+
+// BSON name of struct field. Returns name or "" for skipped field.
+//
+// Params:
+//
+//	f - field of struct
+func bsonFieldName(f reflect.StructField) string {
+
+	if !f.IsExported() {
+		return ""
+	}
+
+	tag, _, _ := strings.Cut(f.Tag.Get("bson"), ",")
+	if tag == "-" {
+		return ""
+	}
+	if tag == "" {
+		return strings.ToLower(f.Name)
+	}
+
+	return tag
+}
+
+// Values of struct fields by BSON names. Returns filter.
+//
+// Params:
+//
+//	doc - document
+//	fields - BSON names of fields
+func bsonFieldValues(doc interface{}, fields []string) bson.M {
+
+	v := reflect.ValueOf(doc)
+	filter := bson.M{}
+
+	for _, f := range fields {
+
+		if i, ok := bsonFieldIndex(v.Type(), f); ok {
+			filter[f] = v.Field(i).Interface()
+		}
+	}
+
+	return filter
+}
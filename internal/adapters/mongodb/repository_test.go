@@ -0,0 +1,212 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Document for tests of repository.
+type docItem struct {
+	Code  string `bson:"code"`
+	Title string `bson:"title,omitempty"`
+	Count int
+	Skip  string `bson:"-"`
+}
+
+// Test NewRepository.
+func TestNewRepository(t *testing.T) {
+
+	t.Run("Not supported DB", func(t *testing.T) {
+
+		_, err := NewRepository(nil, RepositoryConfig[docItem]{})
+		require.Equalf(t, ErrNotSupportedDB, err, "Error is not equal")
+	})
+
+	t.Run("Not struct type", func(t *testing.T) {
+
+		_, err := NewRepository(&mongoDB{}, RepositoryConfig[string]{})
+		require.Equalf(t, ErrNotStructType, err, "Error is not equal")
+	})
+
+	t.Run("Unknown field", func(t *testing.T) {
+
+		_, err := NewRepository(&mongoDB{}, RepositoryConfig[docItem]{KeyField: "skip"})
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+		_, err = NewRepository(&mongoDB{}, RepositoryConfig[docItem]{UniqueFields: []string{"code", "Title"}})
+		require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		repo, err := NewRepository(&mongoDB{}, RepositoryConfig[docItem]{KeyField: "code", UniqueFields: []string{"code", "count"}})
+		require.NoErrorf(t, err, "Unexpected error")
+		require.NotNil(t, repo, "Pointer repository is nil")
+	})
+}
+
+// Test bsonFieldValues.
+func TestBsonFieldValues(t *testing.T) {
+
+	doc := docItem{Code: "A1", Count: 3, Skip: "x"}
+
+	filter := bsonFieldValues(doc, []string{"code", "title", "count", "skip"})
+	assert.Equalf(t, bson.M{"code": "A1", "title": "", "count": 3}, filter, "Filter is not equal")
+}
+
+// Test checks of repository without connection.
+func TestRepositoryChecks(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("Missing DB", func(t *testing.T) {
+
+		repo := &Repository[docItem]{m: &mongoDB{}}
+
+		_, err := repo.Insert(ctx, "items", docItem{})
+		require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+	})
+
+	t.Run("Missing key field", func(t *testing.T) {
+
+		repo := &Repository[docItem]{m: &mongoDB{}}
+
+		_, err := repo.keyFilter("A1")
+		require.Equalf(t, ErrEmptyKeyField, err, "Error is not equal")
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+
+		repo := &Repository[docItem]{m: &mongoDB{}, cfg: RepositoryConfig[docItem]{KeyField: "code"}}
+
+		_, err := repo.keyFilter("")
+		require.Equalf(t, ErrEmptyValueName, err, "Error is not equal")
+
+		_, err = repo.keyFilter(nil)
+		require.Equalf(t, ErrEmptyValueName, err, "Error is not equal")
+
+		filter, err := repo.keyFilter("A1")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.M{"code": "A1"}, filter, "Filter is not equal")
+	})
+}
+
+// Test withKey.
+func TestRepositoryWithKey(t *testing.T) {
+
+	r := &Repository[docItem]{cfg: RepositoryConfig[docItem]{KeyField: "code"}}
+
+	t.Run("Empty key field", func(t *testing.T) {
+
+		doc, err := r.withKey(docItem{Title: "A"}, "c1")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, docItem{Code: "c1", Title: "A"}, doc, "Document is not equal")
+	})
+
+	t.Run("Equal key", func(t *testing.T) {
+
+		_, err := r.withKey(docItem{Code: "c1"}, "c1")
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Other key", func(t *testing.T) {
+
+		_, err := r.withKey(docItem{Code: "c2"}, "c1")
+		require.Equalf(t, ErrKeyMismatch, err, "Error is not equal")
+	})
+
+	t.Run("Wrong type", func(t *testing.T) {
+
+		_, err := r.withKey(docItem{}, 1)
+		require.Truef(t, errors.Is(err, ErrKeyMismatch), "Error is not equal")
+	})
+}
+
+// Test Repository
+func TestRepository(t *testing.T) {
+
+	dsn := "mongodb://localhost:27017/myDatabase"
+
+	db, err := New(dsn)
+	require.NoErrorf(t, err, "Unexpected error New")
+	require.NotNil(t, db, "Pointer db is nil")
+
+	defer func() {
+		err = db.Close()
+		assert.NoErrorf(t, err, "Unexpected error Close")
+	}()
+
+	repo, err := NewRepository(db, RepositoryConfig[docItem]{
+		KeyField:     "code",
+		UniqueFields: []string{"code"},
+		Validate: func(doc docItem) error {
+			if doc.Code == "" {
+				return ErrEmptyDocument
+			}
+			return nil
+		},
+	})
+	require.NoErrorf(t, err, "Unexpected error NewRepository")
+
+	ctx := context.Background()
+	collections := []string{"items-1", "items-2"}
+
+	defer func() {
+		err := db.DropCollection(collections[0])
+		require.NoErrorf(t, err, "Unexpected error DropCollection 0")
+
+		err = db.DropCollection(collections[1])
+		require.NoErrorf(t, err, "Unexpected error DropCollection 1")
+	}()
+
+	for _, c := range collections {
+		err = repo.EnsureIndexes(ctx, c)
+		require.NoErrorf(t, err, "Unexpected error EnsureIndexes")
+	}
+
+	t.Run("Missing document", func(t *testing.T) {
+
+		_, err := repo.Insert(ctx, collections[0], docItem{})
+		require.Equalf(t, ErrEmptyDocument, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		item := docItem{Code: "A1", Title: "First", Count: 1}
+
+		// Insert
+		_, err := repo.Insert(ctx, collections[0], item)
+		require.NoErrorf(t, err, "Unexpected error insert")
+
+		_, err = repo.Insert(ctx, collections[0], item)
+		require.Equalf(t, ErrDocumentExists, err, "Error is not equal")
+
+		// Update
+		item.Count = 2
+		err = repo.UpdateByKey(ctx, collections[0], item.Code, item)
+		require.NoErrorf(t, err, "Unexpected error update")
+
+		// Find
+		rxItem, err := repo.FindOneByKey(ctx, collections[0], item.Code)
+		require.NoErrorf(t, err, "Unexpected error find")
+		assert.Equalf(t, item, rxItem, "Document is not equal")
+
+		items, err := repo.Find(ctx, collections[0], bson.M{"count": 2})
+		require.NoErrorf(t, err, "Unexpected error find")
+		assert.Equalf(t, []docItem{item}, items, "Documents is not equal")
+
+		// Move
+		err = repo.MoveByKeyTx(ctx, collections[0], collections[1], item.Code)
+		require.NoErrorf(t, err, "Unexpected error move")
+
+		// Delete
+		cnt, err := repo.DeleteByKey(ctx, collections[1], item.Code)
+		require.NoErrorf(t, err, "Unexpected error delete")
+		assert.Equalf(t, int64(1), cnt, "Value is not equal")
+	})
+}
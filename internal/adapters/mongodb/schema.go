@@ -0,0 +1,516 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Code of server error: document failed validation.
+const codeDocumentValidationFailure = 121
+
+// Pattern of email for schema and validation.
+const emailPattern = `^[^@\s]+@[^@\s]+\.[^@\s]+$`
+
+// Options of applying schema.
+type SchemaOptions struct {
+	// Level of validation: off, strict, moderate. Empty - strict
+	Level string
+	// Action of validation: error, warn. Empty - error
+	Action string
+}
+
+// Rules of field from tag validate, e.g. `validate:"required,min=1,max=150"`.
+//
+// Supported rules:
+//
+//	required - field must exist
+//	min=N, max=N - bounds of number or length of string
+//	email - string is email
+//	pattern=RE - string matches regular expression without commas
+//	enum=a|b|c - string is one of values
+type fieldRules struct {
+	required bool
+	min      *float64
+	max      *float64
+	email    bool
+	pattern  string
+	enum     []string
+}
+
+// Parse rules of field. Returns rules and error.
+//
+// Params:
+//
+//	tag - value of tag validate
+func parseRules(tag string) (fieldRules, error) {
+
+	var r fieldRules
+
+	for _, part := range strings.Split(tag, ",") {
+
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch key {
+		case "":
+		case "required":
+			r.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fieldRules{}, fmt.Errorf("%w: %s", ErrNotCorrectRule, part)
+			}
+			if key == "min" {
+				r.min = &n
+			} else {
+				r.max = &n
+			}
+		case "email":
+			r.email = true
+		case "pattern":
+			if value == "" {
+				return fieldRules{}, fmt.Errorf("%w: %s", ErrNotCorrectRule, part)
+			}
+			if _, err := compiledPattern(value); err != nil {
+				return fieldRules{}, fmt.Errorf("%w: %s: %v", ErrNotCorrectRule, part, err)
+			}
+			r.pattern = value
+		case "enum":
+			if value == "" {
+				return fieldRules{}, fmt.Errorf("%w: %s", ErrNotCorrectRule, part)
+			}
+			r.enum = strings.Split(value, "|")
+		default:
+			return fieldRules{}, fmt.Errorf("%w: %s", ErrNotCorrectRule, part)
+		}
+	}
+
+	return r, nil
+}
+
+// Generate $jsonSchema from struct by tags bson and validate. Returns schema and error.
+//
+// Params:
+//
+//	v - struct or pointer to struct
+func JSONSchema(v interface{}) (bson.M, error) {
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrNotStructType
+	}
+
+	return structSchema(t)
+}
+
+// Schema of struct. Returns schema and error.
+//
+// Params:
+//
+//	t - type of struct
+func structSchema(t reflect.Type) (bson.M, error) {
+
+	properties := bson.M{}
+	required := bson.A{}
+
+	if err := addStructProperties(t, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := bson.M{"bsonType": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema, nil
+}
+
+// Add properties of struct fields. Returns error.
+//
+// Params:
+//
+//	t - type of struct
+//	properties - properties of schema
+//	required - names of required properties
+func addStructProperties(t reflect.Type, properties bson.M, required *bson.A) error {
+
+	for i := 0; i < t.NumField(); i++ {
+
+		f := t.Field(i)
+		name := bsonFieldName(f)
+		if name == "" {
+			continue
+		}
+
+		// Fields of inline struct belong to parent
+		if _, opts, _ := strings.Cut(f.Tag.Get("bson"), ","); strings.Contains(opts, "inline") && f.Type.Kind() == reflect.Struct {
+			if err := addStructProperties(f.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+
+		schema, err := typeSchema(f.Type)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+
+		rules, err := parseRules(f.Tag.Get("validate"))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", name, err)
+		}
+		applyRules(schema, f.Type, rules)
+
+		if rules.required {
+			*required = append(*required, name)
+		}
+		properties[name] = schema
+	}
+
+	return nil
+}
+
+// Add rules of field to schema.
+//
+// Params:
+//
+//	schema - schema of field
+//	t - type of field
+//	rules - rules of field
+func applyRules(schema bson.M, t reflect.Type, rules fieldRules) {
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.String {
+		if rules.min != nil {
+			schema["minLength"] = int64(*rules.min)
+		}
+		if rules.max != nil {
+			schema["maxLength"] = int64(*rules.max)
+		}
+		if rules.email {
+			schema["pattern"] = emailPattern
+		}
+		if rules.pattern != "" {
+			schema["pattern"] = rules.pattern
+		}
+		if len(rules.enum) > 0 {
+			enum := bson.A{}
+			for _, e := range rules.enum {
+				enum = append(enum, e)
+			}
+			schema["enum"] = enum
+		}
+		return
+	}
+
+	if rules.min != nil {
+		schema["minimum"] = *rules.min
+	}
+	if rules.max != nil {
+		schema["maximum"] = *rules.max
+	}
+}
+
+// Schema of type. Returns schema and error.
+//
+// Params:
+//
+//	t - type
+func typeSchema(t reflect.Type) (bson.M, error) {
+
+	switch t {
+	case reflect.TypeOf(time.Time{}), reflect.TypeOf(primitive.DateTime(0)):
+		return bson.M{"bsonType": "date"}, nil
+	case reflect.TypeOf(primitive.ObjectID{}):
+		return bson.M{"bsonType": "objectId"}, nil
+	case reflect.TypeOf(primitive.Decimal128{}):
+		return bson.M{"bsonType": "decimal"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		if bt, ok := schema["bsonType"]; ok {
+			if list, ok := bt.(bson.A); ok {
+				schema["bsonType"] = append(list, "null")
+			} else {
+				schema["bsonType"] = bson.A{bt, "null"}
+			}
+		}
+		return schema, nil
+	case reflect.String:
+		return bson.M{"bsonType": "string"}, nil
+	case reflect.Bool:
+		return bson.M{"bsonType": "bool"}, nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return bson.M{"bsonType": "int"}, nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return bson.M{"bsonType": bson.A{"int", "long"}}, nil
+	case reflect.Float32, reflect.Float64:
+		return bson.M{"bsonType": "double"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return bson.M{"bsonType": "binData"}, nil
+		}
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"bsonType": "array", "items": items}, nil
+	case reflect.Map:
+		return bson.M{"bsonType": "object"}, nil
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Interface:
+		return bson.M{}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrNotSupportedType, t)
+}
+
+// Apply $jsonSchema, generated from struct, as validator of collection.
+// Missing collection is created, existing collection is modified. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	v - struct with tags bson and validate
+//	opts - options
+func (m *mongoDB) ApplySchema(ctx context.Context, collectionName string, v interface{}, opts SchemaOptions) error {
+
+	// Check
+	if m.db == nil {
+		return ErrNilPtrDB
+	}
+	schema, spec, err := schemaSpec(collectionName, v, opts)
+	if err != nil {
+		return err
+	}
+
+	// Logic
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	names, err := m.db.ListCollectionNames(ctx, bson.M{"name": collectionName})
+	if err != nil {
+		return fmt.Errorf("failed to list collection names: %v", err)
+	}
+
+	if !IsExistsCollection(names, collectionName) {
+		err := m.db.CreateCollection(ctx, collectionName, spec.options())
+		if err == nil {
+			return nil
+		}
+		if !isServerError(err, codeNamespaceExists) {
+			return fmt.Errorf("failed to create collection %s: <%w>", collectionName, err)
+		}
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: collectionName},
+		{Key: "validator", Value: bson.M{"$jsonSchema": schema}},
+		{Key: "validationLevel", Value: spec.ValidationLevel},
+		{Key: "validationAction", Value: spec.ValidationAction},
+	}
+	if err := m.db.RunCommand(ctx, cmd).Err(); err != nil {
+		return fmt.Errorf("failed to modify collection %s: <%w>", collectionName, err)
+	}
+
+	return nil
+}
+
+// Check arguments of applying schema. Returns schema, declaration of collection and error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	v - struct with tags bson and validate
+//	opts - options
+func schemaSpec(collectionName string, v interface{}, opts SchemaOptions) (bson.M, CollectionSpec, error) {
+
+	schema, err := JSONSchema(v)
+	if err != nil {
+		return nil, CollectionSpec{}, err
+	}
+
+	spec := CollectionSpec{
+		Name:             collectionName,
+		Validator:        bson.M{"$jsonSchema": schema},
+		ValidationLevel:  opts.Level,
+		ValidationAction: opts.Action,
+	}
+	if spec.ValidationLevel == "" {
+		spec.ValidationLevel = ValidationLevelStrict
+	}
+	if spec.ValidationAction == "" {
+		spec.ValidationAction = ValidationActionError
+	}
+	if err := spec.Check(); err != nil {
+		return nil, CollectionSpec{}, err
+	}
+
+	return schema, spec, nil
+}
+
+// Check arguments of applying schema. Returns error.
+//
+// Params:
+//
+//	collectionName - name of collection
+//	v - struct with tags bson and validate
+//	opts - options
+func CheckSchema(collectionName string, v interface{}, opts SchemaOptions) error {
+
+	_, _, err := schemaSpec(collectionName, v, opts)
+	return err
+}
+
+// Error of server validation of document.
+type SchemaValidationError struct {
+	// Paths of failing fields, e.g. "age", "address.city"
+	Fields []string
+	// Error of server
+	Err error
+}
+
+// Text of error.
+func (e *SchemaValidationError) Error() string {
+
+	if len(e.Fields) == 0 {
+		return ErrSchemaValidation.Error()
+	}
+
+	return ErrSchemaValidation.Error() + ": " + strings.Join(e.Fields, ", ")
+}
+
+// Wrapped errors.
+func (e *SchemaValidationError) Unwrap() []error {
+
+	if e.Err != nil {
+		return []error{ErrSchemaValidation, e.Err}
+	}
+
+	return []error{ErrSchemaValidation}
+}
+
+// Convert server error of validation to SchemaValidationError. Returns error.
+//
+// Params:
+//
+//	err - error of write
+func schemaValidationError(err error) error {
+
+	if !isServerError(err, codeDocumentValidationFailure) {
+		return err
+	}
+
+	result := &SchemaValidationError{Err: err}
+
+	var we mongo.WriteException
+	if errors.As(err, &we) {
+		for _, e := range we.WriteErrors {
+			if e.Code != codeDocumentValidationFailure || e.Details == nil {
+				continue
+			}
+			var details bson.D
+			if bson.Unmarshal(e.Details, &details) == nil {
+				result.Fields = append(result.Fields, failingFields(details, "")...)
+			}
+		}
+	}
+
+	// Paths are unique in order of appearance
+	seen := map[string]bool{}
+	fields := result.Fields[:0]
+	for _, f := range result.Fields {
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
+		}
+	}
+	result.Fields = fields
+
+	return result
+}
+
+// Value of document by key. Returns value or nil.
+//
+// Params:
+//
+//	d - document
+//	key - key
+func lookup(d bson.D, key string) interface{} {
+
+	for _, e := range d {
+		if e.Key == key {
+			return e.Value
+		}
+	}
+
+	return nil
+}
+
+// Paths of failing fields from details of server error. Returns paths.
+//
+// Params:
+//
+//	v - part of details
+//	prefix - path of parent field
+func failingFields(v interface{}, prefix string) []string {
+
+	fields := []string{}
+
+	switch d := v.(type) {
+	case bson.D:
+		if missing, ok := lookup(d, "missingProperties").(bson.A); ok {
+			for _, name := range missing {
+				fields = append(fields, prefix+fmt.Sprint(name))
+			}
+		}
+
+		if props, ok := lookup(d, "propertiesNotSatisfied").(bson.A); ok {
+			for _, p := range props {
+				pd, ok := p.(bson.D)
+				if !ok {
+					continue
+				}
+				name := fmt.Sprint(lookup(pd, "propertyName"))
+				nested := failingFields(lookup(pd, "details"), prefix+name+".")
+				if len(nested) == 0 {
+					nested = []string{prefix + name}
+				}
+				fields = append(fields, nested...)
+			}
+		}
+
+		for _, e := range d {
+			if e.Key != "missingProperties" && e.Key != "propertiesNotSatisfied" {
+				fields = append(fields, failingFields(e.Value, prefix)...)
+			}
+		}
+	case bson.A:
+		for _, e := range d {
+			fields = append(fields, failingFields(e, prefix)...)
+		}
+	}
+
+	return fields
+}
@@ -0,0 +1,174 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Document for tests of schema.
+type docProfile struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	Login   string             `bson:"login" validate:"required,min=3,max=20,pattern=^[a-z]+$"`
+	Role    string             `bson:"role" validate:"enum=admin|user"`
+	Score   float64            `bson:"score" validate:"min=0,max=1"`
+	Tags    []string           `bson:"tags"`
+	Avatar  []byte             `bson:"avatar,omitempty"`
+	Born    *time.Time         `bson:"born,omitempty"`
+	Address struct {
+		City string `bson:"city" validate:"required"`
+	} `bson:"address"`
+	Extra  map[string]string `bson:"extra"`
+	Hidden string            `bson:"-"`
+}
+
+// Test JSONSchema.
+func TestJSONSchema(t *testing.T) {
+
+	t.Run("Not struct", func(t *testing.T) {
+
+		_, err := JSONSchema(5)
+		require.Equalf(t, ErrNotStructType, err, "Error is not equal")
+
+		_, err = JSONSchema(nil)
+		require.Equalf(t, ErrNotStructType, err, "Error is not equal")
+	})
+
+	t.Run("Not correct rule", func(t *testing.T) {
+
+		type doc struct {
+			Age int `bson:"age" validate:"min=abc"`
+		}
+
+		_, err := JSONSchema(doc{})
+		require.Truef(t, errors.Is(err, ErrNotCorrectRule), "Error is not equal")
+	})
+
+	t.Run("Not correct pattern", func(t *testing.T) {
+
+		type doc struct {
+			Login string `bson:"login" validate:"pattern=^[a-z+$"`
+		}
+
+		_, err := JSONSchema(doc{})
+		require.Truef(t, errors.Is(err, ErrNotCorrectRule), "Error is not equal")
+
+		errs := validateStruct(doc{Login: "abc"}, false)
+		require.Lenf(t, errs, 1, "Count of errors is not equal")
+		assert.Equalf(t, ErrNotCorrectRule, errs[0].Err, "Error is not equal")
+	})
+
+	t.Run("Not supported type", func(t *testing.T) {
+
+		type doc struct {
+			Ch chan int `bson:"ch"`
+		}
+
+		_, err := JSONSchema(doc{})
+		require.Truef(t, errors.Is(err, ErrNotSupportedType), "Error is not equal")
+	})
+
+	t.Run("DocUser", func(t *testing.T) {
+
+		schema, err := JSONSchema(DocUser{})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{
+			"bsonType": "object",
+			"required": bson.A{"name", "age"},
+			"properties": bson.M{
+				"_id":       bson.M{"bsonType": "objectId"},
+				"name":      bson.M{"bsonType": "string", "maxLength": int64(100), "pattern": `^\p{L}[\p{L} .'-]*$`},
+				"age":       bson.M{"bsonType": bson.A{"int", "long"}, "minimum": float64(1), "maximum": float64(150)},
+				"email":     bson.M{"bsonType": "string", "maxLength": int64(254), "pattern": emailPattern},
+				"version":   bson.M{"bsonType": bson.A{"int", "long"}},
+				"createdAt": bson.M{"bsonType": "date"},
+				"updatedAt": bson.M{"bsonType": "date"},
+				"createdBy": bson.M{"bsonType": "string"},
+				"updatedBy": bson.M{"bsonType": "string"},
+				"deletedAt": bson.M{"bsonType": "date"},
+			},
+		}
+		assert.Equalf(t, want, schema, "Schema is not equal")
+	})
+
+	t.Run("Types and rules", func(t *testing.T) {
+
+		schema, err := JSONSchema(&docProfile{})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.M{
+			"bsonType": "object",
+			"required": bson.A{"login"},
+			"properties": bson.M{
+				"_id":    bson.M{"bsonType": "objectId"},
+				"login":  bson.M{"bsonType": "string", "minLength": int64(3), "maxLength": int64(20), "pattern": "^[a-z]+$"},
+				"role":   bson.M{"bsonType": "string", "enum": bson.A{"admin", "user"}},
+				"score":  bson.M{"bsonType": "double", "minimum": float64(0), "maximum": float64(1)},
+				"tags":   bson.M{"bsonType": "array", "items": bson.M{"bsonType": "string"}},
+				"avatar": bson.M{"bsonType": "binData"},
+				"born":   bson.M{"bsonType": bson.A{"date", "null"}},
+				"address": bson.M{
+					"bsonType":   "object",
+					"required":   bson.A{"city"},
+					"properties": bson.M{"city": bson.M{"bsonType": "string"}},
+				},
+				"extra": bson.M{"bsonType": "object"},
+			},
+		}
+		assert.Equalf(t, want, schema, "Schema is not equal")
+	})
+}
+
+// Test schemaValidationError.
+func TestSchemaValidationError(t *testing.T) {
+
+	t.Run("Other error", func(t *testing.T) {
+
+		err := errors.New("other")
+		require.Equalf(t, err, schemaValidationError(err), "Error is not equal")
+	})
+
+	t.Run("Details", func(t *testing.T) {
+
+		details, err := bson.Marshal(bson.D{
+			{Key: "failingDocumentId", Value: 1},
+			{Key: "details", Value: bson.D{
+				{Key: "operatorName", Value: "$jsonSchema"},
+				{Key: "schemaRulesNotSatisfied", Value: bson.A{
+					bson.D{
+						{Key: "operatorName", Value: "properties"},
+						{Key: "propertiesNotSatisfied", Value: bson.A{
+							bson.D{{Key: "propertyName", Value: "age"}, {Key: "details", Value: bson.A{bson.D{{Key: "operatorName", Value: "minimum"}}}}},
+							bson.D{{Key: "propertyName", Value: "address"}, {Key: "details", Value: bson.A{
+								bson.D{{Key: "operatorName", Value: "required"}, {Key: "missingProperties", Value: bson.A{"city"}}},
+							}}},
+						}},
+					},
+					bson.D{{Key: "operatorName", Value: "required"}, {Key: "missingProperties", Value: bson.A{"login", "age"}}},
+				}},
+			}},
+		})
+		require.NoErrorf(t, err, "Unexpected error marshal")
+
+		serverErr := mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+			Code:    codeDocumentValidationFailure,
+			Message: "Document failed validation",
+			Details: details,
+		}}}
+
+		err = schemaValidationError(serverErr)
+		require.Truef(t, errors.Is(err, ErrSchemaValidation), "Error is not equal")
+
+		var validationErr *SchemaValidationError
+		require.Truef(t, errors.As(err, &validationErr), "Error type is not equal")
+		assert.Equalf(t, []string{"age", "address.city", "login"}, validationErr.Fields, "Fields are not equal")
+		assert.Equalf(t, "Document failed validation of schema: age, address.city, login", err.Error(), "Text is not equal")
+	})
+}
@@ -0,0 +1,216 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Find softly deleted documents by filter. Returns documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+func (r *Repository[T]) FindDeleted(ctx context.Context, collectionName string, filter interface{}) (docs []T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return nil, err
+	}
+	if r.cfg.DeletedField == "" {
+		return nil, ErrNotSoftDelete
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	cursor, err := collection.Find(ctx, r.deletedFilter(filter))
+	if err != nil {
+		return nil, fmt.Errorf("Function Find return error: <%w>", err)
+	}
+
+	docs = []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, fmt.Errorf("Function All return error: <%w>", err)
+	}
+
+	return docs, nil
+}
+
+// Restore first softly deleted document by filter. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+func (r *Repository[T]) Restore(ctx context.Context, collectionName string, filter interface{}) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	if r.cfg.DeletedField == "" {
+		return ErrNotSoftDelete
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	update := bson.D{{Key: "$unset", Value: bson.D{{Key: r.cfg.DeletedField, Value: ""}}}}
+	update = r.stampUpdated(ctx, update)
+	if r.cfg.VersionField != "" {
+		update = mergeOp(update, "$inc", r.incVersion().Value.(bson.D))
+	}
+
+	result, err := collection.UpdateOne(ctx, r.deletedFilter(filter), update)
+	if err != nil {
+		// Live document holds values of unique index
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDocumentExists
+		}
+		return fmt.Errorf("Function UpdateOne, returned error: <%w>", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrUpdateDocument
+	}
+
+	return nil
+}
+
+// Restore softly deleted document by key. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+func (r *Repository[T]) RestoreByKey(ctx context.Context, collectionName string, key interface{}) error {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return err
+	}
+
+	return r.Restore(ctx, collectionName, filter)
+}
+
+// Remove softly deleted documents, which are deleted before retention from now.
+// Returns count removed documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	retention - time of keeping of deleted documents, 0 - all deleted documents
+func (r *Repository[T]) Purge(ctx context.Context, collectionName string, retention time.Duration) (int64, error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return 0, err
+	}
+	if r.cfg.DeletedField == "" {
+		return 0, ErrNotSoftDelete
+	}
+	if retention < 0 {
+		return 0, ErrNegativeRetention
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	filter := bson.M{r.cfg.DeletedField: bson.M{"$lte": r.now().Add(-retention)}}
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	result, err := collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("Function DeleteMany, returned error: <%w>", err)
+	}
+
+	return result.DeletedCount, nil
+}
+
+// Filter, which excludes softly deleted documents. Returns filter.
+//
+// Params:
+//
+//	filter - filter
+func (r *Repository[T]) liveFilter(filter interface{}) interface{} {
+
+	if r.cfg.DeletedField == "" {
+		return filter
+	}
+
+	return bson.M{"$and": bson.A{filter, bson.M{r.cfg.DeletedField: bson.M{"$exists": false}}}}
+}
+
+// Filter of softly deleted documents. Returns filter.
+//
+// Params:
+//
+//	filter - filter
+func (r *Repository[T]) deletedFilter(filter interface{}) interface{} {
+
+	return bson.M{"$and": bson.A{filter, bson.M{r.cfg.DeletedField: bson.M{"$exists": true}}}}
+}
+
+// Document of update by soft delete. Returns document.
+//
+// Params:
+//
+//	ctx - context
+func (r *Repository[T]) deleteDoc(ctx context.Context) bson.D {
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: r.cfg.DeletedField, Value: r.now()}}}}
+	update = r.stampUpdated(ctx, update)
+
+	if r.cfg.VersionField != "" {
+		update = mergeOp(update, "$inc", r.incVersion().Value.(bson.D))
+	}
+
+	return update
+}
+
+// Document without time of soft delete. Returns document.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) notDeleted(doc T) T {
+
+	if r.cfg.DeletedField == "" {
+		return doc
+	}
+
+	v := reflect.ValueOf(&doc).Elem()
+	if i, ok := bsonFieldIndex(v.Type(), r.cfg.DeletedField); ok {
+		v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+	}
+
+	return doc
+}
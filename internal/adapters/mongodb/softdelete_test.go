@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Document for tests of soft delete.
+type docDeleted struct {
+	Code      string    `bson:"code"`
+	Title     string    `bson:"title,omitempty"`
+	Version   int64     `bson:"version,omitempty"`
+	DeletedAt time.Time `bson:"deletedAt,omitempty"`
+}
+
+// Test NewRepository with deleted field.
+func TestNewRepositorySoftDelete(t *testing.T) {
+
+	_, err := NewRepository(&mongoDB{}, RepositoryConfig[docDeleted]{DeletedField: "removedAt"})
+	require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+	_, err = NewRepository(&mongoDB{}, RepositoryConfig[docDeleted]{DeletedField: "title"})
+	require.Truef(t, errors.Is(err, ErrNotSupportedType), "Error is not equal")
+
+	_, err = NewRepository(&mongoDB{}, RepositoryConfig[docDeleted]{DeletedField: "deletedAt"})
+	require.NoErrorf(t, err, "Unexpected error")
+}
+
+// Test filters and documents of update with soft delete.
+func TestSoftDeleteUpdates(t *testing.T) {
+
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
+	r := &Repository[docDeleted]{
+		m:   &mongoDB{clock: func() time.Time { return now }},
+		cfg: RepositoryConfig[docDeleted]{VersionField: "version", DeletedField: "deletedAt"},
+	}
+	filter := bson.M{"code": "c1"}
+
+	t.Run("Filters", func(t *testing.T) {
+
+		want := bson.M{"$and": bson.A{filter, bson.M{"deletedAt": bson.M{"$exists": false}}}}
+		assert.Equalf(t, want, r.liveFilter(filter), "Filter is not equal")
+
+		want = bson.M{"$and": bson.A{filter, bson.M{"deletedAt": bson.M{"$exists": true}}}}
+		assert.Equalf(t, want, r.deletedFilter(filter), "Filter is not equal")
+
+		plain := &Repository[docDeleted]{}
+		assert.Equalf(t, filter, plain.liveFilter(filter), "Filter is not equal")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+
+		want := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "deletedAt", Value: now}}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+		assert.Equalf(t, want, r.deleteDoc(context.Background()), "Update is not equal")
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+
+		doc := r.insertDoc(context.Background(), docDeleted{Code: "c1", DeletedAt: now})
+		assert.Equalf(t, docDeleted{Code: "c1", Version: 1}, doc, "Document is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		update, err := r.updateDoc(context.Background(), docDeleted{Code: "c1", DeletedAt: now})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "code", Value: "c1"}}},
+			{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}},
+		}
+		assert.Equalf(t, want, update, "Update is not equal")
+	})
+}
@@ -0,0 +1,318 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Count of retries of transaction, used when settings have no count.
+const defaultTxRetries = 3
+
+// Labels of errors, which allow retry of transaction.
+const (
+	// Retry whole transaction
+	labelTransientTransaction = "TransientTransactionError"
+	// Retry commit of transaction
+	labelUnknownCommitResult = "UnknownTransactionCommitResult"
+)
+
+// Settings of transaction.
+type TxOptions struct {
+	// Read concern of transaction. Nil - of client
+	ReadConcern *readconcern.ReadConcern
+	// Write concern of transaction. Nil - of client
+	WriteConcern *writeconcern.WriteConcern
+	// Maximum time of commit. 0 - without limit
+	MaxCommitTime time.Duration
+	// Count of retries on TransientTransactionError and UnknownTransactionCommitResult. 0 - defaultTxRetries
+	MaxRetries int
+}
+
+// Operations in transaction. Operations are bound to session of transaction.
+type Tx interface {
+	// Context of transaction. Operations of repositories with it are in transaction
+	Context() context.Context
+	// Send new document user
+	SendDocumentUser(collectionName string, doc DocUser) (primitive.ObjectID, error)
+	// Recieve document user by name
+	RecvDocumentUserByName(collectionName, name string) (DocUser, error)
+	// Recieve document user by id
+	RecvDocumentUserByID(collectionName string, id primitive.ObjectID) (DocUser, error)
+	// Update document user by name
+	UpdateDocumentUserByName(collectionName, name string, doc DocUser) error
+	// Patch document user by name, returns updated document
+	PatchDocumentUserByName(collectionName, name string, p *Patch) (DocUser, error)
+	// Replace document user by name or insert it
+	UpsertDocumentUserByName(collectionName, name string, doc DocUser) (UpsertResult, error)
+	// Delete document user by name
+	DelDocumentUserByName(collectionName, name string) (int64, error)
+	// Relocate document by name
+	MoveDocumentUser(srcCollection, destCollection string, doc DocUser) error
+	// Find documents user by query
+	FindDocumentsUser(collectionName string, q *UserQuery) (docs []DocUser, next string, err error)
+}
+
+// Check of settings. Returns error.
+func (o TxOptions) check() error {
+
+	if o.MaxCommitTime < 0 || o.MaxRetries < 0 {
+		return ErrValueTxOptions
+	}
+
+	return nil
+}
+
+// Count of retries. Returns count.
+func (o TxOptions) retries() int {
+
+	if o.MaxRetries == 0 {
+		return defaultTxRetries
+	}
+
+	return o.MaxRetries
+}
+
+// Options of transaction for driver. Returns options.
+func (o TxOptions) transactionOptions() *options.TransactionOptions {
+
+	opts := options.Transaction()
+	if o.ReadConcern != nil {
+		opts.SetReadConcern(o.ReadConcern)
+	}
+	if o.WriteConcern != nil {
+		opts.SetWriteConcern(o.WriteConcern)
+	}
+	if o.MaxCommitTime > 0 {
+		opts.SetMaxCommitTime(&o.MaxCommitTime)
+	}
+
+	return opts
+}
+
+// Execute function in transaction. Transaction is committed, if function returns nil, else it is aborted.
+// Function is executed again on TransientTransactionError, so it must have no side effects out of DB.
+// Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	fn - operations of transaction
+func (m *mongoDB) WithTransaction(ctx context.Context, fn func(tx Tx) error) error {
+
+	// Check
+	if fn == nil {
+		return ErrEmptyTxFunction
+	}
+
+	// Logic
+	return m.transaction(ctx, func(sessCtx context.Context) error {
+		return fn(&tx{m: m, ctx: sessCtx})
+	})
+}
+
+// Execute function in transaction of context or in new transaction. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	fn - operations of transaction, gets context of session
+func (m *mongoDB) inTransaction(ctx context.Context, fn func(sessCtx context.Context) error) error {
+
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	return m.transaction(ctx, fn)
+}
+
+// Execute function in transaction of new session with retries. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	fn - operations of transaction, gets context of session
+func (m *mongoDB) transaction(ctx context.Context, fn func(sessCtx context.Context) error) error {
+
+	ctx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	session, err := m.connect.StartSession()
+	if err != nil {
+		return fmt.Errorf("Error start session: <%w>", err)
+	}
+	defer session.EndSession(context.WithoutCancel(ctx))
+
+	retries := m.txOptions.retries()
+
+	for attempt := 0; ; attempt++ {
+
+		err := m.transactionAttempt(ctx, session, fn)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < retries && hasErrorLabel(err, labelTransientTransaction) && ctx.Err() == nil {
+			continue
+		}
+
+		return err
+	}
+}
+
+// Execute function in one transaction of session. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	session - session
+//	fn - operations of transaction, gets context of session
+func (m *mongoDB) transactionAttempt(ctx context.Context, session mongo.Session, fn func(sessCtx context.Context) error) error {
+
+	if err := session.StartTransaction(m.txOptions.transactionOptions()); err != nil {
+		return fmt.Errorf("Error start transaction: <%w>", err)
+	}
+
+	err := mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		return fn(sessCtx)
+	})
+	if err != nil {
+		_ = session.AbortTransaction(context.WithoutCancel(ctx))
+		return err
+	}
+
+	retries := m.txOptions.retries()
+
+	for attempt := 0; ; attempt++ {
+
+		err = session.CommitTransaction(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt < retries && hasErrorLabel(err, labelUnknownCommitResult) && ctx.Err() == nil {
+			continue
+		}
+
+		return fmt.Errorf("Error commit transaction: <%w>", err)
+	}
+}
+
+// Check, that error of server has label. Returns flag.
+//
+// Params:
+//
+//	err - error
+//	label - label of error
+func hasErrorLabel(err error, label string) bool {
+
+	var se mongo.ServerError
+	if errors.As(err, &se) {
+		return se.HasErrorLabel(label)
+	}
+
+	return false
+}
+
+// Context without session, for operations, which are not allowed in transaction. Returns context.
+//
+// Params:
+//
+//	ctx - context
+func withoutSession(ctx context.Context) context.Context {
+
+	if mongo.SessionFromContext(ctx) == nil {
+		return ctx
+	}
+
+	return sessionlessContext{ctx}
+}
+
+// Context, which hides session of parent. Other values, e.g. actor, are kept.
+type sessionlessContext struct {
+	context.Context
+}
+
+// Value by key. Returns value of parent or nil for session of driver.
+func (c sessionlessContext) Value(key any) any {
+
+	v := c.Context.Value(key)
+	if _, ok := v.(mongo.Session); ok {
+		return nil
+	}
+
+	return v
+}
+
+// Operations bound to session of transaction.
+type tx struct {
+	m   *mongoDB
+	ctx context.Context
+}
+
+// Context of transaction.
+func (t *tx) Context() context.Context {
+
+	return t.ctx
+}
+
+// Send new document user. Returns id added document and error.
+func (t *tx) SendDocumentUser(collectionName string, doc DocUser) (primitive.ObjectID, error) {
+
+	return t.m.SendDocumentUserCtx(t.ctx, collectionName, doc)
+}
+
+// Recieve document user by name. Returns document and error.
+func (t *tx) RecvDocumentUserByName(collectionName, name string) (DocUser, error) {
+
+	return t.m.RecvDocumentUserByNameCtx(t.ctx, collectionName, name)
+}
+
+// Recieve document user by id. Returns document and error.
+func (t *tx) RecvDocumentUserByID(collectionName string, id primitive.ObjectID) (DocUser, error) {
+
+	return t.m.RecvDocumentUserByID(t.ctx, collectionName, id)
+}
+
+// Update document user by name. Returns error.
+func (t *tx) UpdateDocumentUserByName(collectionName, name string, doc DocUser) error {
+
+	return t.m.UpdateDocumentUserByNameCtx(t.ctx, collectionName, name, doc)
+}
+
+// Patch document user by name. Returns updated document and error.
+func (t *tx) PatchDocumentUserByName(collectionName, name string, p *Patch) (DocUser, error) {
+
+	return t.m.PatchDocumentUserByName(t.ctx, collectionName, name, p)
+}
+
+// Replace document user by name or insert it. Returns result and error.
+func (t *tx) UpsertDocumentUserByName(collectionName, name string, doc DocUser) (UpsertResult, error) {
+
+	return t.m.UpsertDocumentUserByName(t.ctx, collectionName, name, doc)
+}
+
+// Delete document user by name. Returns count deleted documents and error.
+func (t *tx) DelDocumentUserByName(collectionName, name string) (int64, error) {
+
+	return t.m.DelDocumentUserByNameCtx(t.ctx, collectionName, name)
+}
+
+// Relocate document by name. Returns error.
+func (t *tx) MoveDocumentUser(srcCollection, destCollection string, doc DocUser) error {
+
+	return t.m.MoveDocumentUserTxCtx(t.ctx, srcCollection, destCollection, doc)
+}
+
+// Find documents user by query. Returns documents, token of next page and error.
+func (t *tx) FindDocumentsUser(collectionName string, q *UserQuery) (docs []DocUser, next string, err error) {
+
+	return t.m.FindDocumentsUser(t.ctx, collectionName, q)
+}
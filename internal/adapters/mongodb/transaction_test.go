@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// Test settings of transaction
+func TestTxOptions(t *testing.T) {
+
+	t.Run("Defaults", func(t *testing.T) {
+
+		o := TxOptions{}
+		require.NoErrorf(t, o.check(), "Unexpected error")
+		assert.Equalf(t, defaultTxRetries, o.retries(), "Retries are not equal")
+
+		opts := o.transactionOptions()
+		assert.Nilf(t, opts.ReadConcern, "Read concern is not nil")
+		assert.Nilf(t, opts.WriteConcern, "Write concern is not nil")
+		assert.Nilf(t, opts.MaxCommitTime, "Max commit time is not nil")
+	})
+
+	t.Run("All settings", func(t *testing.T) {
+
+		o := TxOptions{
+			ReadConcern:   readconcern.Snapshot(),
+			WriteConcern:  writeconcern.Majority(),
+			MaxCommitTime: 2 * time.Second,
+			MaxRetries:    7,
+		}
+		require.NoErrorf(t, o.check(), "Unexpected error")
+		assert.Equalf(t, 7, o.retries(), "Retries are not equal")
+
+		opts := o.transactionOptions()
+		assert.Equalf(t, readconcern.Snapshot(), opts.ReadConcern, "Read concern is not equal")
+		assert.Equalf(t, writeconcern.Majority(), opts.WriteConcern, "Write concern is not equal")
+		require.NotNilf(t, opts.MaxCommitTime, "Max commit time is nil")
+		assert.Equalf(t, 2*time.Second, *opts.MaxCommitTime, "Max commit time is not equal")
+	})
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		require.Equalf(t, ErrValueTxOptions, TxOptions{MaxRetries: -1}.check(), "Error is not equal")
+		require.Equalf(t, ErrValueTxOptions, TxOptions{MaxCommitTime: -1}.check(), "Error is not equal")
+	})
+}
+
+// Test hasErrorLabel
+func TestHasErrorLabel(t *testing.T) {
+
+	transient := mongo.CommandError{Code: 112, Labels: []string{labelTransientTransaction}}
+
+	assert.Truef(t, hasErrorLabel(transient, labelTransientTransaction), "Label is not found")
+	assert.Truef(t, hasErrorLabel(fmt.Errorf("Fault insert document: <%w>", transient), labelTransientTransaction), "Label of wrapped error is not found")
+	assert.Falsef(t, hasErrorLabel(transient, labelUnknownCommitResult), "Unexpected label")
+	assert.Falsef(t, hasErrorLabel(errors.New("error"), labelTransientTransaction), "Unexpected label")
+	assert.Falsef(t, hasErrorLabel(nil, labelTransientTransaction), "Unexpected label")
+}
+
+// Test withoutSession
+func TestWithoutSession(t *testing.T) {
+
+	type key struct{}
+
+	t.Run("Without session", func(t *testing.T) {
+
+		ctx := context.WithValue(context.Background(), key{}, "value")
+		assert.Equalf(t, ctx, withoutSession(ctx), "Context is not equal")
+	})
+
+	t.Run("Sessionless context", func(t *testing.T) {
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		out := withoutSession(sessionlessContext{context.WithValue(ctx, key{}, "value")})
+		require.Truef(t, errors.Is(out.Err(), context.Canceled), "Error is not equal")
+		assert.Equalf(t, "value", out.Value(key{}), "Value is not equal")
+	})
+
+	t.Run("With session", func(t *testing.T) {
+
+		// Server is not needed: client connects lazily, session is started locally
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+		require.NoErrorf(t, err, "Unexpected error")
+		defer client.Disconnect(context.Background())
+
+		sess, err := client.StartSession()
+		require.NoErrorf(t, err, "Unexpected error")
+		defer sess.EndSession(context.Background())
+
+		ctx := mongo.NewSessionContext(WithActor(context.Background(), "alice"), sess)
+		require.NotNilf(t, mongo.SessionFromContext(ctx), "Session is nil")
+
+		out := withoutSession(ctx)
+		assert.Nilf(t, mongo.SessionFromContext(out), "Session is not hidden")
+		assert.Equalf(t, "alice", ActorFromContext(out), "Actor is not equal")
+	})
+}
+
+// Test WithTransaction without function
+func TestWithTransactionNil(t *testing.T) {
+
+	m := &mongoDB{}
+
+	err := m.WithTransaction(context.Background(), nil)
+	require.Equalf(t, ErrEmptyTxFunction, err, "Error is not equal")
+}
@@ -0,0 +1,661 @@
+package mongodb
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"reflect"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Format of export and import.
+type TransferFormat string
+
+// Formats of export and import.
+const (
+	// JSON Lines of canonical Extended JSON, types of values are kept exactly
+	FormatJSONL TransferFormat = "jsonl"
+	// JSON Lines of relaxed Extended JSON, numbers and dates are readable
+	FormatJSONLRelaxed TransferFormat = "jsonl-relaxed"
+	// CSV with header, columns by mapping of fields
+	FormatCSV TransferFormat = "csv"
+)
+
+// Policy of import of document, which exists in collection.
+type DuplicatePolicy string
+
+// Policies of duplicates.
+const (
+	// Stop import with ErrDocumentExists
+	DuplicateFail DuplicatePolicy = "fail"
+	// Keep existing document
+	DuplicateSkip DuplicatePolicy = "skip"
+	// Replace existing document with the same id, otherwise with the same unique key, existing document keeps its id
+	DuplicateOverwrite DuplicatePolicy = "overwrite"
+)
+
+// Default count of documents in one bulk write of import.
+const DefaultImportBatchSize = 500
+
+// Mapping of field of document to column of CSV.
+type FieldMapping struct {
+	// BSON name of field of DocUser
+	Field string
+	// Name of column. Empty - name of field
+	Column string
+}
+
+// Settings of export.
+type ExportOptions struct {
+	// Format of output
+	Format TransferFormat
+	// Query of exported documents, nil - all documents. Softly deleted documents are skipped
+	Query *UserQuery
+	// Columns of CSV in order of output. Empty - all fields of DocUser
+	Fields []FieldMapping
+	// Count of documents in one batch of cursor. 0 - default of server
+	BatchSize int32
+}
+
+// Check of settings. Returns error.
+func (o ExportOptions) Check() error {
+
+	if err := o.Format.check(); err != nil {
+		return err
+	}
+	if o.BatchSize < 0 {
+		return fmt.Errorf("%w: size of batch <%d>", ErrValueTransferOptions, o.BatchSize)
+	}
+	if _, err := fieldMappings(o.Fields); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Settings of import.
+type ImportOptions struct {
+	// Format of input
+	Format TransferFormat
+	// Columns of CSV. Empty - columns are named by fields of DocUser
+	Fields []FieldMapping
+	// Count of documents in one bulk write. 0 - DefaultImportBatchSize
+	BatchSize int
+	// Policy of duplicates. Empty - DuplicateFail
+	OnDuplicate DuplicatePolicy
+}
+
+// Check of settings. Returns error.
+func (o ImportOptions) Check() error {
+
+	if err := o.Format.check(); err != nil {
+		return err
+	}
+	if o.BatchSize < 0 {
+		return fmt.Errorf("%w: size of batch <%d>", ErrValueTransferOptions, o.BatchSize)
+	}
+	switch o.OnDuplicate {
+	case "", DuplicateFail, DuplicateSkip, DuplicateOverwrite:
+	default:
+		return fmt.Errorf("%w: policy of duplicates <%s>", ErrValueTransferOptions, o.OnDuplicate)
+	}
+	if _, err := fieldMappings(o.Fields); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Result of import.
+type ImportResult struct {
+	// Count of read records
+	Read int64
+	// Count of inserted documents
+	Inserted int64
+	// Count of replaced documents, DuplicateOverwrite only
+	Replaced int64
+	// Count of skipped duplicates, DuplicateSkip only
+	Skipped int64
+}
+
+// Record of import.
+type importRecord struct {
+	// Number of line of input
+	Line int
+	// Decoded document
+	Doc DocUser
+}
+
+// Check of format. Returns error.
+func (f TransferFormat) check() error {
+
+	switch f {
+	case FormatJSONL, FormatJSONLRelaxed, FormatCSV:
+		return nil
+	}
+
+	return fmt.Errorf("%w: <%s>", ErrNotCorrectFormat, f)
+}
+
+// Mapping of fields with names of columns. Empty mapping - all fields of DocUser.
+// Returns mapping and error.
+//
+// Params:
+//
+//	fields - mapping of fields
+func fieldMappings(fields []FieldMapping) ([]FieldMapping, error) {
+
+	t := reflect.TypeOf(DocUser{})
+
+	if len(fields) == 0 {
+		for i := 0; i < t.NumField(); i++ {
+			if name := bsonFieldName(t.Field(i)); name != "" {
+				fields = append(fields, FieldMapping{Field: name, Column: name})
+			}
+		}
+		return fields, nil
+	}
+
+	mapping := make([]FieldMapping, 0, len(fields))
+	columns := map[string]bool{}
+
+	for _, f := range fields {
+
+		if _, ok := bsonFieldIndex(t, f.Field); f.Field == "" || !ok {
+			return nil, fmt.Errorf("%w: field <%s>", ErrValueTransferOptions, f.Field)
+		}
+		if f.Column == "" {
+			f.Column = f.Field
+		}
+		if columns[f.Column] {
+			return nil, fmt.Errorf("%w: duplicate column <%s>", ErrValueTransferOptions, f.Column)
+		}
+		columns[f.Column] = true
+
+		mapping = append(mapping, f)
+	}
+
+	return mapping, nil
+}
+
+// Export documents user of collection to writer. Output is streamed by cursor.
+// Returns count of exported documents and error.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	w - writer of output
+//	opts - settings of export
+func Export(ctx context.Context, db MongoDBI, collectionName string, w io.Writer, opts ExportOptions) (int64, error) {
+
+	// Check
+	if db == nil {
+		return 0, ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return 0, ErrEmptyCollectionsName
+	}
+	if w == nil {
+		return 0, fmt.Errorf("%w: writer is nil", ErrValueTransferOptions)
+	}
+	if err := opts.Check(); err != nil {
+		return 0, err
+	}
+
+	// Logic
+	fields, _ := fieldMappings(opts.Fields)
+
+	var n int64
+
+	bw := bufio.NewWriter(w)
+	write := jsonlWriter(bw, opts.Format == FormatJSONL)
+
+	var cw *csv.Writer
+	if opts.Format == FormatCSV {
+		cw = csv.NewWriter(bw)
+		header := make([]string, 0, len(fields))
+		for _, f := range fields {
+			header = append(header, f.Column)
+		}
+		if err := cw.Write(header); err != nil {
+			return 0, fmt.Errorf("Function Write return error: <%w>", err)
+		}
+		write = csvWriter(cw, fields)
+	}
+
+	for doc, err := range db.IterDocumentsUser(ctx, collectionName, opts.Query, IterOptions{BatchSize: opts.BatchSize}) {
+
+		if err != nil {
+			return n, err
+		}
+		if err := write(doc); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	if cw != nil {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return n, fmt.Errorf("Function Flush return error: <%w>", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("Function Flush return error: <%w>", err)
+	}
+
+	return n, nil
+}
+
+// Writer of documents as lines of Extended JSON. Returns function of writing of document.
+//
+// Params:
+//
+//	w - writer of output
+//	canonical - canonical Extended JSON, false - relaxed
+func jsonlWriter(w *bufio.Writer, canonical bool) func(doc DocUser) error {
+
+	return func(doc DocUser) error {
+
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("Function Marshal, returned error: <%w>", err)
+		}
+		line, err := bson.MarshalExtJSON(bson.Raw(raw), canonical, false)
+		if err != nil {
+			return fmt.Errorf("Function MarshalExtJSON, returned error: <%w>", err)
+		}
+
+		line = append(line, '\n')
+		if _, err := w.Write(line); err != nil {
+			return fmt.Errorf("Function Write return error: <%w>", err)
+		}
+
+		return nil
+	}
+}
+
+// Writer of documents as rows of CSV. Returns function of writing of document.
+//
+// Params:
+//
+//	w - writer of CSV
+//	fields - mapping of columns
+func csvWriter(w *csv.Writer, fields []FieldMapping) func(doc DocUser) error {
+
+	return func(doc DocUser) error {
+
+		raw, err := bson.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("Function Marshal, returned error: <%w>", err)
+		}
+
+		row := make([]string, 0, len(fields))
+		for _, f := range fields {
+			row = append(row, csvValue(bson.Raw(raw).Lookup(f.Field)))
+		}
+
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("Function Write return error: <%w>", err)
+		}
+
+		return nil
+	}
+}
+
+// Text of value for CSV: id as hex, date as RFC 3339 in UTC, missing and null value as empty text,
+// other values as relaxed Extended JSON. Returns text.
+//
+// Params:
+//
+//	v - value
+func csvValue(v bson.RawValue) string {
+
+	switch v.Type {
+	case 0, bsontype.Null, bsontype.Undefined:
+		return ""
+	case bsontype.String:
+		return v.StringValue()
+	case bsontype.Int32:
+		return strconv.FormatInt(int64(v.Int32()), 10)
+	case bsontype.Int64:
+		return strconv.FormatInt(v.Int64(), 10)
+	case bsontype.Double:
+		return strconv.FormatFloat(v.Double(), 'g', -1, 64)
+	case bsontype.Boolean:
+		return strconv.FormatBool(v.Boolean())
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex()
+	case bsontype.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano)
+	}
+
+	return v.String()
+}
+
+// Import documents user from reader to collection by bulk writes of batches.
+// Fields, maintained by adapter, are kept from record, missing ones are set as on insert. Id of record is
+// kept for inserted document. Import stops on the first error. Documents of previous bulk writes stay in
+// collection, as well as the written documents of the failed batch, because batch is written unordered.
+// Returns result and error.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	r - reader of input
+//	opts - settings of import
+func Import(ctx context.Context, db MongoDBI, collectionName string, r io.Reader, opts ImportOptions) (ImportResult, error) {
+
+	// Check
+	if db == nil {
+		return ImportResult{}, ErrNilPtrDB
+	}
+	if collectionName == "" {
+		return ImportResult{}, ErrEmptyCollectionsName
+	}
+	if r == nil {
+		return ImportResult{}, fmt.Errorf("%w: reader is nil", ErrValueTransferOptions)
+	}
+	if err := opts.Check(); err != nil {
+		return ImportResult{}, err
+	}
+
+	// Logic
+	size := opts.BatchSize
+	if size == 0 {
+		size = DefaultImportBatchSize
+	}
+
+	records := jsonlRecords(r, opts.Format == FormatJSONL)
+	if opts.Format == FormatCSV {
+		fields, _ := fieldMappings(opts.Fields)
+		records = csvRecords(r, fields)
+	}
+
+	result := ImportResult{}
+	batch := make([]importRecord, 0, size)
+
+	for rec, err := range records {
+
+		if err != nil {
+			return result, err
+		}
+		result.Read++
+
+		batch = append(batch, rec)
+		if len(batch) < size {
+			continue
+		}
+
+		if err := importBatch(ctx, db, collectionName, batch, opts.OnDuplicate, &result); err != nil {
+			return result, err
+		}
+		batch = batch[:0]
+	}
+
+	if len(batch) > 0 {
+		if err := importBatch(ctx, db, collectionName, batch, opts.OnDuplicate, &result); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// Insert batch of records by one unordered bulk write and resolve duplicates by policy. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	batch - records
+//	policy - policy of duplicates
+//	result - result of import
+func importBatch(ctx context.Context, db MongoDBI, collectionName string, batch []importRecord, policy DuplicatePolicy, result *ImportResult) error {
+
+	docs := make([]DocUser, 0, len(batch))
+	for _, rec := range batch {
+		docs = append(docs, rec.Doc)
+	}
+
+	res, err := db.InsertImportedDocumentsUser(ctx, collectionName, docs, BulkOptions{})
+	if err != nil && !errors.Is(err, ErrBulkWrite) {
+		return err
+	}
+	result.Inserted += res.Inserted
+
+	for _, item := range res.Failed() {
+
+		rec := batch[item.Index]
+
+		if !errors.Is(item.Err, ErrDocumentExists) || policy == "" || policy == DuplicateFail {
+			return fmt.Errorf("Record of line <%d> returned error: <%w>", rec.Line, item.Err)
+		}
+
+		if policy == DuplicateSkip {
+			result.Skipped++
+			continue
+		}
+
+		if err := overwriteRecord(ctx, db, collectionName, rec.Doc); err != nil {
+			return fmt.Errorf("Record of line <%d> returned error: <%w>", rec.Line, err)
+		}
+		result.Replaced++
+	}
+
+	return nil
+}
+
+// Replace existing document by record: by id of record, otherwise by unique key of users (name, age, email).
+// Fields, missing in record, are removed. Id of existing document is kept. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	db - connection
+//	collectionName - name of collection
+//	doc - document of record
+func overwriteRecord(ctx context.Context, db MongoDBI, collectionName string, doc DocUser) error {
+
+	id := doc.ID
+	doc.ID = primitive.NilObjectID
+
+	if !id.IsZero() {
+		err := db.ReplaceImportedDocumentUser(ctx, collectionName, id, doc)
+		if !errors.Is(err, ErrUpdateDocument) {
+			return err
+		}
+		// Id is new, so duplicate is by unique key
+	}
+
+	key := NormalizeDocUser(doc)
+
+	q := NewUserQuery().Name(key.Name).AgeBetween(key.Age, key.Age)
+	if key.Email != "" {
+		q = q.Email(key.Email)
+	}
+	found, _, err := db.FindDocumentsUser(ctx, collectionName, q)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range found {
+		if f.Email == key.Email {
+			return db.ReplaceImportedDocumentUser(ctx, collectionName, f.ID, doc)
+		}
+	}
+
+	return ErrUpdateDocument
+}
+
+// Records of lines of Extended JSON. Empty lines are skipped. Returns sequence of records, error ends sequence.
+//
+// Params:
+//
+//	r - reader of input
+//	canonical - canonical Extended JSON, false - relaxed
+func jsonlRecords(r io.Reader, canonical bool) iter.Seq2[importRecord, error] {
+
+	return func(yield func(importRecord, error) bool) {
+
+		br := bufio.NewReader(r)
+
+		for line := 1; ; line++ {
+
+			data, err := br.ReadBytes('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				yield(importRecord{}, fmt.Errorf("Function ReadBytes return error: <%w>", err))
+				return
+			}
+
+			if data = bytes.TrimSpace(data); len(data) > 0 {
+
+				var doc DocUser
+				if err := bson.UnmarshalExtJSON(data, canonical, &doc); err != nil {
+					yield(importRecord{}, fmt.Errorf("%w: line <%d>: %v", ErrNotCorrectRecord, line, err))
+					return
+				}
+				if !yield(importRecord{Line: line, Doc: doc}, nil) {
+					return
+				}
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Records of rows of CSV with header. Columns of header must be mapped. Empty values are skipped.
+// Returns sequence of records, error ends sequence.
+//
+// Params:
+//
+//	r - reader of input
+//	fields - mapping of columns
+func csvRecords(r io.Reader, fields []FieldMapping) iter.Seq2[importRecord, error] {
+
+	return func(yield func(importRecord, error) bool) {
+
+		cr := csv.NewReader(r)
+		cr.ReuseRecord = true
+
+		header, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			yield(importRecord{}, fmt.Errorf("%w: %v", ErrNotCorrectRecord, err))
+			return
+		}
+
+		byColumn := map[string]string{}
+		for _, f := range fields {
+			byColumn[f.Column] = f.Field
+		}
+
+		columns := make([]string, 0, len(header))
+		for _, column := range header {
+			field, ok := byColumn[column]
+			if !ok {
+				yield(importRecord{}, fmt.Errorf("%w: line <1>: column <%s> is not mapped", ErrNotCorrectRecord, column))
+				return
+			}
+			columns = append(columns, field)
+		}
+
+		for {
+
+			row, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				yield(importRecord{}, fmt.Errorf("%w: %v", ErrNotCorrectRecord, err))
+				return
+			}
+			line, _ := cr.FieldPos(0)
+
+			var doc DocUser
+			for i, value := range row {
+				if err := setField(&doc, columns[i], value); err != nil {
+					yield(importRecord{}, fmt.Errorf("%w: line <%d>: column <%s>: %v", ErrNotCorrectRecord, line, header[i], err))
+					return
+				}
+			}
+
+			if !yield(importRecord{Line: line, Doc: doc}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Set field of document by text. Empty text keeps zero value. Returns error.
+//
+// Params:
+//
+//	doc - document
+//	field - BSON name of field
+//	value - text of value
+func setField(doc *DocUser, field, value string) error {
+
+	if value == "" {
+		return nil
+	}
+
+	v := reflect.ValueOf(doc).Elem()
+	i, _ := bsonFieldIndex(v.Type(), field)
+	f := v.Field(i)
+
+	switch f.Interface().(type) {
+	case primitive.ObjectID:
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(id))
+		return nil
+	case time.Time:
+		t, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(t.UTC()))
+		return nil
+	}
+
+	switch f.Kind() {
+	case reflect.String:
+		f.SetString(value)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if f.OverflowInt(n) {
+			return fmt.Errorf("value <%d> overflows field", n)
+		}
+		f.SetInt(n)
+	default:
+		return fmt.Errorf("type <%s> of field is not supported", f.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test settings of export and import.
+func TestTransferOptions(t *testing.T) {
+
+	t.Run("Wrong format", func(t *testing.T) {
+
+		require.Truef(t, errors.Is(ExportOptions{}.Check(), ErrNotCorrectFormat), "Error is not equal")
+		require.Truef(t, errors.Is(ImportOptions{Format: "xml"}.Check(), ErrNotCorrectFormat), "Error is not equal")
+	})
+
+	t.Run("Wrong settings", func(t *testing.T) {
+
+		require.Truef(t, errors.Is(ExportOptions{Format: FormatCSV, BatchSize: -1}.Check(), ErrValueTransferOptions), "Error is not equal")
+		require.Truef(t, errors.Is(ImportOptions{Format: FormatCSV, BatchSize: -1}.Check(), ErrValueTransferOptions), "Error is not equal")
+		require.Truef(t, errors.Is(ImportOptions{Format: FormatJSONL, OnDuplicate: "merge"}.Check(), ErrValueTransferOptions), "Error is not equal")
+	})
+
+	t.Run("Wrong mapping", func(t *testing.T) {
+
+		for _, fields := range [][]FieldMapping{
+			{{Field: ""}},
+			{{Field: "phone"}},
+			{{Field: "name", Column: "user"}, {Field: "email", Column: "user"}},
+			{{Field: "name"}, {Field: "name"}},
+		} {
+			require.Truef(t, errors.Is(ExportOptions{Format: FormatCSV, Fields: fields}.Check(), ErrValueTransferOptions), "Error is not equal")
+		}
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		require.NoErrorf(t, ExportOptions{Format: FormatJSONLRelaxed, BatchSize: 100}.Check(), "Unexpected error")
+		require.NoErrorf(t, ImportOptions{Format: FormatCSV, Fields: []FieldMapping{{Field: "name", Column: "Name"}}, OnDuplicate: DuplicateSkip}.Check(), "Unexpected error")
+	})
+
+	t.Run("Default mapping", func(t *testing.T) {
+
+		fields, err := fieldMappings(nil)
+		require.NoErrorf(t, err, "Unexpected error")
+		require.NotEmptyf(t, fields, "Mapping is empty")
+		assert.Equalf(t, FieldMapping{Field: "_id", Column: "_id"}, fields[0], "Mapping is not equal")
+		assert.Equalf(t, FieldMapping{Field: "name", Column: "name"}, fields[1], "Mapping is not equal")
+
+		fields, err = fieldMappings([]FieldMapping{{Field: "age"}})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, []FieldMapping{{Field: "age", Column: "age"}}, fields, "Mapping is not equal")
+	})
+}
+
+// Test text of values of CSV.
+func TestCSVValue(t *testing.T) {
+
+	id := primitive.NewObjectID()
+	at := time.Date(2024, 5, 6, 7, 8, 9, 10e6, time.UTC)
+
+	raw, err := bson.Marshal(bson.D{
+		{Key: "id", Value: id},
+		{Key: "name", Value: "Anna"},
+		{Key: "age", Value: int32(25)},
+		{Key: "count", Value: int64(7)},
+		{Key: "rate", Value: 0.5},
+		{Key: "ok", Value: true},
+		{Key: "at", Value: at},
+		{Key: "none", Value: nil},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+	})
+	require.NoErrorf(t, err, "Unexpected error")
+
+	tests := map[string]string{
+		"id":      id.Hex(),
+		"name":    "Anna",
+		"age":     "25",
+		"count":   "7",
+		"rate":    "0.5",
+		"ok":      "true",
+		"at":      "2024-05-06T07:08:09.01Z",
+		"none":    "",
+		"missing": "",
+		"tags":    `["a","b"]`,
+	}
+	for key, want := range tests {
+		assert.Equalf(t, want, csvValue(bson.Raw(raw).Lookup(key)), "Value of <%s> is not equal", key)
+	}
+}
+
+// Test decoding of records.
+func TestImportRecords(t *testing.T) {
+
+	id := primitive.NewObjectID()
+
+	t.Run("JSON Lines", func(t *testing.T) {
+
+		input := `{"_id":{"$oid":"` + id.Hex() + `"},"name":"Anna","age":{"$numberInt":"25"}}` + "\n\n" +
+			`{"name":"Boris","age":33,"email":"boris@mail.ru","extra":true}`
+
+		var recs []importRecord
+		for rec, err := range jsonlRecords(strings.NewReader(input), false) {
+			require.NoErrorf(t, err, "Unexpected error")
+			recs = append(recs, rec)
+		}
+
+		want := []importRecord{
+			{Line: 1, Doc: DocUser{ID: id, Name: "Anna", Age: 25}},
+			{Line: 3, Doc: DocUser{Name: "Boris", Age: 33, Email: "boris@mail.ru"}},
+		}
+		assert.Equalf(t, want, recs, "Records is not equal")
+	})
+
+	t.Run("Not correct JSON", func(t *testing.T) {
+
+		input := `{"name":"Anna","age":25}` + "\n" + `{"name":`
+
+		var err error
+		for _, err = range jsonlRecords(strings.NewReader(input), false) {
+		}
+		require.Truef(t, errors.Is(err, ErrNotCorrectRecord), "Error is not equal")
+		assert.Containsf(t, err.Error(), "line <2>", "Line is not reported")
+	})
+
+	t.Run("CSV", func(t *testing.T) {
+
+		input := "id,Name,age,createdAt\n" +
+			id.Hex() + ",Anna,25,2024-05-06T07:08:09Z\n" +
+			",\"Boris, Jr\",33,\n"
+
+		fields, err := fieldMappings([]FieldMapping{{Field: "_id", Column: "id"}, {Field: "name", Column: "Name"}, {Field: "age"}, {Field: "createdAt"}})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		var recs []importRecord
+		for rec, err := range csvRecords(strings.NewReader(input), fields) {
+			require.NoErrorf(t, err, "Unexpected error")
+			recs = append(recs, rec)
+		}
+
+		want := []importRecord{
+			{Line: 2, Doc: DocUser{ID: id, Name: "Anna", Age: 25, CreatedAt: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)}},
+			{Line: 3, Doc: DocUser{Name: "Boris, Jr", Age: 33}},
+		}
+		assert.Equalf(t, want, recs, "Records is not equal")
+	})
+
+	t.Run("Not correct CSV", func(t *testing.T) {
+
+		fields, _ := fieldMappings(nil)
+
+		for _, input := range []string{
+			"name,phone\nAnna,123\n",
+			"name,age\nAnna,old\n",
+			"name,age\nAnna,25,extra\n",
+			"_id,name\n123,Anna\n",
+		} {
+			var err error
+			for _, err = range csvRecords(strings.NewReader(input), fields) {
+			}
+			require.Truef(t, errors.Is(err, ErrNotCorrectRecord), "Error is not equal for <%s>", input)
+		}
+	})
+
+	t.Run("Empty input", func(t *testing.T) {
+
+		count := 0
+		for range csvRecords(strings.NewReader(""), nil) {
+			count++
+		}
+		for range jsonlRecords(strings.NewReader(""), true) {
+			count++
+		}
+		assert.Equalf(t, 0, count, "Count is not equal")
+	})
+}
+
+// Test export and import without connection.
+func TestTransferChecks(t *testing.T) {
+
+	ctx := context.Background()
+	m := &mongoDB{}
+	buf := &bytes.Buffer{}
+
+	_, err := Export(ctx, nil, "users", buf, ExportOptions{Format: FormatJSONL})
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	_, err = Export(ctx, m, "", buf, ExportOptions{Format: FormatJSONL})
+	require.Equalf(t, ErrEmptyCollectionsName, err, "Error is not equal")
+
+	_, err = Export(ctx, m, "users", nil, ExportOptions{Format: FormatJSONL})
+	require.Truef(t, errors.Is(err, ErrValueTransferOptions), "Error is not equal")
+
+	_, err = Export(ctx, m, "users", buf, ExportOptions{Format: FormatJSONL})
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	_, err = Import(ctx, nil, "users", buf, ImportOptions{Format: FormatJSONL})
+	require.Equalf(t, ErrNilPtrDB, err, "Error is not equal")
+
+	_, err = Import(ctx, m, "", buf, ImportOptions{Format: FormatJSONL})
+	require.Equalf(t, ErrEmptyCollectionsName, err, "Error is not equal")
+
+	_, err = Import(ctx, m, "users", nil, ImportOptions{Format: FormatJSONL})
+	require.Truef(t, errors.Is(err, ErrValueTransferOptions), "Error is not equal")
+
+	_, err = Import(ctx, m, "users", buf, ImportOptions{Format: "xml"})
+	require.Truef(t, errors.Is(err, ErrNotCorrectFormat), "Error is not equal")
+}
@@ -1,7 +1,90 @@
 package mongodb
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type DocUser struct {
-	Name  string `bson:"name,omitempty"`
-	Age   int    `bson:"age,omitempty"`
-	Email string `bson:"email,omitempty"`
+	// Id of document, generated on insert, if it is zero
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Name  string             `bson:"name,omitempty" validate:"required,max=100,pattern=^\\p{L}[\\p{L} .'-]*$"`
+	Age   int                `bson:"age,omitempty" validate:"required,min=1,max=150"`
+	Email string             `bson:"email,omitempty" validate:"email,max=254"`
+	// Version of document, maintained by adapter with WithVersioning
+	Version int64 `bson:"version,omitempty"`
+	// Fields of audit, maintained by adapter. Actor is taken from context by ActorFromContext
+	CreatedAt time.Time `bson:"createdAt,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt,omitempty"`
+	CreatedBy string    `bson:"createdBy,omitempty"`
+	UpdatedBy string    `bson:"updatedBy,omitempty"`
+	// Time of soft delete, maintained by adapter with WithSoftDelete
+	DeletedAt time.Time `bson:"deletedAt,omitempty"`
+}
+
+// Settings of repository of users.
+var userRepositoryConfig = RepositoryConfig[DocUser]{
+	KeyField:       "name",
+	UniqueFields:   []string{"name", "age", "email"},
+	Normalize:      NormalizeDocUser,
+	Validate:       ValidateDocUser,
+	ValidateUpdate: ValidateDocUserUpdate,
+	PreparePatch:   PrepareDocUserPatch,
+	Audit:          userAuditFields,
+}
+
+// Fields of audit of users.
+var userAuditFields = AuditFields{
+	CreatedAt: "createdAt",
+	UpdatedAt: "updatedAt",
+	CreatedBy: "createdBy",
+	UpdatedBy: "updatedBy",
+}
+
+// Repository of users.
+func (m *mongoDB) users() *Repository[DocUser] {
+
+	cfg := userRepositoryConfig
+	if m.versioning {
+		cfg.VersionField = "version"
+	}
+	if m.softDelete {
+		cfg.DeletedField = "deletedAt"
+	}
+	if m.outbox != "" {
+		cfg.Outbox = OutboxConfig{Collection: m.outbox, Created: EventUserCreated, Moved: EventUserMoved}
+	}
+
+	return &Repository[DocUser]{m: m, cfg: cfg}
+}
+
+// Filter of document user by id. Returns filter and error.
+//
+// Params:
+//
+//	id - id of document
+func userIDFilter(id primitive.ObjectID) (bson.M, error) {
+
+	if id.IsZero() {
+		return nil, ErrEmptyID
+	}
+
+	return bson.M{"_id": id}, nil
+}
+
+// Filter of document user by email. Email is normalized as on write. Returns filter and error.
+//
+// Params:
+//
+//	email - email
+func userEmailFilter(email string) (bson.M, error) {
+
+	email = NormalizeDocUser(DocUser{Email: email}).Email
+	if email == "" {
+		return nil, ErrEmptyEmail
+	}
+
+	return bson.M{"email": email}, nil
 }
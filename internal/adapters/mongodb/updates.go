@@ -0,0 +1,215 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Prepare document for insert: set the first version and fields of audit, clear time of delete. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) insertDoc(ctx context.Context, doc T) T {
+
+	return r.stampCreated(ctx, r.firstVersion(r.notDeleted(doc)))
+}
+
+// Prepare imported document for insert or replace: maintained fields are kept, missing ones are set
+// as on insert. Returns document.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) importDoc(ctx context.Context, doc T) T {
+
+	stamped := reflect.ValueOf(r.insertDoc(ctx, doc))
+
+	v := reflect.ValueOf(&doc).Elem()
+	for _, name := range []string{r.cfg.VersionField, r.cfg.Audit.CreatedAt, r.cfg.Audit.UpdatedAt, r.cfg.Audit.CreatedBy, r.cfg.Audit.UpdatedBy} {
+		if name == "" {
+			continue
+		}
+		if i, ok := bsonFieldIndex(v.Type(), name); ok && v.Field(i).IsZero() {
+			v.Field(i).Set(stamped.Field(i))
+		}
+	}
+
+	return doc
+}
+
+// Document of update by $set. Maintained fields are set by repository. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) updateDoc(ctx context.Context, doc T) (bson.D, error) {
+
+	d, err := marshalDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	set := withoutKeys(d, r.managedFields()...)
+
+	update := bson.D{}
+	update = mergeOp(update, "$set", set)
+	update = r.stampUpdated(ctx, update)
+
+	if r.cfg.VersionField != "" {
+		update = mergeOp(update, "$inc", r.incVersion().Value.(bson.D))
+	}
+
+	return update, nil
+}
+
+// Document of update, which replaces fields of document. Omitted fields of struct are removed,
+// maintained fields are set by repository. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	doc - document
+func (r *Repository[T]) replaceDoc(ctx context.Context, doc T) (bson.D, error) {
+
+	d, err := marshalDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	managed := r.managedFields()
+	set := withoutKeys(d, managed...)
+
+	unset := bson.D{}
+	t := reflect.TypeOf(doc)
+	for i := 0; i < t.NumField(); i++ {
+
+		name := bsonFieldName(t.Field(i))
+		if name == "" || IsExistsCollection(managed, name) || strings.Contains(t.Field(i).Tag.Get("bson"), "inline") {
+			continue
+		}
+		if lookup(set, name) == nil {
+			unset = append(unset, bson.E{Key: name, Value: ""})
+		}
+	}
+
+	update := bson.D{{Key: "$set", Value: set}}
+	update = mergeOp(update, "$unset", unset)
+	update = r.stampUpdated(ctx, update)
+	update = mergeOp(update, "$setOnInsert", r.createdFields(ctx))
+
+	if r.cfg.VersionField != "" {
+		update = mergeOp(update, "$inc", r.incVersion().Value.(bson.D))
+	}
+
+	return update, nil
+}
+
+// Document of update by patch. Maintained fields are set by repository. Returns document and error.
+//
+// Params:
+//
+//	ctx - context
+//	spec - specification of patch
+func (r *Repository[T]) patchDoc(ctx context.Context, spec PatchSpec) (bson.D, error) {
+
+	managed := r.managedFields()
+	for _, f := range spec.Fields {
+
+		to, _ := f.Value.(string)
+		if IsExistsCollection(managed, f.Field) || (f.Op == PatchRename && IsExistsCollection(managed, to)) {
+			return nil, fmt.Errorf("%w: field <%s> is maintained by repository", ErrNotCorrectPatch, f.Field)
+		}
+	}
+
+	update := r.stampUpdated(ctx, spec.Update())
+
+	if r.cfg.VersionField != "" {
+		update = mergeOp(update, "$inc", r.incVersion().Value.(bson.D))
+	}
+
+	return update, nil
+}
+
+// Names of fields, maintained by repository, and _id. Returns names.
+func (r *Repository[T]) managedFields() []string {
+
+	names := []string{"_id"}
+	for _, f := range []string{r.cfg.VersionField, r.cfg.Audit.CreatedAt, r.cfg.Audit.UpdatedAt, r.cfg.Audit.CreatedBy, r.cfg.Audit.UpdatedBy, r.cfg.DeletedField} {
+		if f != "" {
+			names = append(names, f)
+		}
+	}
+
+	return names
+}
+
+// Add fields to operator of update. Returns document of update.
+//
+// Params:
+//
+//	update - document of update
+//	op - operator, e.g. $set
+//	fields - fields of operator
+func mergeOp(update bson.D, op string, fields bson.D) bson.D {
+
+	if len(fields) == 0 {
+		return update
+	}
+
+	for i, e := range update {
+		if e.Key == op {
+			update[i].Value = append(e.Value.(bson.D), fields...)
+			return update
+		}
+	}
+
+	return append(update, bson.E{Key: op, Value: fields})
+}
+
+// Document as BSON. Returns document and error.
+//
+// Params:
+//
+//	doc - document
+func marshalDoc(doc interface{}) (bson.D, error) {
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("Function Marshal, returned error: <%w>", err)
+	}
+
+	var d bson.D
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return nil, fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+	}
+
+	return d, nil
+}
+
+// Document without keys. Returns document.
+//
+// Params:
+//
+//	d - document
+//	keys - removed keys
+func withoutKeys(d bson.D, keys ...string) bson.D {
+
+	out := bson.D{}
+	for _, e := range d {
+		skip := false
+		for _, k := range keys {
+			skip = skip || e.Key == k
+		}
+		if !skip {
+			out = append(out, e)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,286 @@
+package mongodb
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Compiled patterns of rules.
+var patterns sync.Map
+
+// Compiled pattern of email.
+var emailRegexp = regexp.MustCompile(emailPattern)
+
+// Errors of fields of document user.
+var docUserFieldErrors = map[string]error{
+	"name":  ErrValueName,
+	"age":   ErrValueAge,
+	"email": ErrValueEmail,
+}
+
+// Error of field.
+type FieldError struct {
+	// BSON name of field
+	Field string
+	// Description of the problem
+	Detail string
+	// Error of field, e.g. ErrValueAge
+	Err error
+}
+
+// Text of error.
+func (e FieldError) Error() string {
+
+	return e.Field + ": " + e.Detail
+}
+
+// Error of validation with all failing fields.
+type ValidationError struct {
+	// Failing fields in order of declaration
+	Fields []FieldError
+}
+
+// Text of error.
+func (e *ValidationError) Error() string {
+
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Error())
+	}
+
+	return ErrValidation.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Wrapped errors: ErrValidation and errors of fields.
+func (e *ValidationError) Unwrap() []error {
+
+	errs := []error{ErrValidation}
+	for _, f := range e.Fields {
+		errs = append(errs, f.Err)
+	}
+
+	return errs
+}
+
+// Check struct by tags validate. Returns errors of fields.
+//
+// Params:
+//
+//	v - struct
+//	partial - skip fields with zero value
+func validateStruct(v interface{}, partial bool) []FieldError {
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs []FieldError
+
+	for i := 0; i < rv.NumField(); i++ {
+
+		f := rv.Type().Field(i)
+		name := bsonFieldName(f)
+		tag := f.Tag.Get("validate")
+		if name == "" || tag == "" {
+			continue
+		}
+
+		rules, err := parseRules(tag)
+		if err != nil {
+			errs = append(errs, FieldError{Field: name, Detail: err.Error(), Err: ErrNotCorrectRule})
+			continue
+		}
+
+		value := rv.Field(i)
+		if partial && value.IsZero() {
+			continue
+		}
+		for value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				break
+			}
+			value = value.Elem()
+		}
+
+		if detail := checkRules(value, rules); detail != "" {
+			errs = append(errs, FieldError{Field: name, Detail: detail, Err: ErrValueField})
+		}
+	}
+
+	return errs
+}
+
+// Check value by rules. Returns description of the problem or "".
+//
+// Params:
+//
+//	value - value of field
+//	rules - rules of field
+func checkRules(value reflect.Value, rules fieldRules) string {
+
+	if value.Kind() == reflect.Pointer && value.IsNil() {
+		if rules.required {
+			return "is required"
+		}
+		return ""
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		s := value.String()
+		if s == "" {
+			if rules.required {
+				return "is required"
+			}
+			return ""
+		}
+		n := float64(utf8.RuneCountInString(s))
+		if rules.min != nil && n < *rules.min {
+			return fmt.Sprintf("length must be at least %v", *rules.min)
+		}
+		if rules.max != nil && n > *rules.max {
+			return fmt.Sprintf("length must be at most %v", *rules.max)
+		}
+		if rules.email && !emailRegexp.MatchString(s) {
+			return "is not correct email"
+		}
+		if rules.pattern != "" {
+			// Pattern is checked by parseRules
+			if re, err := compiledPattern(rules.pattern); err != nil || !re.MatchString(s) {
+				return "does not match pattern " + rules.pattern
+			}
+		}
+		if len(rules.enum) > 0 && !IsExistsCollection(rules.enum, s) {
+			return "must be one of " + strings.Join(rules.enum, ", ")
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if rules.required && value.IsZero() && (rules.min == nil || *rules.min > 0) {
+			return "is required"
+		}
+		var n float64
+		switch {
+		case value.CanInt():
+			n = float64(value.Int())
+		case value.CanUint():
+			n = float64(value.Uint())
+		default:
+			n = value.Float()
+		}
+		if rules.min != nil && n < *rules.min {
+			return fmt.Sprintf("must be at least %v", *rules.min)
+		}
+		if rules.max != nil && n > *rules.max {
+			return fmt.Sprintf("must be at most %v", *rules.max)
+		}
+
+	default:
+		if rules.required && value.IsZero() {
+			return "is required"
+		}
+	}
+
+	return ""
+}
+
+// Compiled regular expression. Returns expression and error of compilation.
+//
+// Params:
+//
+//	pattern - regular expression
+func compiledPattern(pattern string) (*regexp.Regexp, error) {
+
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+
+	return re, nil
+}
+
+// Normalize document user: trim spaces, lowercase email.
+// Whole email is lowercased by decision of product: one address has one stored form, although RFC 5321
+// allows case-sensitive local part. Returns document.
+//
+// Params:
+//
+//	doc - document
+func NormalizeDocUser(doc DocUser) DocUser {
+
+	doc.Name = strings.TrimSpace(doc.Name)
+	doc.Email = strings.ToLower(strings.TrimSpace(doc.Email))
+
+	return doc
+}
+
+// Check normalized document user for insert. Returns ErrEmptyDocument, *ValidationError or nil.
+//
+// Params:
+//
+//	doc - document
+func ValidateDocUser(doc DocUser) error {
+
+	if doc == (DocUser{}) {
+		return ErrEmptyDocument
+	}
+
+	return docUserValidationError(validateStruct(doc, false))
+}
+
+// Check normalized document user for update. Empty name and email are not changed, age is always set.
+// Returns ErrEmptyDocument, *ValidationError or nil.
+//
+// Params:
+//
+//	doc - document
+func ValidateDocUserUpdate(doc DocUser) error {
+
+	if doc.Age <= 0 && doc.Email == "" && doc.Name == "" {
+		return ErrEmptyDocument
+	}
+
+	errs := validateStruct(doc, true)
+	if doc.Age <= 0 {
+		errs = append(errs, FieldError{Field: "age", Detail: "is required"})
+	}
+
+	return docUserValidationError(errs)
+}
+
+// Error of validation of document user. Returns *ValidationError or nil.
+//
+// Params:
+//
+//	errs - errors of fields
+func docUserValidationError(errs []FieldError) error {
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	for i := range errs {
+		if err, ok := docUserFieldErrors[errs[i].Field]; ok {
+			errs[i].Err = err
+		}
+	}
+
+	return &ValidationError{Fields: errs}
+}
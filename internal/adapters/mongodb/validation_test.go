@@ -0,0 +1,130 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Test NormalizeDocUser
+func TestNormalizeDocUser(t *testing.T) {
+
+	doc := NormalizeDocUser(DocUser{Name: "  Anna ", Age: 25, Email: " Anna.B@Mail.COM\t"})
+	assert.Equalf(t, DocUser{Name: "Anna", Age: 25, Email: "anna.b@mail.com"}, doc, "Document is not equal")
+
+	doc = NormalizeDocUser(DocUser{Email: " Not-Email "})
+	assert.Equalf(t, "not-email", doc.Email, "Email is not equal")
+}
+
+// Test ValidateDocUser
+func TestValidateDocUser(t *testing.T) {
+
+	t.Run("Empty document", func(t *testing.T) {
+
+		err := ValidateDocUser(DocUser{})
+		require.Equalf(t, ErrEmptyDocument, err, "Error is not equal")
+	})
+
+	t.Run("Correct", func(t *testing.T) {
+
+		err := ValidateDocUser(DocUser{Name: "Anna-Maria O'Neil", Age: 25, Email: "anna@mail.com"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		err = ValidateDocUser(DocUser{Name: "Анна", Age: 150})
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("All fields", func(t *testing.T) {
+
+		err := ValidateDocUser(DocUser{Name: "4nna", Age: 151, Email: "anna@"})
+
+		var verr *ValidationError
+		require.Truef(t, errors.As(err, &verr), "Error is not ValidationError")
+		require.Lenf(t, verr.Fields, 3, "Count of fields is not equal")
+
+		assert.Equalf(t, "name", verr.Fields[0].Field, "Field is not equal")
+		assert.Equalf(t, "age", verr.Fields[1].Field, "Field is not equal")
+		assert.Equalf(t, "email", verr.Fields[2].Field, "Field is not equal")
+
+		assert.Truef(t, errors.Is(err, ErrValidation), "Error is not equal")
+		assert.Truef(t, errors.Is(err, ErrValueName), "Error is not equal")
+		assert.Truef(t, errors.Is(err, ErrValueAge), "Error is not equal")
+		assert.Truef(t, errors.Is(err, ErrValueEmail), "Error is not equal")
+		assert.Falsef(t, errors.Is(err, ErrSchemaValidation), "Error is not equal")
+	})
+
+	t.Run("Missing name", func(t *testing.T) {
+
+		err := ValidateDocUser(DocUser{Age: 30})
+		require.Truef(t, errors.Is(err, ErrValueName), "Error is not equal")
+		require.Falsef(t, errors.Is(err, ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Long name", func(t *testing.T) {
+
+		name := make([]rune, 101)
+		for i := range name {
+			name[i] = 'a'
+		}
+
+		err := ValidateDocUser(DocUser{Name: string(name), Age: 30})
+		require.Truef(t, errors.Is(err, ErrValueName), "Error is not equal")
+	})
+}
+
+// Test ValidateDocUserUpdate
+func TestValidateDocUserUpdate(t *testing.T) {
+
+	t.Run("Empty document", func(t *testing.T) {
+
+		err := ValidateDocUserUpdate(DocUser{})
+		require.Equalf(t, ErrEmptyDocument, err, "Error is not equal")
+	})
+
+	t.Run("Omitted fields", func(t *testing.T) {
+
+		err := ValidateDocUserUpdate(DocUser{Age: 30})
+		require.NoErrorf(t, err, "Unexpected error")
+	})
+
+	t.Run("Missing age", func(t *testing.T) {
+
+		err := ValidateDocUserUpdate(DocUser{Name: "Anna", Email: "anna@mail.com"})
+		require.Truef(t, errors.Is(err, ErrValueAge), "Error is not equal")
+	})
+
+	t.Run("Wrong email", func(t *testing.T) {
+
+		err := ValidateDocUserUpdate(DocUser{Age: 30, Email: "anna"})
+		require.Truef(t, errors.Is(err, ErrValueEmail), "Error is not equal")
+	})
+}
+
+// Test filters of document by id and by email
+func TestUserFilters(t *testing.T) {
+
+	t.Run("Id", func(t *testing.T) {
+
+		_, err := userIDFilter(primitive.NilObjectID)
+		require.Equalf(t, ErrEmptyID, err, "Error is not equal")
+
+		id := primitive.NewObjectID()
+		filter, err := userIDFilter(id)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.M{"_id": id}, filter, "Filter is not equal")
+	})
+
+	t.Run("Email", func(t *testing.T) {
+
+		_, err := userEmailFilter(" \t")
+		require.Equalf(t, ErrEmptyEmail, err, "Error is not equal")
+
+		filter, err := userEmailFilter(" Anna.B@Mail.COM ")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.M{"email": "anna.b@mail.com"}, filter, "Filter is not equal")
+	})
+}
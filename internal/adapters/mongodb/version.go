@@ -0,0 +1,153 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Update first document by filter, if version of document is equal version of doc.
+// Version 0 matches document without version. Returns updated document and error:
+// ErrConcurrentModification - version is changed, ErrUpdateDocument - document is not found.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	filter - filter
+//	doc - document with expected version
+func (r *Repository[T]) UpdateVersioned(ctx context.Context, collectionName string, filter interface{}, doc T) (updated T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return updated, err
+	}
+	if r.cfg.VersionField == "" {
+		return updated, ErrNotVersioned
+	}
+	if filter == nil {
+		filter = bson.M{}
+	}
+	doc = r.normalize(doc)
+	if err := r.validateUpdate(doc); err != nil {
+		return updated, err
+	}
+	update, err := r.updateDoc(ctx, doc)
+	if err != nil {
+		return updated, err
+	}
+
+	// Logic
+	collection := r.m.db.Collection(collectionName)
+
+	ctx, cancel := r.m.withTimeout(ctx)
+	defer cancel()
+
+	var expected interface{} = bson.M{"$exists": false}
+	if v := r.versionOf(doc); v != 0 {
+		expected = v
+	}
+	versionFilter := bson.M{"$and": bson.A{r.liveFilter(filter), bson.M{r.cfg.VersionField: expected}}}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	err = collection.FindOneAndUpdate(ctx, versionFilter, update, opts).Decode(&updated)
+	if err == nil {
+		return updated, nil
+	}
+
+	var zero T
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+	case mongo.IsDuplicateKeyError(err):
+		return zero, ErrDocumentExists
+	case isServerError(err, codeDocumentValidationFailure):
+		return zero, schemaValidationError(err)
+	default:
+		return zero, fmt.Errorf("Function FindOneAndUpdate, returned error: <%w>", err)
+	}
+
+	// Document is not found or has other version
+	n, err := collection.CountDocuments(ctx, r.liveFilter(filter), options.Count().SetLimit(1))
+	if err != nil {
+		return zero, fmt.Errorf("Function CountDocuments, returned error: <%w>", err)
+	}
+	if n > 0 {
+		return zero, ErrConcurrentModification
+	}
+
+	return zero, ErrUpdateDocument
+}
+
+// Update document by key, if version of document is equal version of doc. Returns updated document and error.
+//
+// Params:
+//
+//	ctx - context
+//	collectionName - name of collection
+//	key - value of key field
+//	doc - document with expected version
+func (r *Repository[T]) UpdateByKeyVersioned(ctx context.Context, collectionName string, key interface{}, doc T) (updated T, err error) {
+
+	// Check
+	if err := r.check(collectionName); err != nil {
+		return updated, err
+	}
+	filter, err := r.keyFilter(key)
+	if err != nil {
+		return updated, err
+	}
+
+	return r.UpdateVersioned(ctx, collectionName, filter, doc)
+}
+
+// Version of document. Returns version or 0.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) versionOf(doc T) int64 {
+
+	v := reflect.ValueOf(doc)
+	i, _ := bsonFieldIndex(v.Type(), r.cfg.VersionField)
+
+	if v.Field(i).CanInt() {
+		return v.Field(i).Int()
+	}
+
+	return int64(v.Field(i).Uint())
+}
+
+// Set the first version to document. Returns document.
+//
+// Params:
+//
+//	doc - document
+func (r *Repository[T]) firstVersion(doc T) T {
+
+	if r.cfg.VersionField == "" {
+		return doc
+	}
+
+	v := reflect.ValueOf(&doc).Elem()
+	i, _ := bsonFieldIndex(v.Type(), r.cfg.VersionField)
+
+	if v.Field(i).CanInt() {
+		v.Field(i).SetInt(1)
+	} else {
+		v.Field(i).SetUint(1)
+	}
+
+	return doc
+}
+
+// Increment of version. Returns element of update.
+func (r *Repository[T]) incVersion() bson.E {
+
+	return bson.E{Key: "$inc", Value: bson.D{{Key: r.cfg.VersionField, Value: 1}}}
+}
@@ -0,0 +1,94 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Document for tests of versioning.
+type docVersioned struct {
+	Code    string `bson:"code"`
+	Title   string `bson:"title,omitempty"`
+	Version int64  `bson:"version,omitempty"`
+}
+
+// Test NewRepository with version field.
+func TestNewRepositoryVersion(t *testing.T) {
+
+	_, err := NewRepository(&mongoDB{}, RepositoryConfig[docVersioned]{VersionField: "rev"})
+	require.Truef(t, errors.Is(err, ErrUnknownField), "Error is not equal")
+
+	_, err = NewRepository(&mongoDB{}, RepositoryConfig[docVersioned]{VersionField: "title"})
+	require.Truef(t, errors.Is(err, ErrNotSupportedType), "Error is not equal")
+
+	_, err = NewRepository(&mongoDB{}, RepositoryConfig[docVersioned]{VersionField: "version"})
+	require.NoErrorf(t, err, "Unexpected error")
+}
+
+// Test documents of update with versioning.
+func TestVersionUpdates(t *testing.T) {
+
+	r := &Repository[docVersioned]{cfg: RepositoryConfig[docVersioned]{VersionField: "version"}}
+	inc := bson.E{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}
+	ctx := context.Background()
+
+	t.Run("First version", func(t *testing.T) {
+
+		doc := r.firstVersion(docVersioned{Code: "c1", Version: 5})
+		assert.Equalf(t, int64(1), doc.Version, "Version is not equal")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+
+		update, err := r.updateDoc(ctx, docVersioned{Code: "c1", Version: 5})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.D{{Key: "$set", Value: bson.D{{Key: "code", Value: "c1"}}}, inc}
+		assert.Equalf(t, want, update, "Update is not equal")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+
+		update, err := r.replaceDoc(ctx, docVersioned{Code: "c1", Version: 5})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.D{
+			{Key: "$set", Value: bson.D{{Key: "code", Value: "c1"}}},
+			{Key: "$unset", Value: bson.D{{Key: "title", Value: ""}}},
+			inc,
+		}
+		assert.Equalf(t, want, update, "Update is not equal")
+	})
+
+	t.Run("Patch", func(t *testing.T) {
+
+		spec, err := NewPatch().Inc("count", 2).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		update, err := r.patchDoc(ctx, spec)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		want := bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: 2}, {Key: "version", Value: 1}}}}
+		assert.Equalf(t, want, update, "Update is not equal")
+
+		spec, err = NewPatch().Set("version", 1).Spec()
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = r.patchDoc(ctx, spec)
+		require.Truef(t, errors.Is(err, ErrNotCorrectPatch), "Error is not equal")
+	})
+
+	t.Run("Without versioning", func(t *testing.T) {
+
+		r := &Repository[docVersioned]{}
+
+		update, err := r.updateDoc(ctx, docVersioned{Code: "c1"})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, bson.D{{Key: "$set", Value: bson.D{{Key: "code", Value: "c1"}}}}, update, "Update is not equal")
+	})
+}
@@ -0,0 +1,318 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Type of operation of change.
+type OperationType string
+
+// Types of operations of changes.
+const (
+	OperationInsert     OperationType = "insert"
+	OperationUpdate     OperationType = "update"
+	OperationReplace    OperationType = "replace"
+	OperationDelete     OperationType = "delete"
+	OperationDrop       OperationType = "drop"
+	OperationInvalidate OperationType = "invalidate"
+)
+
+// Change of document.
+type ChangeEvent struct {
+	// Type of operation
+	Operation OperationType
+	// Name of collection
+	Collection string
+	// Id of changed document. Zero - key is not ObjectID or operation has no document
+	DocumentKey primitive.ObjectID
+	// Full document after change, decoded for collections of users. Nil - for delete and for other collections
+	Doc *DocUser
+	// Full document after change, as it is stored. Nil - for delete
+	FullDocument bson.Raw
+	// Changed fields of update. Nil - for other operations
+	Update *UpdateDescription
+	// Resume token of stream after the event
+	Token bson.Raw
+	// Time of operation
+	Time time.Time
+}
+
+// Changed fields of update.
+type UpdateDescription struct {
+	// New values of changed fields
+	UpdatedFields bson.M
+	// Names of removed fields
+	RemovedFields []string
+}
+
+// Settings of watch of changes.
+type WatchOptions struct {
+	// Name of collection. Empty - all collections of DB
+	Collection string
+	// Store of resume tokens. Nil - stream starts from current time
+	Checkpoints CheckpointStore
+	// Key of resume token in store. Empty - names of DB and collection
+	CheckpointKey string
+	// Names of collections of users, full documents of which are decoded. Empty - Collection, if it is not outbox
+	UserCollections []string
+	// Size of buffer of channel of events. Ignored with Checkpoints: channel is not buffered, so token is
+	// saved after the event is received
+	Buffer int
+}
+
+// Size of buffer of channel of events. Returns size.
+func (o WatchOptions) BufferSize() int {
+
+	if o.Checkpoints != nil {
+		return 0
+	}
+
+	return o.Buffer
+}
+
+// Check of settings. Returns error.
+func (o WatchOptions) Check() error {
+
+	if o.Buffer < 0 {
+		return ErrNegativeBuffer
+	}
+
+	return nil
+}
+
+// Key of resume token in store. Returns key.
+//
+// Params:
+//
+//	nameDB - name of DB
+func (o WatchOptions) Key(nameDB string) string {
+
+	if o.CheckpointKey != "" {
+		return o.CheckpointKey
+	}
+	if o.Collection == "" {
+		return nameDB
+	}
+
+	return nameDB + "." + o.Collection
+}
+
+// Names of collections of users, full documents of which are decoded. Returns names.
+//
+// Params:
+//
+//	outbox - name of collection of outbox, empty - without outbox
+func (o WatchOptions) Users(outbox string) []string {
+
+	if len(o.UserCollections) > 0 {
+		return o.UserCollections
+	}
+	if o.Collection == "" || o.Collection == outbox {
+		return nil
+	}
+
+	return []string{o.Collection}
+}
+
+// Watch changes of collection or DB. Events are sent to channel, which is closed on cancel of context
+// or on error. Error is sent to channel of errors before closing. Resume token of event is saved in
+// store of checkpoints after the event is received from channel, which is not buffered then. Changes of
+// collection of checkpoints are not watched. Full documents are decoded to DocUser for collections of
+// users only, documents of other collections are left raw. Returns channel of events, channel of errors and error.
+//
+// Params:
+//
+//	ctx - context
+//	opts - settings
+func (m *mongoDB) Watch(ctx context.Context, opts WatchOptions) (<-chan ChangeEvent, <-chan error, error) {
+
+	// Check
+	if err := opts.Check(); err != nil {
+		return nil, nil, err
+	}
+
+	key := opts.Key(m.nameDB)
+	users := opts.Users(m.outbox)
+
+	csOpts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if opts.Checkpoints != nil {
+		token, err := opts.Checkpoints.Load(ctx, key)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Function Load, returned error: <%w>", err)
+		}
+		if token != nil {
+			if err := checkResumeToken(token); err != nil {
+				return nil, nil, err
+			}
+			csOpts.SetStartAfter(token)
+		}
+	}
+
+	// Open
+	openCtx, cancel := m.withTimeout(ctx)
+	defer cancel()
+
+	var stream *mongo.ChangeStream
+	var err error
+	if opts.Collection != "" {
+		stream, err = m.db.Collection(opts.Collection).Watch(openCtx, mongo.Pipeline{}, csOpts)
+	} else {
+		stream, err = m.db.Watch(openCtx, watchPipeline(opts.Checkpoints), csOpts)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Function Watch, returned error: <%w>", err)
+	}
+
+	// Read
+	events := make(chan ChangeEvent, opts.BufferSize())
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(events)
+		defer stream.Close(context.WithoutCancel(ctx))
+
+		if err := watchStream(ctx, stream, events, opts.Checkpoints, key, users); err != nil {
+			errs <- err
+		}
+	}()
+
+	return events, errs, nil
+}
+
+// Pipeline of stream of DB. Saves of checkpoints produce changes, so collection of checkpoints is excluded.
+// Returns pipeline.
+//
+// Params:
+//
+//	checkpoints - store of resume tokens, nil - without store
+func watchPipeline(checkpoints CheckpointStore) mongo.Pipeline {
+
+	c, ok := checkpoints.(*collectionCheckpoints)
+	if !ok {
+		return mongo.Pipeline{}
+	}
+
+	return mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "ns.coll", Value: bson.D{{Key: "$ne", Value: c.collectionName}}}}}}}
+}
+
+// Send events of stream to channel until cancel of context or end of stream. Returns error.
+//
+// Params:
+//
+//	ctx - context
+//	stream - stream of changes
+//	events - channel of events
+//	checkpoints - store of resume tokens, nil - without store
+//	key - key of resume token
+//	users - names of collections of users
+func watchStream(ctx context.Context, stream *mongo.ChangeStream, events chan<- ChangeEvent, checkpoints CheckpointStore, key string,
+	users []string) error {
+
+	for stream.Next(ctx) {
+
+		ev, err := decodeChange(slices.Clone(stream.Current), users)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if checkpoints != nil {
+			if err := checkpoints.Save(ctx, key, ev.Token); err != nil && ctx.Err() == nil {
+				return fmt.Errorf("Function Save, returned error: <%w>", err)
+			}
+		}
+	}
+
+	if err := stream.Err(); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("Function Next, returned error: <%w>", err)
+	}
+
+	return nil
+}
+
+// Document of change of stream.
+type changeDoc struct {
+	ID            bson.Raw `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	NS            struct {
+		Coll string `bson:"coll"`
+	} `bson:"ns"`
+	DocumentKey       bson.Raw `bson:"documentKey"`
+	FullDocument      bson.Raw `bson:"fullDocument"`
+	UpdateDescription *struct {
+		UpdatedFields bson.M   `bson:"updatedFields"`
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+	ClusterTime primitive.Timestamp `bson:"clusterTime"`
+}
+
+// Event from document of change. Full document is decoded for collections of users only. Returns event and error.
+//
+// Params:
+//
+//	raw - document of change
+//	users - names of collections of users
+func decodeChange(raw bson.Raw, users []string) (ChangeEvent, error) {
+
+	var d changeDoc
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return ChangeEvent{}, fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+	}
+
+	ev := ChangeEvent{
+		Operation:    OperationType(d.OperationType),
+		Collection:   d.NS.Coll,
+		Token:        d.ID,
+		Time:         time.Unix(int64(d.ClusterTime.T), 0).UTC(),
+		FullDocument: d.FullDocument,
+	}
+	if d.FullDocument != nil && slices.Contains(users, d.NS.Coll) {
+		var doc DocUser
+		if err := bson.Unmarshal(d.FullDocument, &doc); err != nil {
+			return ChangeEvent{}, fmt.Errorf("Function Unmarshal, returned error: <%w>", err)
+		}
+		ev.Doc = &doc
+	}
+	if d.DocumentKey != nil {
+		ev.DocumentKey, _ = d.DocumentKey.Lookup("_id").ObjectIDOK()
+	}
+	if d.UpdateDescription != nil {
+		ev.Update = &UpdateDescription{
+			UpdatedFields: d.UpdateDescription.UpdatedFields,
+			RemovedFields: d.UpdateDescription.RemovedFields,
+		}
+	}
+
+	return ev, nil
+}
+
+// Check of resume token. Returns error.
+//
+// Params:
+//
+//	token - resume token
+func checkResumeToken(token bson.Raw) error {
+
+	if err := token.Validate(); err != nil {
+		return ErrNotCorrectResumeToken
+	}
+	if _, err := token.LookupErr("_data"); err != nil {
+		return ErrNotCorrectResumeToken
+	}
+
+	return nil
+}
@@ -0,0 +1,212 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Test settings of watch
+func TestWatchOptions(t *testing.T) {
+
+	require.NoErrorf(t, WatchOptions{}.Check(), "Unexpected error")
+	require.Equalf(t, ErrNegativeBuffer, WatchOptions{Buffer: -1}.Check(), "Error is not equal")
+
+	assert.Equalf(t, "db", WatchOptions{}.Key("db"), "Key is not equal")
+	assert.Equalf(t, "db.users", WatchOptions{Collection: "users"}.Key("db"), "Key is not equal")
+	assert.Equalf(t, "own", WatchOptions{Collection: "users", CheckpointKey: "own"}.Key("db"), "Key is not equal")
+
+	assert.Nilf(t, WatchOptions{}.Users("outbox"), "Collections are not nil")
+	assert.Nilf(t, WatchOptions{Collection: "outbox"}.Users("outbox"), "Collections are not nil")
+	assert.Equalf(t, []string{"users"}, WatchOptions{Collection: "users"}.Users("outbox"), "Collections are not equal")
+	assert.Equalf(t, []string{"a", "b"}, WatchOptions{Collection: "a", UserCollections: []string{"a", "b"}}.Users(""),
+		"Collections are not equal")
+
+	assert.Equalf(t, 4, WatchOptions{Buffer: 4}.BufferSize(), "Size is not equal")
+	assert.Equalf(t, 0, WatchOptions{Buffer: 4, Checkpoints: NewMemoryCheckpoints()}.BufferSize(), "Size is not equal")
+}
+
+// Test decodeChange
+func TestDecodeChange(t *testing.T) {
+
+	id := primitive.NewObjectID()
+	token := bson.M{"_data": "8263"}
+
+	t.Run("Update", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{
+			"_id":           token,
+			"operationType": "update",
+			"ns":            bson.M{"db": "db", "coll": "users"},
+			"documentKey":   bson.M{"_id": id},
+			"fullDocument":  bson.M{"_id": id, "name": "Anna", "age": 31},
+			"updateDescription": bson.M{
+				"updatedFields": bson.M{"age": 31},
+				"removedFields": bson.A{"email"},
+			},
+			"clusterTime": primitive.Timestamp{T: 100, I: 1},
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev, err := decodeChange(raw, []string{"users"})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		assert.Equalf(t, OperationUpdate, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, "users", ev.Collection, "Collection is not equal")
+		assert.Equalf(t, id, ev.DocumentKey, "Key is not equal")
+		require.NotNilf(t, ev.Doc, "Document is nil")
+		assert.Equalf(t, DocUser{ID: id, Name: "Anna", Age: 31}, *ev.Doc, "Document is not equal")
+		assert.Equalf(t, "Anna", ev.FullDocument.Lookup("name").StringValue(), "Document is not equal")
+		require.NotNilf(t, ev.Update, "Description of update is nil")
+		assert.Equalf(t, bson.M{"age": int32(31)}, ev.Update.UpdatedFields, "Updated fields are not equal")
+		assert.Equalf(t, []string{"email"}, ev.Update.RemovedFields, "Removed fields are not equal")
+		assert.Equalf(t, time.Unix(100, 0).UTC(), ev.Time, "Time is not equal")
+		require.NoErrorf(t, checkResumeToken(ev.Token), "Unexpected error")
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{
+			"_id":           token,
+			"operationType": "delete",
+			"ns":            bson.M{"db": "db", "coll": "users"},
+			"documentKey":   bson.M{"_id": id},
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev, err := decodeChange(raw, nil)
+		require.NoErrorf(t, err, "Unexpected error")
+
+		assert.Equalf(t, OperationDelete, ev.Operation, "Operation is not equal")
+		assert.Equalf(t, id, ev.DocumentKey, "Key is not equal")
+		assert.Nilf(t, ev.Doc, "Document is not nil")
+		assert.Nilf(t, ev.Update, "Description of update is not nil")
+	})
+
+	t.Run("Key is not ObjectID", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{
+			"_id":           token,
+			"operationType": "delete",
+			"ns":            bson.M{"db": "db", "coll": "checkpoints"},
+			"documentKey":   bson.M{"_id": "db.users"},
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev, err := decodeChange(raw, nil)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Truef(t, ev.DocumentKey.IsZero(), "Key is not zero")
+	})
+
+	t.Run("Other collection", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{
+			"_id":           token,
+			"operationType": "insert",
+			"ns":            bson.M{"db": "db", "coll": "checkpoints"},
+			"documentKey":   bson.M{"_id": "db.users"},
+			"fullDocument":  bson.M{"_id": "db.users", "token": token},
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		ev, err := decodeChange(raw, []string{"users"})
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, OperationInsert, ev.Operation, "Operation is not equal")
+		assert.Nilf(t, ev.Doc, "Document is not nil")
+		assert.Equalf(t, "db.users", ev.FullDocument.Lookup("_id").StringValue(), "Document is not equal")
+
+		ev, err = decodeChange(raw, nil)
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Nilf(t, ev.Doc, "Document is not nil")
+		assert.NotNilf(t, ev.FullDocument, "Document is nil")
+	})
+
+	t.Run("Wrong document of users", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{
+			"_id":           token,
+			"operationType": "insert",
+			"ns":            bson.M{"db": "db", "coll": "users"},
+			"documentKey":   bson.M{"_id": "key"},
+			"fullDocument":  bson.M{"_id": "key"},
+		})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = decodeChange(raw, []string{"users"})
+		require.Errorf(t, err, "Error is not exists")
+	})
+
+	t.Run("Wrong document", func(t *testing.T) {
+
+		raw, err := bson.Marshal(bson.M{"operationType": 1})
+		require.NoErrorf(t, err, "Unexpected error")
+
+		_, err = decodeChange(raw, nil)
+		require.Errorf(t, err, "Error is not exists")
+	})
+}
+
+// Test watchPipeline
+func TestWatchPipeline(t *testing.T) {
+
+	assert.Equalf(t, mongo.Pipeline{}, watchPipeline(nil), "Pipeline is not equal")
+	assert.Equalf(t, mongo.Pipeline{}, watchPipeline(NewMemoryCheckpoints()), "Pipeline is not equal")
+
+	want := mongo.Pipeline{{{Key: "$match", Value: bson.D{{Key: "ns.coll", Value: bson.D{{Key: "$ne", Value: "checkpoints"}}}}}}}
+	assert.Equalf(t, want, watchPipeline(&collectionCheckpoints{collectionName: "checkpoints"}), "Pipeline is not equal")
+}
+
+// Test checkResumeToken
+func TestCheckResumeToken(t *testing.T) {
+
+	token, err := bson.Marshal(bson.M{"_data": "8263"})
+	require.NoErrorf(t, err, "Unexpected error")
+	require.NoErrorf(t, checkResumeToken(token), "Unexpected error")
+
+	wrong, err := bson.Marshal(bson.M{"token": 1})
+	require.NoErrorf(t, err, "Unexpected error")
+	require.Equalf(t, ErrNotCorrectResumeToken, checkResumeToken(wrong), "Error is not equal")
+
+	require.Equalf(t, ErrNotCorrectResumeToken, checkResumeToken(bson.Raw{0x01}), "Error is not equal")
+}
+
+// Test stores of checkpoints
+func TestCheckpoints(t *testing.T) {
+
+	ctx := context.Background()
+
+	t.Run("Memory", func(t *testing.T) {
+
+		store := NewMemoryCheckpoints()
+
+		token, err := store.Load(ctx, "key")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Nilf(t, token, "Token is not nil")
+
+		saved, err := bson.Marshal(bson.M{"_data": "01"})
+		require.NoErrorf(t, err, "Unexpected error")
+		require.NoErrorf(t, store.Save(ctx, "key", saved), "Unexpected error")
+
+		// Store keeps own copy
+		saved[len(saved)-2] = '2'
+
+		token, err = store.Load(ctx, "key")
+		require.NoErrorf(t, err, "Unexpected error")
+		assert.Equalf(t, "01", token.Lookup("_data").StringValue(), "Token is not equal")
+	})
+
+	t.Run("Collection", func(t *testing.T) {
+
+		_, err := NewCollectionCheckpoints(nil, "checkpoints")
+		require.Equalf(t, ErrNotSupportedDB, err, "Error is not equal")
+
+		_, err = NewCollectionCheckpoints(&mongoDB{}, "")
+		require.Equalf(t, ErrEmptyCollectionsName, err, "Error is not equal")
+	})
+}